@@ -0,0 +1,208 @@
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// State represents the circuit breaker state
+type State int
+
+const (
+	StateClosed   State = iota // calls flow normally
+	StateOpen                  // calls fail fast until the cool-down ends
+	StateHalfOpen              // a limited number of trial calls probe the dependency
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrOpen is returned (wrapped in *OpenError) when the breaker rejects a call
+var ErrOpen = errors.New("circuit breaker aberto")
+
+// OpenError reports a call rejected by an open breaker
+type OpenError struct {
+	Name       string
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Name, ErrOpen.Error())
+}
+
+func (e *OpenError) Unwrap() error {
+	return ErrOpen
+}
+
+// Settings configures when the breaker opens and how it recovers
+type Settings struct {
+	FailureThreshold int           // consecutive failures that open the breaker
+	CoolDown         time.Duration // time spent open before allowing trial calls
+	HalfOpenMaxCalls int           // concurrent trial calls allowed while half-open
+}
+
+// DefaultSettings returns the settings used when nothing is configured
+func DefaultSettings() Settings {
+	return Settings{
+		FailureThreshold: 5,
+		CoolDown:         30 * time.Second,
+		HalfOpenMaxCalls: 1,
+	}
+}
+
+// Breaker is a closed/open/half-open circuit breaker for one external dependency
+type Breaker struct {
+	name     string
+	settings Settings
+	now      func() time.Time
+
+	mu               sync.Mutex
+	state            State
+	failures         int
+	openedAt         time.Time
+	halfOpenInFlight int
+	generation       uint64 // incremented on every state change
+}
+
+// New creates a circuit breaker for the named dependency
+func New(name string, settings Settings) *Breaker {
+	if settings.FailureThreshold < 1 {
+		settings.FailureThreshold = 1
+	}
+	if settings.HalfOpenMaxCalls < 1 {
+		settings.HalfOpenMaxCalls = 1
+	}
+	return &Breaker{
+		name:     name,
+		settings: settings,
+		now:      time.Now,
+	}
+}
+
+// Name returns the dependency protected by the breaker
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// Allow reports whether a call may proceed and returns the generation the
+// call belongs to. Every allowed call must be followed by exactly one Record
+// with that generation and its outcome.
+func (b *Breaker) Allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance()
+
+	switch b.state {
+	case StateOpen:
+		return 0, &OpenError{Name: b.name, RetryAfter: b.settings.CoolDown - b.now().Sub(b.openedAt)}
+	case StateHalfOpen:
+		if b.halfOpenInFlight >= b.settings.HalfOpenMaxCalls {
+			return 0, &OpenError{Name: b.name}
+		}
+		b.halfOpenInFlight++
+	}
+	return b.generation, nil
+}
+
+// Record registers the outcome of an allowed call; failed must only be true
+// for failures of the dependency itself (network errors, 5xx), not for
+// expected answers such as "not found". Outcomes of calls allowed before the
+// last state change are ignored, so a slow call started while closed cannot
+// close a half-open breaker or take the place of its trial call.
+func (b *Breaker) Record(generation uint64, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case StateHalfOpen:
+		if b.halfOpenInFlight > 0 {
+			b.halfOpenInFlight--
+		}
+		if failed {
+			b.open()
+		} else {
+			b.state = StateClosed
+			b.failures = 0
+			b.generation++
+		}
+	case StateClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.settings.FailureThreshold {
+			b.open()
+		}
+	}
+}
+
+// State returns the current state
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance()
+	return b.state
+}
+
+// Attributes describes the breaker for span attributes
+func (b *Breaker) Attributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("circuit_breaker.name", b.name),
+		attribute.String("circuit_breaker.state", b.State().String()),
+	}
+}
+
+// Stats returns the breaker status for health checks
+func (b *Breaker) Stats() map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance()
+	stats := map[string]interface{}{
+		"state":                b.state.String(),
+		"consecutive_failures": b.failures,
+	}
+	if b.state == StateOpen {
+		stats["retry_after_seconds"] = (b.settings.CoolDown - b.now().Sub(b.openedAt)).Seconds()
+	}
+	return stats
+}
+
+// open moves the breaker to the open state; must be called with mu held
+func (b *Breaker) open() {
+	b.state = StateOpen
+	b.openedAt = b.now()
+	b.failures = 0
+	b.halfOpenInFlight = 0
+	b.generation++
+}
+
+// advance moves an open breaker to half-open once the cool-down has elapsed; must be called with mu held
+func (b *Breaker) advance() {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.settings.CoolDown {
+		b.state = StateHalfOpen
+		b.halfOpenInFlight = 0
+		b.generation++
+	}
+}
@@ -0,0 +1,134 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestBreaker(now *time.Time) *Breaker {
+	b := New("test", Settings{FailureThreshold: 3, CoolDown: 10 * time.Second, HalfOpenMaxCalls: 1})
+	b.now = func() time.Time { return *now }
+	return b
+}
+
+func TestBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	now := time.Now()
+	b := newTestBreaker(&now)
+
+	for i := 0; i < 2; i++ {
+		generation, err := b.Allow()
+		if err != nil {
+			t.Fatalf("Allow() erro inesperado = %v", err)
+		}
+		b.Record(generation, true)
+	}
+
+	// Um sucesso zera a contagem de falhas consecutivas
+	generation, _ := b.Allow()
+	b.Record(generation, false)
+	if b.State() != StateClosed {
+		t.Fatalf("State() = %v, esperava closed", b.State())
+	}
+
+	for i := 0; i < 3; i++ {
+		generation, _ := b.Allow()
+		b.Record(generation, true)
+	}
+	if b.State() != StateOpen {
+		t.Fatalf("State() = %v, esperava open", b.State())
+	}
+
+	_, err := b.Allow()
+	if !errors.Is(err, ErrOpen) {
+		t.Fatalf("Allow() erro = %v, esperava ErrOpen", err)
+	}
+
+	var openErr *OpenError
+	if !errors.As(err, &openErr) || openErr.RetryAfter != 10*time.Second {
+		t.Errorf("Allow() erro = %#v, esperava OpenError com RetryAfter de 10s", err)
+	}
+}
+
+func TestBreaker_HalfOpenRecovery(t *testing.T) {
+	now := time.Now()
+	b := newTestBreaker(&now)
+
+	for i := 0; i < 3; i++ {
+		generation, _ := b.Allow()
+		b.Record(generation, true)
+	}
+
+	now = now.Add(10 * time.Second)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("State() = %v, esperava half-open", b.State())
+	}
+
+	generation, err := b.Allow()
+	if err != nil {
+		t.Fatalf("Allow() em half-open erro inesperado = %v", err)
+	}
+	if _, err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("segunda chamada em half-open deveria ser rejeitada, erro = %v", err)
+	}
+
+	b.Record(generation, false)
+	if b.State() != StateClosed {
+		t.Errorf("State() = %v, esperava closed após sucesso em half-open", b.State())
+	}
+}
+
+func TestBreaker_HalfOpenFailureReopens(t *testing.T) {
+	now := time.Now()
+	b := newTestBreaker(&now)
+
+	for i := 0; i < 3; i++ {
+		generation, _ := b.Allow()
+		b.Record(generation, true)
+	}
+
+	now = now.Add(10 * time.Second)
+	generation, _ := b.Allow()
+	b.Record(generation, true)
+
+	if b.State() != StateOpen {
+		t.Errorf("State() = %v, esperava open após falha em half-open", b.State())
+	}
+}
+
+func TestBreaker_IgnoresOutcomesFromEarlierGeneration(t *testing.T) {
+	now := time.Now()
+	b := newTestBreaker(&now)
+
+	// Chamada lenta iniciada com o breaker fechado
+	slow, err := b.Allow()
+	if err != nil {
+		t.Fatalf("Allow() erro inesperado = %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		generation, _ := b.Allow()
+		b.Record(generation, true)
+	}
+
+	now = now.Add(10 * time.Second)
+	trial, err := b.Allow()
+	if err != nil {
+		t.Fatalf("Allow() em half-open erro inesperado = %v", err)
+	}
+
+	// O sucesso da chamada lenta não fecha o breaker nem libera a vaga da
+	// chamada de teste
+	b.Record(slow, false)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("State() = %v, esperava half-open após resultado de geração anterior", b.State())
+	}
+	if _, err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("segunda chamada em half-open deveria ser rejeitada, erro = %v", err)
+	}
+
+	b.Record(trial, true)
+	if b.State() != StateOpen {
+		t.Errorf("State() = %v, esperava open após falha da chamada de teste", b.State())
+	}
+}
@@ -0,0 +1,50 @@
+package models
+
+// AlertSubscriptionRequest representa a criação de uma assinatura de alerta
+// de temperatura: o webhook é chamado quando a temperatura do CEP, na
+// unidade informada, fica acima (above) ou abaixo (below) do limite
+type AlertSubscriptionRequest struct {
+	CEP        string   `json:"cep"`
+	Unit       string   `json:"unit"` // C, F ou K
+	Threshold  *float64 `json:"threshold"`
+	Direction  string   `json:"direction"` // above ou below
+	WebhookURL string   `json:"webhook_url"`
+}
+
+// AlertSubscription representa uma assinatura de alerta. Secret, usado para
+// assinar as entregas, só é retornado na criação.
+type AlertSubscription struct {
+	ID          string   `json:"id"`
+	CEP         string   `json:"cep"`
+	Unit        string   `json:"unit"`
+	Threshold   float64  `json:"threshold"`
+	Direction   string   `json:"direction"`
+	WebhookURL  string   `json:"webhook_url"`
+	Secret      string   `json:"secret,omitempty"`
+	CreatedAt   string   `json:"created_at"` // RFC 3339
+	Triggered   bool     `json:"triggered"`
+	LastValue   *float64 `json:"last_value,omitempty"`
+	LastChecked string   `json:"last_checked,omitempty"`
+}
+
+// AlertEvent representa o corpo enviado ao webhook quando o limite é cruzado
+type AlertEvent struct {
+	ID             string  `json:"id"`
+	SubscriptionID string  `json:"subscription_id"`
+	CEP            string  `json:"cep"`
+	City           string  `json:"city"`
+	Unit           string  `json:"unit"`
+	Threshold      float64 `json:"threshold"`
+	Direction      string  `json:"direction"`
+	Temperature    float64 `json:"temperature"`
+	TriggeredAt    string  `json:"triggered_at"` // RFC 3339
+}
+
+// AlertDeadLetter representa uma entrega que falhou em todas as tentativas
+type AlertDeadLetter struct {
+	Event      AlertEvent `json:"event"`
+	WebhookURL string     `json:"webhook_url"`
+	Attempts   int        `json:"attempts"`
+	LastError  string     `json:"last_error"`
+	FailedAt   string     `json:"failed_at"` // RFC 3339
+}
@@ -20,6 +20,14 @@ type ErrorResponse struct {
 
 // Constants para mensagens de erro padrão
 const (
-	ErrInvalidZipcode  = "invalid zipcode"
-	ErrZipcodeNotFound = "can not find zipcode"
+	ErrInvalidZipcode   = "invalid zipcode"
+	ErrZipcodeNotFound  = "can not find zipcode"
+	ErrInvalidDays      = "invalid days"
+	ErrInvalidDate      = "invalid date"
+	ErrNoHistory        = "history not available for date"
+	ErrNoReadings       = "no readings for period"
+	ErrAlertNotFound    = "alert subscription not found"
+	ErrInvalidScale     = "invalid temperature scale"
+	ErrInvalidPrecision = "invalid precision"
+	ErrInvalidMode      = "invalid temperature mode"
 )
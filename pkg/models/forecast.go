@@ -0,0 +1,30 @@
+package models
+
+// ForecastResponse representa a previsão do tempo da cidade de um CEP, dia a
+// dia e hora a hora. Horários no fuso da cidade (RFC 3339).
+type ForecastResponse struct {
+	CEP      string          `json:"cep"`
+	City     string          `json:"city"`
+	UF       string          `json:"uf"`
+	Timezone string          `json:"timezone,omitempty"`
+	Days     []DailyForecast `json:"days"`
+}
+
+// DailyForecast representa a previsão de um dia, com as temperaturas mínima e máxima
+type DailyForecast struct {
+	Date                string           `json:"date"` // 2006-01-02
+	Min                 TemperatureUnits `json:"min"`
+	Max                 TemperatureUnits `json:"max"`
+	ChanceOfRainPercent int              `json:"chance_of_rain_percent"`
+	Condition           *Condition       `json:"condition,omitempty"`
+	Hours               []HourlyForecast `json:"hours"`
+}
+
+// HourlyForecast representa a previsão de uma hora
+type HourlyForecast struct {
+	Time                string           `json:"time"`
+	Temperature         TemperatureUnits `json:"temperature"`
+	ChanceOfRainPercent int              `json:"chance_of_rain_percent"`
+	IsDay               bool             `json:"is_day"`
+	Condition           *Condition       `json:"condition,omitempty"`
+}
@@ -0,0 +1,22 @@
+package models
+
+// HistoryResponse representa as temperaturas registradas em uma data na
+// cidade de um CEP. Horários no fuso da cidade (RFC 3339).
+type HistoryResponse struct {
+	CEP      string              `json:"cep"`
+	City     string              `json:"city"`
+	UF       string              `json:"uf"`
+	Date     string              `json:"date"` // 2006-01-02
+	Timezone string              `json:"timezone,omitempty"`
+	Provider string              `json:"provider"`
+	Min      TemperatureUnits    `json:"min"`
+	Max      TemperatureUnits    `json:"max"`
+	Avg      *TemperatureUnits   `json:"avg,omitempty"`
+	Hours    []HourlyTemperature `json:"hours"`
+}
+
+// HourlyTemperature representa a temperatura registrada em uma hora
+type HourlyTemperature struct {
+	Time        string           `json:"time"`
+	Temperature TemperatureUnits `json:"temperature"`
+}
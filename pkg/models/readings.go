@@ -0,0 +1,27 @@
+package models
+
+// ReadingsSummaryResponse representa a mínima, a máxima e a média das
+// temperaturas servidas para uma cidade em um período, opcionalmente
+// divididas em intervalos. Observations conta as medições registradas: a
+// mesma medição do provedor servida várias vezes conta uma única vez.
+type ReadingsSummaryResponse struct {
+	City         string           `json:"city"`
+	From         string           `json:"from"` // RFC 3339
+	To           string           `json:"to"`
+	Observations int              `json:"observations"`
+	Min          TemperatureUnits `json:"min"`
+	Max          TemperatureUnits `json:"max"`
+	Avg          TemperatureUnits `json:"avg"`
+	Providers    map[string]int   `json:"providers"`
+	Buckets      []ReadingsBucket `json:"buckets,omitempty"`
+}
+
+// ReadingsBucket representa as temperaturas de um intervalo do período
+type ReadingsBucket struct {
+	From         string           `json:"from"`
+	To           string           `json:"to"`
+	Observations int              `json:"observations"`
+	Min          TemperatureUnits `json:"min"`
+	Max          TemperatureUnits `json:"max"`
+	Avg          TemperatureUnits `json:"avg"`
+}
@@ -0,0 +1,45 @@
+package models
+
+// TemperatureScale identifica uma escala de temperatura nas opções de conversão
+type TemperatureScale string
+
+// Escalas de temperatura aceitas no parâmetro scales
+const (
+	ScaleCelsius    TemperatureScale = "C"
+	ScaleFahrenheit TemperatureScale = "F"
+	ScaleKelvin     TemperatureScale = "K"
+	ScaleRankine    TemperatureScale = "R"
+	ScaleReaumur    TemperatureScale = "Re"
+	ScaleDelisle    TemperatureScale = "De"
+)
+
+// Modos de conversão aceitos no parâmetro mode
+const (
+	TemperatureModeSpec   = "spec"   // K = C + 273, o contrato original
+	TemperatureModeStrict = "strict" // K = C + 273.15
+)
+
+// Opções de conversão do POST /temperature, como parâmetros de consulta ou
+// cabeçalhos; o parâmetro de consulta tem precedência sobre o cabeçalho
+const (
+	TemperatureScalesParam    = "scales"    // lista separada por vírgulas, ex.: "C,K,Re"
+	TemperaturePrecisionParam = "precision" // casas decimais, de 0 a 6
+	TemperatureModeParam      = "mode"      // spec ou strict
+
+	TemperatureScalesHeader    = "X-Temperature-Scales"
+	TemperaturePrecisionHeader = "X-Temperature-Precision"
+	TemperatureModeHeader      = "X-Temperature-Mode"
+)
+
+// ScaledTemperatureResponse representa a temperatura nas escalas pedidas nas
+// opções de conversão; escalas não pedidas são omitidas. Com C, F e K e uma
+// casa decimal, o JSON é idêntico ao de TemperatureResponse.
+type ScaledTemperatureResponse struct {
+	City   string   `json:"city"`
+	TempC  *float64 `json:"temp_C,omitempty"`
+	TempF  *float64 `json:"temp_F,omitempty"`
+	TempK  *float64 `json:"temp_K,omitempty"`
+	TempR  *float64 `json:"temp_R,omitempty"`
+	TempRe *float64 `json:"temp_Re,omitempty"`
+	TempDe *float64 `json:"temp_De,omitempty"`
+}
@@ -0,0 +1,104 @@
+package models
+
+// WeatherConditionsVersion versão do documento de condições meteorológicas;
+// muda somente em alterações incompatíveis do formato
+const WeatherConditionsVersion = "1"
+
+// WeatherConditionsResponse representa as condições meteorológicas atuais
+// da cidade de um CEP, independente do provedor de clima. Valores sempre em
+// unidades métricas; campos que o provedor não informa são omitidos.
+type WeatherConditionsResponse struct {
+	Version     string             `json:"version"`
+	Location    ConditionsLocation `json:"location"`
+	Current     CurrentConditions  `json:"current"`
+	Observation *Observation       `json:"observation,omitempty"`
+}
+
+// Observation representa o horário local da cidade e quando as condições
+// foram medidas, para o cliente avaliar se a leitura está atualizada
+type Observation struct {
+	Timezone   string `json:"timezone"`              // IANA, ex.: "America/Sao_Paulo"
+	LocalTime  string `json:"local_time"`            // horário atual no fuso da cidade (RFC 3339)
+	ObservedAt string `json:"observed_at,omitempty"` // last_updated do provedor (RFC 3339)
+	AgeSeconds *int64 `json:"age_seconds,omitempty"` // idade da leitura no momento da resposta
+}
+
+// ConditionsLocation representa a localização do CEP consultado
+type ConditionsLocation struct {
+	CEP          string  `json:"cep"`
+	City         string  `json:"city"`
+	Neighborhood string  `json:"neighborhood,omitempty"`
+	UF           string  `json:"uf"`
+	State        string  `json:"state,omitempty"`
+	IBGE         string  `json:"ibge,omitempty"`
+	Country      string  `json:"country,omitempty"`
+	Latitude     float64 `json:"latitude,omitempty"`
+	Longitude    float64 `json:"longitude,omitempty"`
+}
+
+// TemperatureUnits representa uma temperatura em Celsius, Fahrenheit e Kelvin
+type TemperatureUnits struct {
+	C float64 `json:"C"`
+	F float64 `json:"F"`
+	K float64 `json:"K"`
+}
+
+// CurrentConditions representa as condições meteorológicas atuais
+type CurrentConditions struct {
+	Temperature     TemperatureUnits `json:"temperature"`
+	FeelsLike       TemperatureUnits `json:"feels_like"`
+	HumidityPercent int              `json:"humidity_percent"`
+	CloudPercent    int              `json:"cloud_percent"`
+	Wind            Wind             `json:"wind"`
+	PressureHPa     float64          `json:"pressure_hpa,omitempty"`
+	PrecipitationMm float64          `json:"precipitation_mm"`
+	VisibilityKm    float64          `json:"visibility_km,omitempty"`
+	UVIndex         float64          `json:"uv_index"`
+	IsDay           bool             `json:"is_day"`
+	Condition       *Condition       `json:"condition,omitempty"`
+	AirQuality      *AirQuality      `json:"air_quality,omitempty"`
+}
+
+// Wind representa velocidade, rajada e direção do vento
+type Wind struct {
+	SpeedKph         float64 `json:"speed_kph"`
+	GustKph          float64 `json:"gust_kph,omitempty"`
+	DirectionDegrees int     `json:"direction_degrees"`
+	Direction        string  `json:"direction,omitempty"` // ponto cardeal, ex.: "NNE"
+}
+
+// Condition representa a descrição do tempo e o ícone correspondente
+type Condition struct {
+	Text string `json:"text"`
+	Icon string `json:"icon,omitempty"` // URL absoluta
+}
+
+// Categorias do índice US EPA de qualidade do ar, do índice 1 ao 6
+const (
+	AirQualityGood                        = "good"
+	AirQualityModerate                    = "moderate"
+	AirQualityUnhealthyForSensitiveGroups = "unhealthy_for_sensitive_groups"
+	AirQualityUnhealthy                   = "unhealthy"
+	AirQualityVeryUnhealthy               = "very_unhealthy"
+	AirQualityHazardous                   = "hazardous"
+)
+
+// AirQuality representa a qualidade do ar atual; presente somente quando a
+// qualidade do ar está habilitada e o provedor a informou
+type AirQuality struct {
+	USEPAIndex   int        `json:"us_epa_index"`             // 1 (boa) a 6 (perigosa)
+	Category     string     `json:"category,omitempty"`       // vazia se o índice estiver fora de 1 a 6
+	GBDefraIndex int        `json:"gb_defra_index,omitempty"` // 1 a 10; somente WeatherAPI
+	Pollutants   Pollutants `json:"pollutants"`
+	Provider     string     `json:"provider"`
+}
+
+// Pollutants representa as concentrações dos poluentes, em μg/m³
+type Pollutants struct {
+	CO    float64 `json:"co"`
+	NO2   float64 `json:"no2"`
+	O3    float64 `json:"o3"`
+	SO2   float64 `json:"so2"`
+	PM2_5 float64 `json:"pm2_5"`
+	PM10  float64 `json:"pm10"`
+}
@@ -0,0 +1,222 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lcidral/goExpertOtel/pkg/telemetry"
+)
+
+// Policy defines how failed calls to external APIs are retried
+type Policy struct {
+	MaxAttempts int           // total attempts, including the first one
+	BaseDelay   time.Duration // delay before the first retry
+	MaxDelay    time.Duration // upper bound for a single delay (also caps Retry-After)
+	Jitter      float64       // fraction of the delay randomized, between 0 and 1
+}
+
+// DefaultPolicy returns the policy used when nothing is configured
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts: 3,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    2 * time.Second,
+		Jitter:      0.5,
+	}
+}
+
+// Backoff returns the delay before the given retry (1 for the first retry),
+// growing exponentially from BaseDelay and randomized by Jitter
+func (p Policy) Backoff(retry int) time.Duration {
+	if retry < 1 || p.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := float64(p.BaseDelay) * math.Pow(2, float64(retry-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+
+	jitter := math.Min(math.Max(p.Jitter, 0), 1)
+	delay = delay*(1-jitter) + delay*jitter*rand.Float64()
+
+	return time.Duration(delay)
+}
+
+// IsRetryableStatus reports whether an HTTP status indicates a transient failure
+func IsRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusRequestTimeout,
+		http.StatusTooEarly,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// RetryAfter parses the Retry-After header (seconds or HTTP date)
+func RetryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// Transport is an http.RoundTripper that retries transient failures
+// (network errors and retryable statuses) according to a Policy. Every
+// attempt is recorded as an event on the span active in the request context.
+type Transport struct {
+	base   http.RoundTripper
+	policy Policy
+	target string
+}
+
+// NewTransport wraps base with retries; target identifies the external API in spans and metrics
+func NewTransport(base http.RoundTripper, policy Policy, target string) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{
+		base:   base,
+		policy: policy,
+		target: target,
+	}
+}
+
+var (
+	attemptsCounter, _ = telemetry.Meter().Int64Counter("http.client.attempts",
+		metric.WithDescription("Attempts made to external APIs, including the first one"),
+	)
+	retriesCounter, _ = telemetry.Meter().Int64Counter("http.client.retries",
+		metric.WithDescription("Retries made to external APIs after a transient failure"),
+	)
+)
+
+// RoundTrip executes the request, retrying transient failures
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	span := trace.SpanFromContext(ctx)
+	targetAttr := attribute.String("http.target_api", t.target)
+
+	maxAttempts := t.policy.MaxAttempts
+	if maxAttempts < 1 || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		// Without GetBody the body can't be replayed, so the request is sent only once
+		maxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 {
+			var err error
+			if attemptReq, err = rewind(req); err != nil {
+				return nil, err
+			}
+		}
+
+		attemptsCounter.Add(ctx, 1, metric.WithAttributes(targetAttr))
+		resp, err := t.base.RoundTrip(attemptReq)
+
+		attrs := []attribute.KeyValue{
+			targetAttr,
+			attribute.Int("http.attempt", attempt),
+		}
+		retryable := false
+		switch {
+		case err != nil:
+			attrs = append(attrs, attribute.String("error.message", errorMessage(err)))
+			retryable = ctx.Err() == nil && !errors.Is(err, context.Canceled)
+		default:
+			attrs = append(attrs, attribute.Int("http.status_code", resp.StatusCode))
+			retryable = IsRetryableStatus(resp.StatusCode)
+		}
+		attrs = append(attrs, attribute.Bool("http.retryable", retryable))
+		span.AddEvent("http.attempt", trace.WithAttributes(attrs...))
+
+		if !retryable || attempt >= maxAttempts {
+			return resp, err
+		}
+
+		delay := t.policy.Backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := RetryAfter(resp, time.Now()); ok {
+				if t.policy.MaxDelay > 0 && retryAfter > t.policy.MaxDelay {
+					// The server asked for a longer pause than we are willing to wait
+					return resp, nil
+				}
+				delay = retryAfter
+			}
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			// Not enough time left for another attempt
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+		}
+
+		retriesCounter.Add(ctx, 1, metric.WithAttributes(targetAttr))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// errorMessage describes a transport error without the request URL, which
+// may carry credentials in the query string
+func errorMessage(err error) string {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// rewind clones the request with a fresh body for a new attempt
+func rewind(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("erro ao reenviar corpo da requisição: %w", err)
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
@@ -0,0 +1,201 @@
+package retry
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testPolicy() Policy {
+	return Policy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    10 * time.Millisecond,
+		Jitter:      0.5,
+	}
+}
+
+func TestPolicy_Backoff(t *testing.T) {
+	policy := Policy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+	tests := []struct {
+		name     string
+		retry    int
+		expected time.Duration
+	}{
+		{"Sem retry", 0, 0},
+		{"Primeiro retry", 1, 100 * time.Millisecond},
+		{"Segundo retry", 2, 200 * time.Millisecond},
+		{"Limitado ao MaxDelay", 5, 300 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := policy.Backoff(tt.retry); result != tt.expected {
+				t.Errorf("Backoff(%d) = %v, esperava %v", tt.retry, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPolicy_BackoffJitter(t *testing.T) {
+	policy := Policy{BaseDelay: 100 * time.Millisecond, Jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		delay := policy.Backoff(1)
+		if delay < 50*time.Millisecond || delay > 100*time.Millisecond {
+			t.Fatalf("Backoff(1) = %v, esperava entre 50ms e 100ms", delay)
+		}
+	}
+}
+
+func TestIsRetryableStatus(t *testing.T) {
+	tests := []struct {
+		status   int
+		expected bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusBadRequest, false},
+		{http.StatusNotFound, false},
+		{http.StatusForbidden, false},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusBadGateway, true},
+		{http.StatusServiceUnavailable, true},
+		{http.StatusGatewayTimeout, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			if result := IsRetryableStatus(tt.status); result != tt.expected {
+				t.Errorf("IsRetryableStatus(%d) = %v, esperava %v", tt.status, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		header   string
+		expected time.Duration
+		ok       bool
+	}{
+		{"Ausente", "", 0, false},
+		{"Segundos", "3", 3 * time.Second, true},
+		{"Data HTTP", now.Add(5 * time.Second).Format(http.TimeFormat), 5 * time.Second, true},
+		{"Data no passado", now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"Inválido", "amanhã", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.header != "" {
+				resp.Header.Set("Retry-After", tt.header)
+			}
+
+			delay, ok := RetryAfter(resp, now)
+			if delay != tt.expected || ok != tt.ok {
+				t.Errorf("RetryAfter() = (%v, %v), esperava (%v, %v)", delay, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestTransport_RetriesTransientFailures(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "payload" {
+			t.Errorf("corpo da tentativa = %q, esperava %q", body, "payload")
+		}
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, testPolicy(), "test")}
+	resp, err := client.Post(server.URL, "text/plain", bytes.NewBufferString("payload"))
+	if err != nil {
+		t.Fatalf("Post() erro inesperado = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, esperava %d", resp.StatusCode, http.StatusOK)
+	}
+	if calls != 3 {
+		t.Errorf("tentativas = %d, esperava 3", calls)
+	}
+}
+
+func TestTransport_DoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, testPolicy(), "test")}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() erro inesperado = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound || calls != 1 {
+		t.Errorf("status = %d, tentativas = %d; esperava 404 e 1 tentativa", resp.StatusCode, calls)
+	}
+}
+
+func TestTransport_GivesUpAfterMaxAttempts(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, testPolicy(), "test")}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() erro inesperado = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadGateway || calls != 3 {
+		t.Errorf("status = %d, tentativas = %d; esperava 502 e 3 tentativas", resp.StatusCode, calls)
+	}
+}
+
+func TestTransport_RetryAfterAboveMaxDelay(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, testPolicy(), "test")}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() erro inesperado = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTooManyRequests || calls != 1 {
+		t.Errorf("status = %d, tentativas = %d; esperava 429 e 1 tentativa", resp.StatusCode, calls)
+	}
+}
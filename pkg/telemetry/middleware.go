@@ -26,6 +26,12 @@ func StartSpan(ctx context.Context, spanName string, attrs ...attribute.KeyValue
 	return tracer.Start(ctx, spanName, trace.WithAttributes(attrs...))
 }
 
+// StartLinkedSpan starts a new span linked to spans from other traces or requests
+func StartLinkedSpan(ctx context.Context, spanName string, links []trace.Link, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	tracer := otel.Tracer("goExpertOtel")
+	return tracer.Start(ctx, spanName, trace.WithLinks(links...), trace.WithAttributes(attrs...))
+}
+
 // SetSpanAttributes adds attributes to the current span
 func SetSpanAttributes(span trace.Span, attrs ...attribute.KeyValue) {
 	span.SetAttributes(attrs...)
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/lcidral/goExpertOtel/pkg/models"
+)
+
+// temperatureOptions relaciona cada parâmetro de conversão ao cabeçalho equivalente
+var temperatureOptions = []struct {
+	param  string
+	header string
+}{
+	{models.TemperatureScalesParam, models.TemperatureScalesHeader},
+	{models.TemperaturePrecisionParam, models.TemperaturePrecisionHeader},
+	{models.TemperatureModeParam, models.TemperatureModeHeader},
+}
+
+// TemperatureOptions extrai as opções de conversão de temperatura da
+// requisição, sem validá-las: o parâmetro de consulta tem precedência sobre
+// o cabeçalho. Retorna vazio quando a requisição não pede nenhuma opção.
+func TemperatureOptions(r *http.Request) url.Values {
+	options := url.Values{}
+	query := r.URL.Query()
+	for _, option := range temperatureOptions {
+		value := strings.TrimSpace(query.Get(option.param))
+		if value == "" {
+			value = strings.TrimSpace(r.Header.Get(option.header))
+		}
+		if value != "" {
+			options.Set(option.param, value)
+		}
+	}
+	return options
+}
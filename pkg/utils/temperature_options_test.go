@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lcidral/goExpertOtel/pkg/models"
+)
+
+func TestTemperatureOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		headers map[string]string
+		want    string
+	}{
+		{"Sem opções", "/temperature", nil, ""},
+		{"Parâmetros de consulta", "/temperature?scales=C,Re&precision=2", nil, "precision=2&scales=C%2CRe"},
+		{"Cabeçalhos", "/temperature", map[string]string{models.TemperatureModeHeader: "strict"}, "mode=strict"},
+		{"Consulta tem precedência", "/temperature?precision=0", map[string]string{models.TemperaturePrecisionHeader: "3"}, "precision=0"},
+		{"Valores vazios ignorados", "/temperature?scales=&mode=", map[string]string{models.TemperatureScalesHeader: " "}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.target, nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			if got := TemperatureOptions(req).Encode(); got != tt.want {
+				t.Errorf("TemperatureOptions() = %q, esperava %q", got, tt.want)
+			}
+		})
+	}
+}
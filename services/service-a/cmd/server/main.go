@@ -13,6 +13,7 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/joho/godotenv"
 
+	"github.com/lcidral/goExpertOtel/pkg/breaker"
 	"github.com/lcidral/goExpertOtel/pkg/telemetry"
 	"github.com/lcidral/goExpertOtel/services/service-a/config"
 	"github.com/lcidral/goExpertOtel/services/service-a/internal/client"
@@ -38,11 +39,24 @@ func main() {
 		log.Println("🔍 OpenTelemetry inicializado com sucesso")
 	}
 
+	// Initialize OpenTelemetry metrics
+	mp, err := telemetry.InitMeter("service-a")
+	if err != nil {
+		log.Printf("Aviso: falha ao inicializar métricas OpenTelemetry: %v", err)
+	} else {
+		defer func() {
+			if err := telemetry.ShutdownMeter(mp); err != nil {
+				log.Printf("Erro ao finalizar métricas OpenTelemetry: %v", err)
+			}
+		}()
+	}
+
 	// Load configuration
 	cfg := config.LoadConfig()
 
 	// Initialize Service B client
-	serviceBClient := client.NewServiceBClient(cfg.ServiceBURL, cfg.RequestTimeout)
+	serviceBClient := client.NewServiceBClient(cfg.ServiceBURL, cfg.RequestTimeout, cfg.RetryPolicy(),
+		breaker.New("service-b", cfg.BreakerSettings()))
 
 	// Initialize handlers
 	cepHandler := handler.NewCEPHandler(serviceBClient)
@@ -81,6 +95,9 @@ func main() {
 
 	// Routes
 	r.Post("/", cepHandler.HandleCEP)
+	r.Post("/weather", cepHandler.HandleWeather)
+	r.Get("/forecast/{cep}", cepHandler.HandleForecast)
+	r.Get("/history/{cep}", cepHandler.HandleHistory)
 	r.Get("/health", cepHandler.HealthCheck)
 
 	// Server configuration
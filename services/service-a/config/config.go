@@ -4,6 +4,9 @@ import (
 	"os"
 	"strconv"
 	"time"
+
+	"github.com/lcidral/goExpertOtel/pkg/breaker"
+	"github.com/lcidral/goExpertOtel/pkg/retry"
 )
 
 // Config holds the configuration for Service A
@@ -11,6 +14,14 @@ type Config struct {
 	Port           string
 	ServiceBURL    string
 	RequestTimeout time.Duration
+
+	RetryMaxAttempts int
+	RetryBaseDelay   time.Duration
+	RetryMaxDelay    time.Duration
+
+	BreakerFailureThreshold int
+	BreakerCoolDown         time.Duration
+	BreakerHalfOpenMaxCalls int
 }
 
 // LoadConfig loads configuration from environment variables
@@ -19,6 +30,32 @@ func LoadConfig() *Config {
 		Port:           getEnv("PORT", "8080"),
 		ServiceBURL:    getEnv("SERVICE_B_URL", "http://localhost:8081"),
 		RequestTimeout: getEnvDuration("REQUEST_TIMEOUT", 30*time.Second),
+
+		RetryMaxAttempts: getEnvInt("RETRY_MAX_ATTEMPTS", 3),
+		RetryBaseDelay:   getEnvDuration("RETRY_BASE_DELAY", 100*time.Millisecond),
+		RetryMaxDelay:    getEnvDuration("RETRY_MAX_DELAY", 2*time.Second),
+
+		BreakerFailureThreshold: getEnvInt("BREAKER_FAILURE_THRESHOLD", 5),
+		BreakerCoolDown:         getEnvDuration("BREAKER_COOLDOWN", 30*time.Second),
+		BreakerHalfOpenMaxCalls: getEnvInt("BREAKER_HALF_OPEN_MAX_CALLS", 1),
+	}
+}
+
+// RetryPolicy builds the retry policy for calls to Service B
+func (c *Config) RetryPolicy() retry.Policy {
+	policy := retry.DefaultPolicy()
+	policy.MaxAttempts = c.RetryMaxAttempts
+	policy.BaseDelay = c.RetryBaseDelay
+	policy.MaxDelay = c.RetryMaxDelay
+	return policy
+}
+
+// BreakerSettings builds the circuit breaker settings for calls to Service B
+func (c *Config) BreakerSettings() breaker.Settings {
+	return breaker.Settings{
+		FailureThreshold: c.BreakerFailureThreshold,
+		CoolDown:         c.BreakerCoolDown,
+		HalfOpenMaxCalls: c.BreakerHalfOpenMaxCalls,
 	}
 }
 
@@ -4,34 +4,143 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/lcidral/goExpertOtel/pkg/breaker"
 	"github.com/lcidral/goExpertOtel/pkg/models"
+	"github.com/lcidral/goExpertOtel/pkg/retry"
 )
 
 // ServiceBClient cliente para comunicação com o Serviço B
 type ServiceBClient struct {
 	baseURL    string
 	httpClient *http.Client
+	breaker    *breaker.Breaker
 }
 
 // NewServiceBClient cria uma nova instância do cliente
-func NewServiceBClient(baseURL string, timeout time.Duration) *ServiceBClient {
+func NewServiceBClient(baseURL string, timeout time.Duration, retryPolicy retry.Policy, cb *breaker.Breaker) *ServiceBClient {
 	return &ServiceBClient{
 		baseURL: baseURL,
 		httpClient: &http.Client{
-			Timeout:   timeout,
-			Transport: otelhttp.NewTransport(http.DefaultTransport),
+			Timeout: timeout,
+			// Retries wrap the instrumented transport so each attempt gets its own client span
+			Transport: retry.NewTransport(otelhttp.NewTransport(http.DefaultTransport), retryPolicy, "service-b"),
 		},
+		breaker: cb,
 	}
 }
 
-// GetTemperature faz uma requisição para o Serviço B para obter temperatura
+// CircuitBreaker retorna o circuit breaker que protege as chamadas ao Serviço B
+func (c *ServiceBClient) CircuitBreaker() *breaker.Breaker {
+	return c.breaker
+}
+
+// GetTemperature faz uma requisição para o Serviço B para obter temperatura,
+// falhando imediatamente enquanto o circuit breaker estiver aberto
 func (c *ServiceBClient) GetTemperature(ctx context.Context, cep string) (*models.TemperatureResponse, error) {
+	var tempResponse models.TemperatureResponse
+	if err := c.call(ctx, func() error {
+		return c.post(ctx, "/temperature", cep, &tempResponse)
+	}); err != nil {
+		return nil, err
+	}
+	return &tempResponse, nil
+}
+
+// GetScaledTemperature faz uma requisição para o Serviço B para obter a
+// temperatura nas escalas e casas decimais das opções de conversão, que o
+// Serviço B valida; falha imediatamente enquanto o circuit breaker estiver aberto
+func (c *ServiceBClient) GetScaledTemperature(ctx context.Context, cep string, options url.Values) (*models.ScaledTemperatureResponse, error) {
+	var tempResponse models.ScaledTemperatureResponse
+	if err := c.call(ctx, func() error {
+		return c.post(ctx, "/temperature?"+options.Encode(), cep, &tempResponse)
+	}); err != nil {
+		return nil, err
+	}
+	return &tempResponse, nil
+}
+
+// GetWeatherConditions faz uma requisição para o Serviço B para obter as
+// condições meteorológicas completas, falhando imediatamente enquanto o
+// circuit breaker estiver aberto
+func (c *ServiceBClient) GetWeatherConditions(ctx context.Context, cep string) (*models.WeatherConditionsResponse, error) {
+	var conditions models.WeatherConditionsResponse
+	if err := c.call(ctx, func() error {
+		return c.post(ctx, "/weather", cep, &conditions)
+	}); err != nil {
+		return nil, err
+	}
+	return &conditions, nil
+}
+
+// GetForecast faz uma requisição para o Serviço B para obter a previsão do
+// tempo de days dias (zero: o padrão do Serviço B), falhando imediatamente
+// enquanto o circuit breaker estiver aberto
+func (c *ServiceBClient) GetForecast(ctx context.Context, cep string, days int) (*models.ForecastResponse, error) {
+	path := "/forecast/" + url.PathEscape(cep)
+	if days > 0 {
+		path += "?days=" + strconv.Itoa(days)
+	}
+
+	var forecast models.ForecastResponse
+	if err := c.call(ctx, func() error {
+		return c.get(ctx, path, &forecast)
+	}); err != nil {
+		return nil, err
+	}
+	return &forecast, nil
+}
+
+// GetHistory faz uma requisição para o Serviço B para obter as temperaturas
+// registradas na data (2006-01-02), falhando imediatamente enquanto o
+// circuit breaker estiver aberto
+func (c *ServiceBClient) GetHistory(ctx context.Context, cep, date string) (*models.HistoryResponse, error) {
+	var history models.HistoryResponse
+	if err := c.call(ctx, func() error {
+		return c.get(ctx, "/history/"+url.PathEscape(cep)+"?date="+url.QueryEscape(date), &history)
+	}); err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+// call executa a requisição fn protegida pelo circuit breaker, registrando o
+// resultado e o estado do breaker no span atual
+func (c *ServiceBClient) call(ctx context.Context, fn func() error) error {
+	span := trace.SpanFromContext(ctx)
+	generation, err := c.breaker.Allow()
+	if err != nil {
+		span.SetAttributes(c.breaker.Attributes()...)
+		return err
+	}
+
+	err = fn()
+	c.breaker.Record(generation, isServiceBFailure(ctx, err))
+	span.SetAttributes(c.breaker.Attributes()...)
+	return err
+}
+
+// get consulta o endpoint path do Serviço B e decodifica a resposta em target
+func (c *ServiceBClient) get(ctx context.Context, path string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	return c.do(req, target)
+}
+
+// post envia o CEP para o endpoint path do Serviço B e decodifica a resposta em target
+func (c *ServiceBClient) post(ctx context.Context, path, cep string, target interface{}) error {
 	// Prepara o payload
 	request := models.CEPRequest{
 		CEP: cep,
@@ -40,24 +149,28 @@ func (c *ServiceBClient) GetTemperature(ctx context.Context, cep string) (*model
 	// Serializa para JSON
 	jsonData, err := json.Marshal(request)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao serializar requisição: %w", err)
+		return fmt.Errorf("erro ao serializar requisição: %w", err)
 	}
 
 	// Cria a requisição HTTP
-	url := fmt.Sprintf("%s/temperature", c.baseURL)
+	url := c.baseURL + path
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return nil, fmt.Errorf("erro ao criar requisição: %w", err)
+		return fmt.Errorf("erro ao criar requisição: %w", err)
 	}
 
 	// Define headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
+	return c.do(req, target)
+}
 
+// do executa a requisição e decodifica a resposta em target
+func (c *ServiceBClient) do(req *http.Request, target interface{}) error {
 	// Executa a requisição
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao executar requisição: %w", err)
+		return fmt.Errorf("erro ao executar requisição: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -65,46 +178,76 @@ func (c *ServiceBClient) GetTemperature(ctx context.Context, cep string) (*model
 	switch resp.StatusCode {
 	case http.StatusOK:
 		// Sucesso - decodifica resposta
-		var tempResponse models.TemperatureResponse
-		if err := json.NewDecoder(resp.Body).Decode(&tempResponse); err != nil {
-			return nil, fmt.Errorf("erro ao decodificar resposta: %w", err)
+		if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+			return fmt.Errorf("erro ao decodificar resposta: %w", err)
 		}
-		return &tempResponse, nil
+		return nil
 
 	case http.StatusNotFound:
-		// CEP não encontrado
-		return nil, &ServiceBError{
+		// CEP não encontrado ou, no histórico, data sem dados
+		message := models.ErrZipcodeNotFound
+		var errorResp models.ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err == nil && errorResp.Message == models.ErrNoHistory {
+			message = errorResp.Message
+		}
+		return &ServiceBError{
 			StatusCode: resp.StatusCode,
-			Message:    models.ErrZipcodeNotFound,
+			Message:    message,
 		}
 
 	case http.StatusUnprocessableEntity:
 		// CEP inválido
-		return nil, &ServiceBError{
+		return &ServiceBError{
 			StatusCode: resp.StatusCode,
 			Message:    models.ErrInvalidZipcode,
 		}
 
 	default:
 		// Outros erros
+		_, hasRetryAfter := retry.RetryAfter(resp, time.Now())
 		var errorResp models.ErrorResponse
 		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
-			return nil, &ServiceBError{
+			return &ServiceBError{
 				StatusCode: resp.StatusCode,
 				Message:    fmt.Sprintf("erro inesperado do serviço B: %d", resp.StatusCode),
+				RetryAfter: hasRetryAfter,
 			}
 		}
-		return nil, &ServiceBError{
+		return &ServiceBError{
 			StatusCode: resp.StatusCode,
 			Message:    errorResp.Message,
+			RetryAfter: hasRetryAfter,
+		}
+	}
+}
+
+// isServiceBFailure indica se o erro representa uma falha do Serviço B
+// (rede, timeout ou 5xx); CEP inválido ou não encontrado não contam para o circuit breaker.
+// Também não contam o cancelamento ou o prazo esgotado do próprio chamador, nem
+// o 503 com Retry-After, com que o Serviço B sinaliza que uma dependência dele
+// está indisponível.
+func isServiceBFailure(ctx context.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || (errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil) {
+		return false
+	}
+	var serviceBErr *ServiceBError
+	if errors.As(err, &serviceBErr) {
+		if serviceBErr.StatusCode == http.StatusServiceUnavailable && serviceBErr.RetryAfter {
+			return false
 		}
+		return serviceBErr.StatusCode >= http.StatusInternalServerError
 	}
+	return true
 }
 
 // ServiceBError representa erros específicos do Serviço B
 type ServiceBError struct {
 	StatusCode int
 	Message    string
+	RetryAfter bool // a resposta trouxe o cabeçalho Retry-After
 }
 
 func (e *ServiceBError) Error() string {
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestIsServiceBFailure(t *testing.T) {
+	expired, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{"Sucesso", context.Background(), nil, false},
+		{"Erro de rede", context.Background(), errors.New("connection refused"), true},
+		{"Erro 500", context.Background(), &ServiceBError{StatusCode: http.StatusInternalServerError}, true},
+		{"Erro 503 sem Retry-After", context.Background(), &ServiceBError{StatusCode: http.StatusServiceUnavailable}, true},
+		{"Erro 503 com Retry-After", context.Background(), &ServiceBError{StatusCode: http.StatusServiceUnavailable, RetryAfter: true}, false},
+		{"CEP não encontrado", context.Background(), &ServiceBError{StatusCode: http.StatusNotFound}, false},
+		{"Chamador cancelou", expired, fmt.Errorf("erro ao executar requisição: %w", context.Canceled), false},
+		{"Prazo do chamador esgotado", expired, fmt.Errorf("erro ao executar requisição: %w", context.DeadlineExceeded), false},
+		{"Timeout do cliente HTTP", context.Background(), fmt.Errorf("erro ao executar requisição: %w", context.DeadlineExceeded), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isServiceBFailure(tt.ctx, tt.err); got != tt.want {
+				t.Errorf("isServiceBFailure() = %v, esperava %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,13 +3,20 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/lcidral/goExpertOtel/pkg/breaker"
 	"github.com/lcidral/goExpertOtel/pkg/models"
 	"github.com/lcidral/goExpertOtel/pkg/telemetry"
 	"github.com/lcidral/goExpertOtel/pkg/utils"
@@ -35,36 +42,12 @@ func (h *CEPHandler) HandleCEP(w http.ResponseWriter, r *http.Request) {
 	// Set response headers
 	w.Header().Set("Content-Type", "application/json")
 
-	// Parse request body
-	var req models.CEPRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Erro ao decodificar requisição: %v", err)
-		h.respondWithError(w, http.StatusBadRequest, models.ErrInvalidZipcode)
+	ctx, validationSpan, normalizedCEP, ok := h.decodeCEP(w, r)
+	if !ok {
 		return
 	}
-
-	// Start CEP validation span
-	ctx, validationSpan := telemetry.StartSpan(r.Context(), "cep.validation",
-		attribute.String("cep.input", req.CEP),
-	)
 	defer validationSpan.End()
 
-	// Validate and normalize CEP
-	normalizedCEP, err := h.validator.ValidateAndNormalize(req.CEP)
-	if err != nil {
-		log.Printf("CEP inválido: %s, erro: %v", req.CEP, err)
-		validationSpan.RecordError(err)
-		validationSpan.SetStatus(codes.Error, "CEP validation failed")
-		h.respondWithError(w, http.StatusUnprocessableEntity, models.ErrInvalidZipcode)
-		return
-	}
-
-	validationSpan.SetAttributes(
-		attribute.String("cep.normalized", normalizedCEP),
-		attribute.Bool("cep.valid", true),
-	)
-	validationSpan.SetStatus(codes.Ok, "CEP validation successful")
-
 	// Create context with timeout
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
@@ -76,25 +59,17 @@ func (h *CEPHandler) HandleCEP(w http.ResponseWriter, r *http.Request) {
 	)
 	defer serviceBSpan.End()
 
+	// Conversion options are forwarded to Service B, which validates them
+	if options := utils.TemperatureOptions(r); len(options) > 0 {
+		h.respondScaledTemperature(ctx, w, serviceBSpan, normalizedCEP, options)
+		return
+	}
+
 	// Call Service B
 	tempResponse, err := h.serviceBClient.GetTemperature(ctx, normalizedCEP)
 	if err != nil {
 		log.Printf("Erro ao chamar Serviço B para CEP %s: %v", normalizedCEP, err)
-		serviceBSpan.RecordError(err)
-		serviceBSpan.SetStatus(codes.Error, "Service B call failed")
-		
-		// Check if it's a ServiceBError
-		if serviceBErr, ok := err.(*client.ServiceBError); ok {
-			serviceBSpan.SetAttributes(
-				attribute.Int("http.status_code", serviceBErr.GetStatusCode()),
-				attribute.String("error.message", serviceBErr.Message),
-			)
-			h.respondWithError(w, serviceBErr.GetStatusCode(), serviceBErr.Message)
-			return
-		}
-		
-		// Generic error
-		h.respondWithError(w, http.StatusInternalServerError, "Erro interno do servidor")
+		h.respondServiceBError(w, serviceBSpan, err)
 		return
 	}
 
@@ -115,15 +90,264 @@ func (h *CEPHandler) HandleCEP(w http.ResponseWriter, r *http.Request) {
 	log.Printf("CEP %s processado com sucesso", normalizedCEP)
 }
 
+// respondScaledTemperature answers with the temperature in the scales and
+// precision of the request's conversion options
+func (h *CEPHandler) respondScaledTemperature(ctx context.Context, w http.ResponseWriter, serviceBSpan trace.Span, cep string, options url.Values) {
+	serviceBSpan.SetAttributes(attribute.String("temperature.options", options.Encode()))
+
+	tempResponse, err := h.serviceBClient.GetScaledTemperature(ctx, cep, options)
+	if err != nil {
+		log.Printf("Erro ao chamar Serviço B para CEP %s: %v", cep, err)
+		h.respondServiceBError(w, serviceBSpan, err)
+		return
+	}
+
+	serviceBSpan.SetAttributes(attribute.String("city.name", tempResponse.City))
+	serviceBSpan.SetStatus(codes.Ok, "Service B call successful")
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(tempResponse); err != nil {
+		log.Printf("Erro ao codificar resposta: %v", err)
+	}
+
+	log.Printf("CEP %s processado com sucesso (%s)", cep, options.Encode())
+}
+
+// HandleWeather returns the full current conditions for a CEP from Service B
+func (h *CEPHandler) HandleWeather(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx, validationSpan, normalizedCEP, ok := h.decodeCEP(w, r)
+	if !ok {
+		return
+	}
+	defer validationSpan.End()
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	ctx, serviceBSpan := telemetry.StartSpan(ctxWithTimeout, "service_b.call",
+		attribute.String("cep.value", normalizedCEP),
+		attribute.String("service.name", "service-b"),
+		attribute.String("service_b.endpoint", "/weather"),
+	)
+	defer serviceBSpan.End()
+
+	conditions, err := h.serviceBClient.GetWeatherConditions(ctx, normalizedCEP)
+	if err != nil {
+		log.Printf("Erro ao chamar Serviço B para condições do CEP %s: %v", normalizedCEP, err)
+		h.respondServiceBError(w, serviceBSpan, err)
+		return
+	}
+
+	serviceBSpan.SetAttributes(
+		attribute.String("city.name", conditions.Location.City),
+		attribute.String("conditions.version", conditions.Version),
+		attribute.Float64("temp_c", conditions.Current.Temperature.C),
+	)
+	serviceBSpan.SetStatus(codes.Ok, "Service B call successful")
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(conditions); err != nil {
+		log.Printf("Erro ao codificar resposta: %v", err)
+	}
+
+	log.Printf("Condições do CEP %s processadas com sucesso", normalizedCEP)
+}
+
+// HandleForecast returns the forecast for the CEP in the path from Service B.
+// The days query parameter is forwarded; Service B enforces its maximum.
+func (h *CEPHandler) HandleForecast(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	days := 0
+	if value := r.URL.Query().Get("days"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			h.respondWithError(w, http.StatusBadRequest, models.ErrInvalidDays)
+			return
+		}
+		days = n
+	}
+
+	ctx, validationSpan, normalizedCEP, ok := h.validateCEP(w, r, chi.URLParam(r, "cep"))
+	if !ok {
+		return
+	}
+	defer validationSpan.End()
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	ctx, serviceBSpan := telemetry.StartSpan(ctxWithTimeout, "service_b.call",
+		attribute.String("cep.value", normalizedCEP),
+		attribute.String("service.name", "service-b"),
+		attribute.String("service_b.endpoint", "/forecast"),
+		attribute.Int("forecast.days", days),
+	)
+	defer serviceBSpan.End()
+
+	forecast, err := h.serviceBClient.GetForecast(ctx, normalizedCEP, days)
+	if err != nil {
+		log.Printf("Erro ao chamar Serviço B para previsão do CEP %s: %v", normalizedCEP, err)
+		h.respondServiceBError(w, serviceBSpan, err)
+		return
+	}
+
+	serviceBSpan.SetAttributes(
+		attribute.String("city.name", forecast.City),
+		attribute.Int("forecast.days_returned", len(forecast.Days)),
+	)
+	serviceBSpan.SetStatus(codes.Ok, "Service B call successful")
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(forecast); err != nil {
+		log.Printf("Erro ao codificar resposta: %v", err)
+	}
+
+	log.Printf("Previsão do CEP %s processada com sucesso", normalizedCEP)
+}
+
+// HandleHistory returns the temperatures recorded on the date query parameter
+// (YYYY-MM-DD) for the CEP in the path, from Service B
+func (h *CEPHandler) HandleHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	date := r.URL.Query().Get("date")
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, models.ErrInvalidDate)
+		return
+	}
+
+	ctx, validationSpan, normalizedCEP, ok := h.validateCEP(w, r, chi.URLParam(r, "cep"))
+	if !ok {
+		return
+	}
+	defer validationSpan.End()
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	ctx, serviceBSpan := telemetry.StartSpan(ctxWithTimeout, "service_b.call",
+		attribute.String("cep.value", normalizedCEP),
+		attribute.String("service.name", "service-b"),
+		attribute.String("service_b.endpoint", "/history"),
+		attribute.String("history.date", date),
+	)
+	defer serviceBSpan.End()
+
+	history, err := h.serviceBClient.GetHistory(ctx, normalizedCEP, date)
+	if err != nil {
+		log.Printf("Erro ao chamar Serviço B para histórico do CEP %s em %s: %v", normalizedCEP, date, err)
+		h.respondServiceBError(w, serviceBSpan, err)
+		return
+	}
+
+	serviceBSpan.SetAttributes(
+		attribute.String("city.name", history.City),
+		attribute.String("history.provider", history.Provider),
+		attribute.Float64("temp_min_c", history.Min.C),
+		attribute.Float64("temp_max_c", history.Max.C),
+	)
+	serviceBSpan.SetStatus(codes.Ok, "Service B call successful")
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(history); err != nil {
+		log.Printf("Erro ao codificar resposta: %v", err)
+	}
+
+	log.Printf("Histórico do CEP %s em %s processado com sucesso", normalizedCEP, date)
+}
+
+// decodeCEP parses and validates the CEP in the request body, answering the
+// request itself when the CEP is invalid. The returned validation span is the
+// parent of the rest of the request and must be ended by the caller.
+func (h *CEPHandler) decodeCEP(w http.ResponseWriter, r *http.Request) (context.Context, trace.Span, string, bool) {
+	// Parse request body
+	var req models.CEPRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Erro ao decodificar requisição: %v", err)
+		h.respondWithError(w, http.StatusBadRequest, models.ErrInvalidZipcode)
+		return nil, nil, "", false
+	}
+	return h.validateCEP(w, r, req.CEP)
+}
+
+// validateCEP validates and normalizes a CEP, answering the request itself
+// when it is invalid. The returned validation span must be ended by the caller.
+func (h *CEPHandler) validateCEP(w http.ResponseWriter, r *http.Request, cep string) (context.Context, trace.Span, string, bool) {
+	// Start CEP validation span
+	ctx, validationSpan := telemetry.StartSpan(r.Context(), "cep.validation",
+		attribute.String("cep.input", cep),
+	)
+
+	// Validate and normalize CEP
+	normalizedCEP, err := h.validator.ValidateAndNormalize(cep)
+	if err != nil {
+		log.Printf("CEP inválido: %s, erro: %v", cep, err)
+		validationSpan.RecordError(err)
+		validationSpan.SetStatus(codes.Error, "CEP validation failed")
+		validationSpan.End()
+		h.respondWithError(w, http.StatusUnprocessableEntity, models.ErrInvalidZipcode)
+		return nil, nil, "", false
+	}
+
+	validationSpan.SetAttributes(
+		attribute.String("cep.normalized", normalizedCEP),
+		attribute.Bool("cep.valid", true),
+	)
+	validationSpan.SetStatus(codes.Ok, "CEP validation successful")
+	return ctx, validationSpan, normalizedCEP, true
+}
+
+// respondServiceBError maps a failed Service B call to the HTTP response
+func (h *CEPHandler) respondServiceBError(w http.ResponseWriter, serviceBSpan trace.Span, err error) {
+	serviceBSpan.RecordError(err)
+	serviceBSpan.SetStatus(codes.Error, "Service B call failed")
+
+	// Fail fast while the Service B circuit breaker is open
+	var openErr *breaker.OpenError
+	if errors.As(err, &openErr) {
+		if openErr.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(openErr.RetryAfter.Seconds()))))
+		}
+		h.respondWithError(w, http.StatusServiceUnavailable, "Serviço temporariamente indisponível")
+		return
+	}
+
+	// Check if it's a ServiceBError
+	if serviceBErr, ok := err.(*client.ServiceBError); ok {
+		serviceBSpan.SetAttributes(
+			attribute.Int("http.status_code", serviceBErr.GetStatusCode()),
+			attribute.String("error.message", serviceBErr.Message),
+		)
+		h.respondWithError(w, serviceBErr.GetStatusCode(), serviceBErr.Message)
+		return
+	}
+
+	// Generic error
+	h.respondWithError(w, http.StatusInternalServerError, "Erro interno do servidor")
+}
+
 // HealthCheck provides a health check endpoint
 func (h *CEPHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	
-	response := map[string]string{
-		"status": "healthy",
-		"service": "service-a",
+
+	// An open breaker degrades the service but doesn't make it unhealthy
+	cb := h.serviceBClient.CircuitBreaker()
+	status := "healthy"
+	if cb.State() != breaker.StateClosed {
+		status = "degraded"
+	}
+
+	response := map[string]interface{}{
+		"status":    status,
+		"service":   "service-a",
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"circuit_breakers": map[string]interface{}{
+			cb.Name(): cb.Stats(),
+		},
 	}
 	
 	json.NewEncoder(w).Encode(response)
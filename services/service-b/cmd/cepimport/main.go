@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/cepindex"
+)
+
+// cepimport converte uma base de CEPs (DNE dos Correios em formato fixo ou um
+// export CSV) no índice compacto usado pelo Service B no modo offline.
+//
+// Uso:
+//
+//	go run ./services/service-b/cmd/cepimport -format csv -out ceps.idx ceps.csv
+//	go run ./services/service-b/cmd/cepimport -format dne -out ceps.idx DNE_GU_*_LOGRADOUROS.TXT
+func main() {
+	format := flag.String("format", "csv", "formato dos arquivos de entrada: csv ou dne")
+	out := flag.String("out", "ceps.idx", "caminho do índice gerado")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Uso: %s [opções] arquivo [arquivo...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	start := time.Now()
+	var records []cepindex.Record
+
+	for _, path := range flag.Args() {
+		parsed, err := parseFile(path, strings.ToLower(*format))
+		if err != nil {
+			log.Fatalf("Erro ao importar %s: %v", path, err)
+		}
+		log.Printf("📄 %s: %d CEPs", path, len(parsed))
+		records = append(records, parsed...)
+	}
+
+	if err := cepindex.WriteFile(*out, records); err != nil {
+		log.Fatalf("Erro ao gravar índice: %v", err)
+	}
+
+	index, err := cepindex.Open(*out)
+	if err != nil {
+		log.Fatalf("Erro ao validar índice gerado: %v", err)
+	}
+
+	info, _ := os.Stat(*out)
+	log.Printf("✅ Índice %s gerado com %d CEPs (%d bytes) em %v",
+		*out, index.Len(), info.Size(), time.Since(start).Round(time.Millisecond))
+}
+
+// parseFile lê um arquivo de entrada no formato informado
+func parseFile(path, format string) ([]cepindex.Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch format {
+	case "csv":
+		return cepindex.ParseCSV(f)
+	case "dne":
+		return cepindex.ParseDNE(f, cepindex.DefaultDNELayout)
+	default:
+		return nil, fmt.Errorf("formato desconhecido: %s", format)
+	}
+}
@@ -8,16 +8,23 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // forecast hours in the location's timezone; the runtime image has no zoneinfo
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/joho/godotenv"
 
+	"github.com/lcidral/goExpertOtel/pkg/breaker"
 	"github.com/lcidral/goExpertOtel/pkg/telemetry"
 	"github.com/lcidral/goExpertOtel/services/service-b/config"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/alerts"
 	"github.com/lcidral/goExpertOtel/services/service-b/internal/cache"
 	"github.com/lcidral/goExpertOtel/services/service-b/internal/client"
 	"github.com/lcidral/goExpertOtel/services/service-b/internal/handler"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/quota"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/readings"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/secrets"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/warmer"
 )
 
 func main() {
@@ -39,6 +46,18 @@ func main() {
 		log.Println("🔍 OpenTelemetry inicializado com sucesso")
 	}
 
+	// Initialize OpenTelemetry metrics
+	mp, err := telemetry.InitMeter("service-b")
+	if err != nil {
+		log.Printf("Aviso: falha ao inicializar métricas OpenTelemetry: %v", err)
+	} else {
+		defer func() {
+			if err := telemetry.ShutdownMeter(mp); err != nil {
+				log.Printf("Erro ao finalizar métricas OpenTelemetry: %v", err)
+			}
+		}()
+	}
+
 	// Load configuration
 	cfg := config.LoadConfig()
 
@@ -48,14 +67,188 @@ func main() {
 	}
 
 	// Initialize cache
-	memoryCache := cache.NewMemoryCache(cfg.CacheTTL, cfg.CacheCleanup)
+	cacheOptions := cfg.CacheOptions()
+	var appCache cache.Cache
+	var snapshotCache *cache.MemoryCache
+	switch cfg.CacheBackend {
+	case config.CacheBackendRedis, config.CacheBackendTiered:
+		codec, err := cache.CodecByName(cfg.CacheCodec)
+		if err != nil {
+			log.Fatalf("Erro de configuração: %v", err)
+		}
+		redisCache := cache.NewRedisCache(cache.RedisOptions{
+			Addr:      cfg.RedisAddr,
+			Password:  cfg.RedisPassword,
+			DB:        cfg.RedisDB,
+			KeyPrefix: cfg.RedisKeyPrefix,
+			Codec:     codec,
+		}, cacheOptions)
+
+		pingCtx, cancelPing := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := redisCache.Ping(pingCtx); err != nil {
+			// The service keeps working without cache; every lookup becomes a miss
+			log.Printf("Aviso: Redis indisponível em %s: %v", cfg.RedisAddr, err)
+		}
+		cancelPing()
+
+		if cfg.CacheBackend == config.CacheBackendTiered {
+			memoryCache := cache.NewMemoryCache(cfg.CacheTTL, cfg.CacheCleanup, cacheOptions)
+			tieredCache := cache.NewTieredCache(memoryCache, redisCache, cfg.CacheL1TTL)
+			defer tieredCache.Close()
+			appCache = tieredCache
+		} else {
+			defer redisCache.Close()
+			appCache = redisCache
+		}
+	default:
+		memoryCache := cache.NewMemoryCache(cfg.CacheTTL, cfg.CacheCleanup, cacheOptions)
+		if cfg.CacheSnapshot != "" {
+			restored, err := memoryCache.LoadSnapshot(cfg.CacheSnapshot)
+			if err != nil {
+				log.Printf("Aviso: falha ao restaurar snapshot do cache: %v", err)
+			} else {
+				log.Printf("💾 Cache restaurado de %s: %d itens", cfg.CacheSnapshot, restored)
+			}
+			snapshotCache = memoryCache
+		}
+		appCache = memoryCache
+	}
+
+	// Initialize circuit breakers, one per external dependency
+	openCEPBreaker := breaker.New("opencep", cfg.BreakerSettings())
+	weatherBreaker := breaker.New("weatherapi", cfg.BreakerSettings())
+
+	// Track the WeatherAPI budget, persisting the counters across restarts
+	weatherQuota, err := quota.NewTracker("weatherapi", cfg.QuotaOptions())
+	if err != nil {
+		log.Fatalf("Erro ao carregar contadores de cota: %v", err)
+	}
+	quotaCtx, stopQuota := context.WithCancel(context.Background())
+	defer stopQuota()
+	go weatherQuota.Run(quotaCtx, 30*time.Second)
 
 	// Initialize clients
-	openCEPClient := client.NewOpenCEPClient(cfg.OpenCEPURL, cfg.RequestTimeout)
-	weatherClient := client.NewWeatherClient(cfg.WeatherAPIURL, cfg.WeatherAPIKey, cfg.RequestTimeout)
+	openCEPClient := client.NewOpenCEPClient(cfg.OpenCEPURL, cfg.RequestTimeout, cfg.RetryPolicy(), openCEPBreaker)
+	weatherKeys := cfg.WeatherAPIKeyPool()
+	weatherAPIClient := client.NewWeatherClient(cfg.WeatherAPIURL, weatherKeys, cfg.RequestTimeout, cfg.RetryPolicy(), weatherBreaker, weatherQuota)
+
+	openMeteoClient := client.NewOpenMeteoClient(cfg.OpenMeteoURL, cfg.OpenMeteoGeocodingURL, cfg.OpenMeteoArchiveURL, cfg.OpenMeteoAirQualityURL, cfg.RequestTimeout, cfg.RetryPolicy())
+
+	// Past the budget threshold, weather comes from cache only or from Open-Meteo
+	var fallbackWeather client.WeatherProvider
+	if cfg.WeatherQuotaMode == config.QuotaModeFallback {
+		fallbackWeather = openMeteoClient
+	}
+	weatherClient := client.NewBudgetWeatherProvider(weatherAPIClient, fallbackWeather, weatherQuota)
+
+	// Historical weather comes from WeatherAPI (within the budget) or from Open-Meteo's archive
+	var historyProvider client.HistoryProvider = weatherClient
+	if cfg.HistoryProvider == config.HistoryProviderOpenMeteo {
+		historyProvider = openMeteoClient
+	}
+
+	// Air quality is opt-in, from WeatherAPI (within the budget) or from Open-Meteo
+	var airQualityProvider client.AirQualityProvider
+	switch cfg.AirQualityProvider {
+	case config.AirQualityWeatherAPI:
+		airQualityProvider = weatherClient
+	case config.AirQualityOpenMeteo:
+		airQualityProvider = openMeteoClient
+	}
+
+	// Select location provider according to the CEP lookup mode
+	var locationProvider client.LocationProvider = openCEPClient
+	if cfg.CEPLookupMode != config.CEPLookupOnline {
+		offlineClient, err := client.NewOfflineCEPClient(cfg.CEPIndexPath)
+		if err != nil {
+			log.Fatalf("Erro ao carregar índice de CEPs: %v", err)
+		}
+		log.Printf("📦 Índice de CEPs carregado: %d CEPs", offlineClient.Size())
+
+		locationProvider = offlineClient
+		if cfg.CEPLookupMode == config.CEPLookupChain {
+			locationProvider = client.NewChainLocationProvider(offlineClient, openCEPClient)
+		}
+	}
 
 	// Initialize handlers
-	tempHandler := handler.NewTemperatureHandler(openCEPClient, weatherClient, memoryCache)
+	tempHandler := handler.NewTemperatureHandler(locationProvider, weatherClient, appCache,
+		[]*breaker.Breaker{openCEPBreaker, weatherBreaker})
+	tempHandler.SetTTLPolicy(cfg.TTLPolicy())
+	tempHandler.SetQuotaReporter(weatherQuota)
+	tempHandler.SetKeyPoolReporter(weatherKeys)
+	tempHandler.SetForecastProvider(weatherClient, cfg.ForecastMaxDays)
+	tempHandler.SetHistoryProvider(historyProvider)
+	if airQualityProvider != nil {
+		tempHandler.SetAirQualityProvider(airQualityProvider)
+	}
+
+	// Record every temperature served, for dashboards without historical API calls
+	var readingStore *readings.Store
+	if cfg.ReadingsPath != "" {
+		readingStore, err = readings.Open(cfg.ReadingsPath, cfg.ReadingsRetention)
+		if err != nil {
+			log.Fatalf("Erro ao abrir registro de leituras: %v", err)
+		}
+		tempHandler.SetReadingStore(readingStore)
+		log.Printf("📈 Leituras registradas em %s (retenção: %v)", cfg.ReadingsPath, cfg.ReadingsRetention)
+	}
+
+	// Rotate the WeatherAPI keys when the secret file changes; in-flight
+	// requests finish with the key they already hold
+	secretsCtx, stopSecrets := context.WithCancel(context.Background())
+	defer stopSecrets()
+	if cfg.WeatherAPIKeysFile != "" {
+		keysWatcher := secrets.NewWatcher(cfg.WeatherAPIKeysFile, cfg.SecretsReloadInterval, func(value string) {
+			weatherKeys.Replace(secrets.ParseList(value))
+		})
+		go keysWatcher.Run(secretsCtx)
+		log.Printf("🔑 Chaves da WeatherAPI lidas de %s (verificado a cada %v)", cfg.WeatherAPIKeysFile, cfg.SecretsReloadInterval)
+	}
+
+	// Keep popular CEPs warm in background
+	warmCtx, stopWarmer := context.WithCancel(context.Background())
+	defer stopWarmer()
+	if cfg.WarmSource != config.WarmSourceOff {
+		var source warmer.Source
+		if cfg.WarmSource == config.WarmSourceFile {
+			source = warmer.NewFileSource(cfg.WarmFile)
+		} else {
+			topK := warmer.NewTopK(cfg.WarmTopK * 10)
+			tempHandler.SetTrafficRecorder(topK)
+			source = warmer.NewTrafficSource(topK, cfg.WarmTopK)
+		}
+		cacheWarmer := warmer.New(source, tempHandler, warmer.Options{
+			Interval: cfg.WarmInterval,
+			Rate:     cfg.WarmRate,
+		})
+		go cacheWarmer.Run(warmCtx)
+		log.Printf("🔥 Aquecimento do cache ativado (fonte: %s, intervalo: %v, %.1f chamadas/s)",
+			cfg.WarmSource, cfg.WarmInterval, cfg.WarmRate)
+	}
+
+	// Temperature alerts: subscribed CEPs are polled in background and
+	// crossed thresholds are delivered to the subscribers' webhooks
+	alertsCtx, stopAlerts := context.WithCancel(context.Background())
+	defer stopAlerts()
+	alertsDone := make(chan struct{})
+	var alertStore *alerts.Store
+	if cfg.AlertsEnabled {
+		alertStore, err = alerts.Open(cfg.AlertsPath, cfg.AlertsMaxSubscriptions)
+		if err != nil {
+			log.Fatalf("Erro ao abrir assinaturas de alertas: %v", err)
+		}
+		notifier := alerts.NewNotifier(cfg.AlertsWebhookTimeout, cfg.AlertsWebhookPolicy(), cfg.AlertsWebhookAllowPrivate)
+		scheduler := alerts.NewScheduler(alertStore, tempHandler, notifier, cfg.AlertsInterval)
+		go func() {
+			defer close(alertsDone)
+			scheduler.Run(alertsCtx)
+		}()
+		log.Printf("🔔 Alertas de temperatura ativados (intervalo: %v, %d tentativas por entrega)",
+			cfg.AlertsInterval, cfg.AlertsWebhookMaxAttempts)
+	} else {
+		close(alertsDone)
+	}
 
 	// Setup router
 	r := chi.NewRouter()
@@ -91,9 +284,39 @@ func main() {
 
 	// Routes
 	r.Post("/temperature", tempHandler.HandleTemperature)
+	r.Post("/weather", tempHandler.HandleWeather)
+	r.Get("/forecast/{cep}", tempHandler.HandleForecast)
+	r.Get("/history/{cep}", tempHandler.HandleHistory)
+	r.Get("/readings/summary", tempHandler.HandleReadingsSummary)
 	r.Get("/health", tempHandler.HealthCheck)
 	r.Get("/cache/stats", tempHandler.CacheStats)
 
+	if alertStore != nil {
+		alertsHandler := handler.NewAlertsHandler(alertStore, cfg.AlertsAPIKeys)
+		alertsHandler.SetAllowPrivateWebhooks(cfg.AlertsWebhookAllowPrivate)
+		r.Route("/alerts", func(r chi.Router) {
+			r.Use(alertsHandler.Authenticate)
+			r.Post("/", alertsHandler.Create)
+			r.Get("/", alertsHandler.List)
+			r.Get("/dead-letters", alertsHandler.DeadLetters)
+			r.Delete("/{id}", alertsHandler.Delete)
+		})
+	}
+
+	// Cache administration, only enabled when a token is configured
+	if cfg.AdminToken != "" {
+		adminHandler := handler.NewAdminHandler(appCache, tempHandler, cfg.AdminToken)
+		r.Route("/admin/cache", func(r chi.Router) {
+			r.Use(adminHandler.Authenticate)
+			r.Get("/keys", adminHandler.ListKeys)
+			r.Get("/entry", adminHandler.GetEntry)
+			r.Delete("/entries", adminHandler.DeleteEntries)
+			r.Post("/flush", adminHandler.Flush)
+			r.Post("/warm", adminHandler.Warm)
+		})
+		log.Println("🔑 API de administração do cache ativada em /admin/cache")
+	}
+
 	// Server configuration
 	server := &http.Server{
 		Addr:         ":" + cfg.Port,
@@ -108,10 +331,19 @@ func main() {
 		log.Printf("🌡️ Service B iniciado na porta %s", cfg.Port)
 		log.Printf("📍 Health check: http://localhost:%s/health", cfg.Port)
 		log.Printf("🌐 OpenCEP URL: %s", cfg.OpenCEPURL)
+		log.Printf("📍 Modo de consulta de CEP: %s", cfg.CEPLookupMode)
 		log.Printf("☁️ WeatherAPI URL: %s", cfg.WeatherAPIURL)
-		log.Printf("🗄️ Cache TTL: %v", cfg.CacheTTL)
+		log.Printf("🔑 Chaves da WeatherAPI: %d (%s, quarentena de %v)",
+			weatherKeys.Size(), cfg.WeatherAPIKeySelection, cfg.WeatherAPIKeyCooldown)
+		log.Printf("📊 Cota da WeatherAPI: %d/dia, %d/mês (0 = sem limite), limiar %.0f%%, modo %s",
+			cfg.WeatherQuotaDaily, cfg.WeatherQuotaMonthly, cfg.WeatherQuotaThreshold*100, cfg.WeatherQuotaMode)
+		log.Printf("🗄️ Cache: %s (TTL localização: %v, clima: %v, modo %s)",
+			cfg.CacheBackend, cfg.CacheLocationTTL, cfg.CacheWeatherTTL, cfg.CacheWeatherTTLMode)
+		log.Printf("📅 Previsão: até %d dias (TTL: %v)", cfg.ForecastMaxDays, cfg.CacheForecastTTL)
+		log.Printf("🗓️ Histórico: %s (TTL: %v)", cfg.HistoryProvider, cfg.CacheHistoryTTL)
+		log.Printf("🌫️ Qualidade do ar: %s (TTL: %v)", cfg.AirQualityProvider, cfg.CacheAirQualityTTL)
 
-		if cfg.WeatherAPIKey == "" {
+		if weatherKeys.Size() == 0 {
 			log.Printf("⚠️ ATENÇÃO: WEATHER_API_KEY não configurada!")
 		}
 		
@@ -132,14 +364,44 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	// Stop the cache warmer and the alerts scheduler before the server;
+	// deliveries still in progress end up in the dead-letter list
+	stopWarmer()
+	stopAlerts()
+	<-alertsDone
+
 	// Shutdown server
 	if err := server.Shutdown(ctx); err != nil {
 		log.Printf("Erro durante shutdown: %v", err)
 	}
 
-	// Clear cache
-	memoryCache.Clear()
-	log.Println("🗑️ Cache limpo")
+	// Close the readings file after the last request has been served
+	if readingStore != nil {
+		if err := readingStore.Close(); err != nil {
+			log.Printf("Erro ao fechar registro de leituras: %v", err)
+		}
+	}
+
+	// Persist the quota counters
+	stopQuota()
+	if err := weatherQuota.Save(); err != nil {
+		log.Printf("Erro ao salvar contadores de cota: %v", err)
+	}
+
+	// Persist the cache so the next start isn't cold
+	if snapshotCache != nil {
+		if saved, err := snapshotCache.SaveSnapshot(cfg.CacheSnapshot); err != nil {
+			log.Printf("Erro ao salvar snapshot do cache: %v", err)
+		} else {
+			log.Printf("💾 Snapshot do cache salvo em %s: %d itens", cfg.CacheSnapshot, saved)
+		}
+	}
+
+	// Clear cache; the Redis cache is shared with the other replicas and is kept
+	if cfg.CacheBackend == config.CacheBackendMemory {
+		appCache.Clear()
+		log.Println("🗑️ Cache limpo")
+	}
 
 	log.Println("✅ Service B encerrado com sucesso")
 }
\ No newline at end of file
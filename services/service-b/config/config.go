@@ -3,37 +3,491 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/lcidral/goExpertOtel/pkg/breaker"
+	"github.com/lcidral/goExpertOtel/pkg/retry"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/cache"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/keypool"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/quota"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/secrets"
 )
 
 // Config holds the configuration for Service B
 type Config struct {
-	Port            string
-	WeatherAPIKey   string
-	WeatherAPIURL   string
-	OpenCEPURL      string
-	RequestTimeout  time.Duration
-	CacheTTL        time.Duration
-	CacheCleanup    time.Duration
+	Port           string
+	WeatherAPIKeys []string
+	WeatherAPIURL  string
+	OpenCEPURL     string
+	RequestTimeout time.Duration
+	CacheTTL       time.Duration
+	CacheCleanup   time.Duration
+	CacheStaleTTL  time.Duration
+	CacheMaxStale  time.Duration
+	CacheNegTTL    time.Duration
+	CEPLookupMode  string
+	CEPIndexPath   string
+
+	CacheLocationTTL      time.Duration
+	CacheWeatherTTL       time.Duration
+	CacheWeatherTTLMode   string
+	CacheWeatherMinTTL    time.Duration
+	WeatherUpdateInterval time.Duration
+	CacheForecastTTL      time.Duration
+	ForecastMaxDays       int
+	CacheHistoryTTL       time.Duration
+	HistoryProvider       string
+	CacheAirQualityTTL    time.Duration
+	AirQualityProvider    string
+
+	CacheBackend  string
+	CacheCodec    string
+	CacheL1TTL    time.Duration
+	CacheSnapshot string
+
+	CacheMaxItems           int
+	CacheMaxLocationItems   int
+	CacheMaxWeatherItems    int
+	CacheMaxForecastItems   int
+	CacheMaxHistoryItems    int
+	CacheMaxAirQualityItems int
+	CacheMaxNegItems        int
+	CacheMaxBytes           int64
+	CacheEvictionPolicy     string
+
+	AdminToken string
+
+	ReadingsPath      string
+	ReadingsRetention time.Duration
+
+	AlertsEnabled             bool
+	AlertsPath                string
+	AlertsInterval            time.Duration
+	AlertsMaxSubscriptions    int
+	AlertsWebhookTimeout      time.Duration
+	AlertsWebhookMaxAttempts  int
+	AlertsWebhookBaseDelay    time.Duration
+	AlertsWebhookMaxDelay     time.Duration
+	AlertsWebhookAllowPrivate bool
+	AlertsAPIKeys             []string
+
+	WarmSource     string
+	WarmFile       string
+	WarmTopK       int
+	WarmInterval   time.Duration
+	WarmRate       float64
+	RedisAddr      string
+	RedisPassword  string
+	RedisDB        int
+	RedisKeyPrefix string
+
+	WeatherQuotaDaily      int64
+	WeatherQuotaMonthly    int64
+	WeatherQuotaThreshold  float64
+	WeatherQuotaStatePath  string
+	WeatherQuotaMode       string
+	OpenMeteoURL           string
+	OpenMeteoGeocodingURL  string
+	OpenMeteoArchiveURL    string
+	OpenMeteoAirQualityURL string
+
+	WeatherAPIKeySelection string
+	WeatherAPIKeyCooldown  time.Duration
+	WeatherAPIKeysFile     string
+	SecretsReloadInterval  time.Duration
+
+	RetryMaxAttempts int
+	RetryBaseDelay   time.Duration
+	RetryMaxDelay    time.Duration
+
+	BreakerFailureThreshold int
+	BreakerCoolDown         time.Duration
+	BreakerHalfOpenMaxCalls int
+
+	// secretErr reports a *_FILE secret that could not be read
+	secretErr error
 }
 
+// CEP lookup modes
+const (
+	CEPLookupOnline  = "online"  // somente OpenCEP
+	CEPLookupOffline = "offline" // somente o índice local
+	CEPLookupChain   = "chain"   // índice local, com fallback para o OpenCEP
+)
+
+// Weather TTL modes
+const (
+	WeatherTTLFixed    = "fixed"    // CACHE_WEATHER_TTL para todos os dados meteorológicos
+	WeatherTTLUpstream = "upstream" // até a próxima atualização esperada da WeatherAPI
+)
+
+// Cache backends
+const (
+	CacheBackendMemory = "memory" // cache local de cada réplica
+	CacheBackendRedis  = "redis"  // cache compartilhado entre as réplicas
+	CacheBackendTiered = "tiered" // cache local (L1) na frente do Redis (L2)
+)
+
+// Actions once the weather API budget threshold is crossed
+const (
+	QuotaModeCacheOnly = "cache-only" // responde somente com dados em cache
+	QuotaModeFallback  = "fallback"   // consulta a Open-Meteo
+)
+
+// Historical weather providers
+const (
+	HistoryProviderWeatherAPI = "weatherapi" // history.json, sujeito à cota e ao período do plano
+	HistoryProviderOpenMeteo  = "openmeteo"  // arquivo histórico da Open-Meteo, sem chave de API
+)
+
+// Air quality providers
+const (
+	AirQualityOff        = "off"        // qualidade do ar desativada
+	AirQualityWeatherAPI = "weatherapi" // current.json com aqi=yes, sujeito à cota
+	AirQualityOpenMeteo  = "openmeteo"  // API de qualidade do ar da Open-Meteo, sem chave de API
+)
+
+// Cache warming sources
+const (
+	WarmSourceOff     = "off"     // aquecimento desativado
+	WarmSourceFile    = "file"    // CEPs listados em WARM_FILE
+	WarmSourceTraffic = "traffic" // CEPs mais consultados recentemente
+)
+
 // LoadConfig loads configuration from environment variables
 func LoadConfig() *Config {
-	return &Config{
-		Port:            getEnv("PORT", "8081"),
-		WeatherAPIKey:   getEnv("WEATHER_API_KEY", ""),
-		WeatherAPIURL:   getEnv("WEATHER_API_URL", "http://api.weatherapi.com/v1"),
-		OpenCEPURL:      getEnv("OPENCEP_API_URL", "https://opencep.com"),
-		RequestTimeout:  getEnvDuration("REQUEST_TIMEOUT", 10*time.Second),
-		CacheTTL:        getEnvDuration("CACHE_TTL", 1*time.Hour),
-		CacheCleanup:    getEnvDuration("CACHE_CLEANUP", 10*time.Minute),
+	maxItems := getEnvInt("CACHE_MAX_ITEMS", 10000)
+	ttls := cache.DefaultTTLPolicy()
+
+	cfg := &Config{
+		Port:           getEnv("PORT", "8081"),
+		WeatherAPIURL:  getEnv("WEATHER_API_URL", "http://api.weatherapi.com/v1"),
+		OpenCEPURL:     getEnv("OPENCEP_API_URL", "https://opencep.com"),
+		RequestTimeout: getEnvDuration("REQUEST_TIMEOUT", 10*time.Second),
+		CacheTTL:       getEnvDuration("CACHE_TTL", 1*time.Hour),
+		CacheCleanup:   getEnvDuration("CACHE_CLEANUP", 10*time.Minute),
+		CacheStaleTTL:  getEnvDuration("CACHE_STALE_WHILE_REVALIDATE", 5*time.Minute),
+		CacheMaxStale:  getEnvDuration("CACHE_MAX_STALE", 1*time.Hour),
+		CacheNegTTL:    getEnvDuration("CACHE_NEGATIVE_TTL", 5*time.Minute),
+		CEPLookupMode:  getEnv("CEP_LOOKUP_MODE", CEPLookupOnline),
+		CEPIndexPath:   getEnv("CEP_INDEX_PATH", ""),
+
+		CacheLocationTTL:      getEnvDuration("CACHE_LOCATION_TTL", ttls.Location),
+		CacheWeatherTTL:       getEnvDuration("CACHE_WEATHER_TTL", ttls.Weather),
+		CacheWeatherTTLMode:   getEnv("CACHE_WEATHER_TTL_MODE", WeatherTTLFixed),
+		CacheWeatherMinTTL:    getEnvDuration("CACHE_WEATHER_MIN_TTL", 1*time.Minute),
+		WeatherUpdateInterval: getEnvDuration("WEATHER_API_UPDATE_INTERVAL", 15*time.Minute),
+		CacheForecastTTL:      getEnvDuration("CACHE_FORECAST_TTL", ttls.Forecast),
+		ForecastMaxDays:       getEnvInt("FORECAST_MAX_DAYS", 3),
+		CacheHistoryTTL:       getEnvDuration("CACHE_HISTORY_TTL", ttls.History),
+		HistoryProvider:       getEnv("HISTORY_PROVIDER", HistoryProviderWeatherAPI),
+		CacheAirQualityTTL:    getEnvDuration("CACHE_AIR_QUALITY_TTL", ttls.AirQuality),
+		AirQualityProvider:    getEnv("AIR_QUALITY_PROVIDER", AirQualityOff),
+
+		CacheBackend:  getEnv("CACHE_BACKEND", CacheBackendMemory),
+		CacheCodec:    getEnv("CACHE_CODEC", "json"),
+		CacheL1TTL:    getEnvDuration("CACHE_L1_TTL", 1*time.Minute),
+		CacheSnapshot: getEnv("CACHE_SNAPSHOT_PATH", ""),
+
+		CacheMaxItems:           maxItems,
+		CacheMaxLocationItems:   getEnvInt("CACHE_MAX_LOCATION_ITEMS", maxItems),
+		CacheMaxWeatherItems:    getEnvInt("CACHE_MAX_WEATHER_ITEMS", maxItems),
+		CacheMaxForecastItems:   getEnvInt("CACHE_MAX_FORECAST_ITEMS", maxItems),
+		CacheMaxHistoryItems:    getEnvInt("CACHE_MAX_HISTORY_ITEMS", maxItems),
+		CacheMaxAirQualityItems: getEnvInt("CACHE_MAX_AIR_QUALITY_ITEMS", maxItems),
+		CacheMaxNegItems:        getEnvInt("CACHE_MAX_NEGATIVE_ITEMS", maxItems),
+		CacheMaxBytes:           int64(getEnvInt("CACHE_MAX_BYTES", 0)),
+		CacheEvictionPolicy:     getEnv("CACHE_EVICTION_POLICY", string(cache.EvictLRU)),
+
+		AdminToken: getEnv("ADMIN_TOKEN", ""),
+
+		ReadingsPath:      getEnv("READINGS_PATH", ""),
+		ReadingsRetention: getEnvDuration("READINGS_RETENTION", 30*24*time.Hour),
+
+		AlertsEnabled:             getEnvBool("ALERTS_ENABLED", false),
+		AlertsPath:                getEnv("ALERTS_PATH", ""),
+		AlertsInterval:            getEnvDuration("ALERTS_INTERVAL", 5*time.Minute),
+		AlertsMaxSubscriptions:    getEnvInt("ALERTS_MAX_SUBSCRIPTIONS", 1000),
+		AlertsWebhookTimeout:      getEnvDuration("ALERTS_WEBHOOK_TIMEOUT", 10*time.Second),
+		AlertsWebhookMaxAttempts:  getEnvInt("ALERTS_WEBHOOK_MAX_ATTEMPTS", 5),
+		AlertsWebhookBaseDelay:    getEnvDuration("ALERTS_WEBHOOK_BASE_DELAY", 1*time.Second),
+		AlertsWebhookMaxDelay:     getEnvDuration("ALERTS_WEBHOOK_MAX_DELAY", 1*time.Minute),
+		AlertsWebhookAllowPrivate: getEnvBool("ALERTS_WEBHOOK_ALLOW_PRIVATE", false),
+
+		WarmSource:     getEnv("WARM_SOURCE", WarmSourceOff),
+		WarmFile:       getEnv("WARM_FILE", ""),
+		WarmTopK:       getEnvInt("WARM_TOP_K", 1000),
+		WarmInterval:   getEnvDuration("WARM_INTERVAL", 5*time.Minute),
+		WarmRate:       getEnvFloat("WARM_RATE", 1),
+		RedisAddr:      getEnv("REDIS_ADDR", "localhost:6379"),
+		RedisPassword:  getEnv("REDIS_PASSWORD", ""),
+		RedisDB:        getEnvInt("REDIS_DB", 0),
+		RedisKeyPrefix: getEnv("REDIS_KEY_PREFIX", "goexpertotel:service-b:"),
+
+		WeatherQuotaDaily:      int64(getEnvInt("WEATHER_QUOTA_DAILY", 0)),
+		WeatherQuotaMonthly:    int64(getEnvInt("WEATHER_QUOTA_MONTHLY", 0)),
+		WeatherQuotaThreshold:  getEnvFloat("WEATHER_QUOTA_THRESHOLD", 0.9),
+		WeatherQuotaStatePath:  getEnv("WEATHER_QUOTA_STATE_PATH", ""),
+		WeatherQuotaMode:       getEnv("WEATHER_QUOTA_MODE", QuotaModeCacheOnly),
+		OpenMeteoURL:           getEnv("OPEN_METEO_URL", "https://api.open-meteo.com"),
+		OpenMeteoGeocodingURL:  getEnv("OPEN_METEO_GEOCODING_URL", "https://geocoding-api.open-meteo.com"),
+		OpenMeteoArchiveURL:    getEnv("OPEN_METEO_ARCHIVE_URL", "https://archive-api.open-meteo.com"),
+		OpenMeteoAirQualityURL: getEnv("OPEN_METEO_AIR_QUALITY_URL", "https://air-quality-api.open-meteo.com"),
+
+		WeatherAPIKeySelection: getEnv("WEATHER_API_KEY_SELECTION", string(keypool.RoundRobin)),
+		WeatherAPIKeyCooldown:  getEnvDuration("WEATHER_API_KEY_COOLDOWN", 15*time.Minute),
+		SecretsReloadInterval:  getEnvDuration("SECRETS_RELOAD_INTERVAL", 30*time.Second),
+
+		RetryMaxAttempts: getEnvInt("RETRY_MAX_ATTEMPTS", 3),
+		RetryBaseDelay:   getEnvDuration("RETRY_BASE_DELAY", 100*time.Millisecond),
+		RetryMaxDelay:    getEnvDuration("RETRY_MAX_DELAY", 2*time.Second),
+
+		BreakerFailureThreshold: getEnvInt("BREAKER_FAILURE_THRESHOLD", 5),
+		BreakerCoolDown:         getEnvDuration("BREAKER_COOLDOWN", 30*time.Second),
+		BreakerHalfOpenMaxCalls: getEnvInt("BREAKER_HALF_OPEN_MAX_CALLS", 1),
+	}
+	cfg.loadSecrets()
+	return cfg
+}
+
+// loadSecrets loads the secrets, preferring the *_FILE variants (Docker/Kubernetes
+// secrets) over the plain environment variables
+func (c *Config) loadSecrets() {
+	c.AdminToken = c.getSecret("ADMIN_TOKEN", c.AdminToken)
+	c.RedisPassword = c.getSecret("REDIS_PASSWORD", c.RedisPassword)
+	c.AlertsAPIKeys = secrets.ParseList(c.getSecret("ALERTS_API_KEYS", os.Getenv("ALERTS_API_KEYS")))
+
+	// WeatherAPI keys: WEATHER_API_KEYS(_FILE) takes precedence over WEATHER_API_KEY(_FILE)
+	for _, key := range []string{"WEATHER_API_KEYS", "WEATHER_API_KEY"} {
+		if path := os.Getenv(key + "_FILE"); path != "" {
+			c.WeatherAPIKeysFile = path
+			c.WeatherAPIKeys = secrets.ParseList(c.getSecret(key, ""))
+			return
+		}
+		if keys := getEnvList(key, nil); len(keys) > 0 {
+			c.WeatherAPIKeys = keys
+			return
+		}
+	}
+}
+
+// getSecret reads the secret from the file named by key_FILE, if set;
+// otherwise returns value
+func (c *Config) getSecret(key, value string) string {
+	path := os.Getenv(key + "_FILE")
+	if path == "" {
+		return value
+	}
+	secret, err := secrets.ReadFile(path)
+	if err != nil {
+		if c.secretErr == nil {
+			c.secretErr = &ConfigError{Field: key + "_FILE", Message: "não pôde ser lido: " + err.Error()}
+		}
+		return value
+	}
+	return secret
+}
+
+// CacheOptions builds the cache options, with the size limits applied to each item type
+func (c *Config) CacheOptions() cache.Options {
+	limit := func(maxItems int) cache.Limit {
+		return cache.Limit{MaxItems: maxItems, MaxBytes: c.CacheMaxBytes}
+	}
+	return cache.Options{
+		StaleWhileRevalidate: c.CacheStaleTTL,
+		MaxStale:             c.CacheMaxStale,
+		NegativeTTL:          c.CacheNegTTL,
+		Limits: map[string]cache.Limit{
+			cache.TypeLocation:   limit(c.CacheMaxLocationItems),
+			cache.TypeWeather:    limit(c.CacheMaxWeatherItems),
+			cache.TypeForecast:   limit(c.CacheMaxForecastItems),
+			cache.TypeHistory:    limit(c.CacheMaxHistoryItems),
+			cache.TypeAirQuality: limit(c.CacheMaxAirQualityItems),
+			cache.TypeNegative:   limit(c.CacheMaxNegItems),
+		},
+		Eviction: cache.EvictionPolicy(c.CacheEvictionPolicy),
+	}
+}
+
+// TTLPolicy builds the TTL of each cache type; in upstream mode weather
+// expires when WeatherAPI is expected to refresh it
+func (c *Config) TTLPolicy() cache.TTLPolicy {
+	ttls := cache.TTLPolicy{
+		Location:      c.CacheLocationTTL,
+		Weather:       c.CacheWeatherTTL,
+		Forecast:      c.CacheForecastTTL,
+		History:       c.CacheHistoryTTL,
+		AirQuality:    c.CacheAirQualityTTL,
+		WeatherMinTTL: c.CacheWeatherMinTTL,
+	}
+	if c.CacheWeatherTTLMode == WeatherTTLUpstream {
+		ttls.WeatherUpdateInterval = c.WeatherUpdateInterval
+	}
+	return ttls
+}
+
+// QuotaOptions builds the weather API budget
+func (c *Config) QuotaOptions() quota.Options {
+	return quota.Options{
+		DailyLimit:   c.WeatherQuotaDaily,
+		MonthlyLimit: c.WeatherQuotaMonthly,
+		Threshold:    c.WeatherQuotaThreshold,
+		StatePath:    c.WeatherQuotaStatePath,
+	}
+}
+
+// WeatherAPIKeyPool builds the pool of WeatherAPI keys
+func (c *Config) WeatherAPIKeyPool() *keypool.Pool {
+	return keypool.New("weatherapi", c.WeatherAPIKeys, keypool.Strategy(c.WeatherAPIKeySelection), c.WeatherAPIKeyCooldown)
+}
+
+// RetryPolicy builds the retry policy shared by the external API clients
+func (c *Config) RetryPolicy() retry.Policy {
+	policy := retry.DefaultPolicy()
+	policy.MaxAttempts = c.RetryMaxAttempts
+	policy.BaseDelay = c.RetryBaseDelay
+	policy.MaxDelay = c.RetryMaxDelay
+	return policy
+}
+
+// AlertsWebhookPolicy builds the retry policy of the alert webhook deliveries
+func (c *Config) AlertsWebhookPolicy() retry.Policy {
+	policy := retry.DefaultPolicy()
+	policy.MaxAttempts = c.AlertsWebhookMaxAttempts
+	policy.BaseDelay = c.AlertsWebhookBaseDelay
+	policy.MaxDelay = c.AlertsWebhookMaxDelay
+	return policy
+}
+
+// BreakerSettings builds the circuit breaker settings used for each external dependency
+func (c *Config) BreakerSettings() breaker.Settings {
+	return breaker.Settings{
+		FailureThreshold: c.BreakerFailureThreshold,
+		CoolDown:         c.BreakerCoolDown,
+		HalfOpenMaxCalls: c.BreakerHalfOpenMaxCalls,
 	}
 }
 
 // ValidateConfig validates required configuration
 func (c *Config) ValidateConfig() error {
-	if c.WeatherAPIKey == "" {
-		return &ConfigError{Field: "WEATHER_API_KEY", Message: "é obrigatória"}
+	if c.secretErr != nil {
+		return c.secretErr
+	}
+	if len(c.WeatherAPIKeys) == 0 {
+		return &ConfigError{Field: "WEATHER_API_KEY", Message: "ou WEATHER_API_KEYS é obrigatória"}
+	}
+	switch keypool.Strategy(c.WeatherAPIKeySelection) {
+	case keypool.RoundRobin, keypool.LeastUsed:
+	default:
+		return &ConfigError{Field: "WEATHER_API_KEY_SELECTION", Message: "deve ser round-robin ou least-used"}
+	}
+	if c.WeatherAPIKeyCooldown <= 0 {
+		return &ConfigError{Field: "WEATHER_API_KEY_COOLDOWN", Message: "deve ser positivo"}
+	}
+	if c.WeatherAPIKeysFile != "" && c.SecretsReloadInterval <= 0 {
+		return &ConfigError{Field: "SECRETS_RELOAD_INTERVAL", Message: "deve ser positivo"}
+	}
+	switch c.CEPLookupMode {
+	case CEPLookupOnline:
+	case CEPLookupOffline, CEPLookupChain:
+		if c.CEPIndexPath == "" {
+			return &ConfigError{Field: "CEP_INDEX_PATH", Message: "é obrigatório no modo " + c.CEPLookupMode}
+		}
+	default:
+		return &ConfigError{Field: "CEP_LOOKUP_MODE", Message: "deve ser online, offline ou chain"}
+	}
+	if c.CacheLocationTTL <= 0 || c.CacheWeatherTTL <= 0 {
+		return &ConfigError{Field: "CACHE_LOCATION_TTL", Message: "e CACHE_WEATHER_TTL devem ser positivos"}
+	}
+	if c.CacheForecastTTL <= 0 {
+		return &ConfigError{Field: "CACHE_FORECAST_TTL", Message: "deve ser positivo"}
+	}
+	if c.ForecastMaxDays < 1 || c.ForecastMaxDays > 14 {
+		return &ConfigError{Field: "FORECAST_MAX_DAYS", Message: "deve estar entre 1 e 14"}
+	}
+	if c.CacheHistoryTTL <= 0 {
+		return &ConfigError{Field: "CACHE_HISTORY_TTL", Message: "deve ser positivo"}
+	}
+	switch c.HistoryProvider {
+	case HistoryProviderWeatherAPI, HistoryProviderOpenMeteo:
+	default:
+		return &ConfigError{Field: "HISTORY_PROVIDER", Message: "deve ser weatherapi ou openmeteo"}
+	}
+	switch c.AirQualityProvider {
+	case AirQualityOff, AirQualityWeatherAPI, AirQualityOpenMeteo:
+	default:
+		return &ConfigError{Field: "AIR_QUALITY_PROVIDER", Message: "deve ser off, weatherapi ou openmeteo"}
+	}
+	if c.CacheAirQualityTTL <= 0 {
+		return &ConfigError{Field: "CACHE_AIR_QUALITY_TTL", Message: "deve ser positivo"}
+	}
+	if c.ReadingsPath != "" && c.ReadingsRetention <= 0 {
+		return &ConfigError{Field: "READINGS_RETENTION", Message: "deve ser positivo"}
+	}
+	if c.AlertsEnabled {
+		if c.AlertsInterval <= 0 || c.AlertsWebhookTimeout <= 0 {
+			return &ConfigError{Field: "ALERTS_INTERVAL", Message: "e ALERTS_WEBHOOK_TIMEOUT devem ser positivos"}
+		}
+		if c.AlertsWebhookMaxAttempts < 1 || c.AlertsWebhookBaseDelay < 0 || c.AlertsWebhookMaxDelay < 0 {
+			return &ConfigError{Field: "ALERTS_WEBHOOK_MAX_ATTEMPTS", Message: "deve ser pelo menos 1 e os atrasos não podem ser negativos"}
+		}
+		if c.AlertsMaxSubscriptions < 0 {
+			return &ConfigError{Field: "ALERTS_MAX_SUBSCRIPTIONS", Message: "não pode ser negativo"}
+		}
+		if len(c.AlertsAPIKeys) == 0 {
+			return &ConfigError{Field: "ALERTS_API_KEYS", Message: "é obrigatório com ALERTS_ENABLED=true"}
+		}
+	}
+	switch c.CacheWeatherTTLMode {
+	case WeatherTTLFixed:
+	case WeatherTTLUpstream:
+		if c.WeatherUpdateInterval <= 0 {
+			return &ConfigError{Field: "WEATHER_API_UPDATE_INTERVAL", Message: "deve ser positivo com CACHE_WEATHER_TTL_MODE=upstream"}
+		}
+		if c.CacheWeatherMinTTL <= 0 {
+			return &ConfigError{Field: "CACHE_WEATHER_MIN_TTL", Message: "deve ser positivo com CACHE_WEATHER_TTL_MODE=upstream"}
+		}
+	default:
+		return &ConfigError{Field: "CACHE_WEATHER_TTL_MODE", Message: "deve ser fixed ou upstream"}
+	}
+	if c.WeatherQuotaDaily < 0 || c.WeatherQuotaMonthly < 0 {
+		return &ConfigError{Field: "WEATHER_QUOTA_DAILY", Message: "e WEATHER_QUOTA_MONTHLY não podem ser negativos"}
+	}
+	if c.WeatherQuotaThreshold <= 0 || c.WeatherQuotaThreshold > 1 {
+		return &ConfigError{Field: "WEATHER_QUOTA_THRESHOLD", Message: "deve estar entre 0 e 1"}
+	}
+	switch c.WeatherQuotaMode {
+	case QuotaModeCacheOnly, QuotaModeFallback:
+	default:
+		return &ConfigError{Field: "WEATHER_QUOTA_MODE", Message: "deve ser cache-only ou fallback"}
+	}
+	switch c.CacheBackend {
+	case CacheBackendMemory, CacheBackendRedis, CacheBackendTiered:
+	default:
+		return &ConfigError{Field: "CACHE_BACKEND", Message: "deve ser memory, redis ou tiered"}
+	}
+	switch c.CacheCodec {
+	case "json", "msgpack":
+	default:
+		return &ConfigError{Field: "CACHE_CODEC", Message: "deve ser json ou msgpack"}
+	}
+	switch c.WarmSource {
+	case WarmSourceOff, WarmSourceTraffic:
+	case WarmSourceFile:
+		if c.WarmFile == "" {
+			return &ConfigError{Field: "WARM_FILE", Message: "é obrigatório com WARM_SOURCE=file"}
+		}
+	default:
+		return &ConfigError{Field: "WARM_SOURCE", Message: "deve ser off, file ou traffic"}
+	}
+	if c.WarmSource != WarmSourceOff && (c.WarmRate <= 0 || c.WarmInterval <= 0) {
+		return &ConfigError{Field: "WARM_RATE", Message: "e WARM_INTERVAL devem ser positivos"}
+	}
+	switch cache.EvictionPolicy(c.CacheEvictionPolicy) {
+	case cache.EvictLRU, cache.EvictLFU:
+	default:
+		return &ConfigError{Field: "CACHE_EVICTION_POLICY", Message: "deve ser lru ou lfu"}
 	}
 	return nil
 }
@@ -46,6 +500,20 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvList gets a comma-separated list environment variable with a default value
+func getEnvList(key string, defaultValue []string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return defaultValue
+	}
+	return values
+}
+
 // getEnvDuration gets a duration environment variable with a default value
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
@@ -66,6 +534,16 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvFloat gets a float environment variable with a default value
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 // getEnvBool gets a boolean environment variable with a default value
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
@@ -84,4 +562,4 @@ type ConfigError struct {
 
 func (e *ConfigError) Error() string {
 	return "configuração inválida: " + e.Field + " " + e.Message
-}
\ No newline at end of file
+}
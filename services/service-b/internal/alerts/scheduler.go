@@ -0,0 +1,234 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lcidral/goExpertOtel/pkg/models"
+	"github.com/lcidral/goExpertOtel/pkg/telemetry"
+)
+
+// TemperatureSource fornece a temperatura atual de um CEP, de preferência do cache
+type TemperatureSource interface {
+	CurrentTemperature(ctx context.Context, cep string) (city string, temperature models.TemperatureUnits, err error)
+}
+
+var triggeredCounter, _ = telemetry.Meter().Int64Counter("alerts.triggered",
+	metric.WithDescription("Temperature alerts triggered"),
+)
+
+// Scheduler verifica periodicamente as assinaturas. Cada CEP assinado é
+// consultado uma vez por ciclo; o alerta dispara quando o limite é cruzado
+// e só volta a disparar depois que a temperatura retorna para o lado de
+// cá do limite. Entregas que falham vão para a lista de entregas com falha.
+type Scheduler struct {
+	store    *Store
+	source   TemperatureSource
+	notifier *Notifier
+	interval time.Duration
+	now      func() time.Time
+
+	deliveries sync.WaitGroup
+}
+
+// NewScheduler cria o agendador; interval é o tempo entre dois ciclos
+func NewScheduler(store *Store, source TemperatureSource, notifier *Notifier, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	return &Scheduler{
+		store:    store,
+		source:   source,
+		notifier: notifier,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run executa ciclos de verificação até ctx ser cancelado, aguardando as
+// entregas em andamento antes de retornar
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	defer s.deliveries.Wait()
+
+	for {
+		s.RunCycle(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunCycle verifica uma vez todas as assinaturas. As entregas são feitas em
+// segundo plano, para que um webhook lento não atrase os demais CEPs.
+func (s *Scheduler) RunCycle(ctx context.Context) {
+	subs := s.store.List("", "")
+	if len(subs) == 0 {
+		return
+	}
+
+	ctx, span := telemetry.StartSpan(ctx, "alerts.cycle",
+		attribute.Int("alerts.subscriptions", len(subs)),
+	)
+	defer span.End()
+
+	byCEP := make(map[string][]Subscription)
+	var ceps []string
+	for _, sub := range subs {
+		if _, found := byCEP[sub.CEP]; !found {
+			ceps = append(ceps, sub.CEP)
+		}
+		byCEP[sub.CEP] = append(byCEP[sub.CEP], sub)
+	}
+
+	triggered, failed := 0, 0
+	for _, cep := range ceps {
+		if ctx.Err() != nil {
+			break
+		}
+		count, err := s.evaluate(ctx, cep, byCEP[cep])
+		if err != nil {
+			failed++
+			span.AddEvent("alerts.evaluate.failed", trace.WithAttributes(
+				attribute.String("cep.value", cep),
+				attribute.String("error.message", err.Error()),
+			))
+		}
+		triggered += count
+	}
+
+	span.SetAttributes(
+		attribute.Int("alerts.ceps", len(ceps)),
+		attribute.Int("alerts.triggered", triggered),
+		attribute.Int("alerts.failed", failed),
+	)
+	if ctx.Err() != nil {
+		span.SetStatus(codes.Error, "Alerts cycle interrupted")
+		return
+	}
+	span.SetStatus(codes.Ok, "Alerts cycle completed")
+	if triggered > 0 || failed > 0 {
+		log.Printf("🔔 Alertas: %d CEPs verificados, %d alertas disparados, %d falhas", len(ceps), triggered, failed)
+	}
+}
+
+// evaluate consulta a temperatura do CEP e dispara os alertas cujo limite
+// foi cruzado, retornando quantos dispararam
+func (s *Scheduler) evaluate(ctx context.Context, cep string, subs []Subscription) (int, error) {
+	ctx, span := telemetry.StartSpan(ctx, "alerts.evaluate",
+		attribute.String("cep.value", cep),
+		attribute.Int("alerts.subscriptions", len(subs)),
+	)
+	defer span.End()
+
+	city, temperature, err := s.source.CurrentTemperature(ctx, cep)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to get temperature")
+		return 0, err
+	}
+	span.SetAttributes(
+		attribute.String("city.name", city),
+		attribute.Float64("temp_c", temperature.C),
+	)
+
+	now := s.now().UTC()
+	triggered := 0
+	for _, sub := range subs {
+		value := valueIn(temperature, sub.Unit)
+		crossed := sub.Crossed(value)
+		if err := s.store.UpdateState(sub.ID, value, crossed, now); err != nil {
+			log.Printf("Erro ao salvar estado do alerta %s: %v", sub.ID, err)
+		}
+		if !crossed || sub.Triggered {
+			continue
+		}
+
+		deliveryID, err := randomHex(16)
+		if err != nil {
+			span.RecordError(err)
+			continue
+		}
+		event := Event{
+			ID:             deliveryID,
+			SubscriptionID: sub.ID,
+			CEP:            cep,
+			City:           city,
+			Unit:           sub.Unit,
+			Threshold:      sub.Threshold,
+			Direction:      sub.Direction,
+			Temperature:    value,
+			TriggeredAt:    now,
+		}
+		triggered++
+		triggeredCounter.Add(ctx, 1)
+		span.AddEvent("alerts.triggered", trace.WithAttributes(
+			attribute.String("alert.subscription_id", sub.ID),
+			attribute.String("alert.delivery_id", deliveryID),
+			attribute.Float64("alert.threshold", sub.Threshold),
+			attribute.String("alert.direction", sub.Direction),
+		))
+		s.deliver(ctx, sub, event)
+	}
+
+	span.SetAttributes(attribute.Int("alerts.triggered", triggered))
+	span.SetStatus(codes.Ok, "Subscriptions evaluated")
+	return triggered, nil
+}
+
+// deliver entrega o evento em segundo plano, no trace do ciclo, e registra a
+// entrega na lista de falhas se todas as tentativas falharem ou se o serviço
+// for desligado antes de concluí-la
+func (s *Scheduler) deliver(ctx context.Context, sub Subscription, event Event) {
+	s.deliveries.Add(1)
+	go func() {
+		defer s.deliveries.Done()
+
+		err := s.notifier.Deliver(ctx, sub.WebhookURL, sub.Secret, event)
+		if err == nil {
+			return
+		}
+
+		attempts := 0
+		var deliveryErr *DeliveryError
+		if errors.As(err, &deliveryErr) {
+			attempts = deliveryErr.Attempts
+		}
+		log.Printf("Erro ao entregar alerta %s para %s: %v", event.ID, RedactURL(sub.WebhookURL), err)
+		letter := DeadLetter{
+			Owner:      sub.Owner,
+			Event:      event,
+			WebhookURL: RedactURL(sub.WebhookURL),
+			Attempts:   attempts,
+			LastError:  err.Error(),
+			FailedAt:   s.now().UTC(),
+		}
+		if err := s.store.AddDeadLetter(letter); err != nil {
+			log.Printf("Erro ao registrar entrega com falha do alerta %s: %v", event.ID, err)
+		}
+	}()
+}
+
+// valueIn retorna a temperatura na unidade da assinatura
+func valueIn(temperature models.TemperatureUnits, unit string) float64 {
+	switch unit {
+	case "F":
+		return temperature.F
+	case "K":
+		return temperature.K
+	default:
+		return temperature.C
+	}
+}
@@ -0,0 +1,181 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lcidral/goExpertOtel/pkg/models"
+	"github.com/lcidral/goExpertOtel/pkg/retry"
+)
+
+// fakeSource retorna a temperatura definida pelo teste
+type fakeSource struct {
+	mu    sync.Mutex
+	tempC float64
+}
+
+func (f *fakeSource) set(tempC float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tempC = tempC
+}
+
+func (f *fakeSource) CurrentTemperature(_ context.Context, _ string) (string, models.TemperatureUnits, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return "São Paulo", models.TemperatureUnits{C: f.tempC, F: math.Round((f.tempC*1.8+32)*10) / 10, K: f.tempC + 273}, nil
+}
+
+// webhookReceiver registra as entregas e responde com os status de statuses, em ordem
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	events   []Event
+	requests int
+}
+
+func (wr *webhookReceiver) handler(t *testing.T, secret *string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if got, want := r.Header.Get(HeaderSignature), Sign(*secret, timestamp, body); got != want {
+			t.Errorf("assinatura = %s, esperava %s", got, want)
+		}
+		if r.Header.Get(HeaderDelivery) == "" {
+			t.Errorf("entrega sem o cabeçalho %s", HeaderDelivery)
+		}
+
+		wr.mu.Lock()
+		defer wr.mu.Unlock()
+		status := http.StatusOK
+		if wr.requests < len(wr.statuses) {
+			status = wr.statuses[wr.requests]
+		}
+		wr.requests++
+		if status == http.StatusOK {
+			var event Event
+			if err := json.Unmarshal(body, &event); err != nil {
+				t.Errorf("erro ao decodificar evento: %v", err)
+			}
+			wr.events = append(wr.events, event)
+		}
+		w.WriteHeader(status)
+	}
+}
+
+func TestScheduler_EdgeTriggeredDelivery(t *testing.T) {
+	tests := []struct {
+		name           string
+		statuses       []int
+		wantRequests   int
+		wantDelivered  int
+		wantDeadLetter bool
+	}{
+		{"Entregue na primeira tentativa", nil, 1, 1, false},
+		{"Entregue após falhas transitórias", []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}, 3, 1, false},
+		{"Tentativas esgotadas", []int{500, 500, 500}, 3, 0, true},
+		{"Falha permanente", []int{http.StatusGone}, 1, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := Open(filepath.Join(t.TempDir(), "alerts.json"), 0)
+			if err != nil {
+				t.Fatalf("Open() erro inesperado = %v", err)
+			}
+
+			var secret string
+			receiver := &webhookReceiver{statuses: tt.statuses}
+			server := httptest.NewServer(receiver.handler(t, &secret))
+			defer server.Close()
+
+			sub, err := store.Create(Subscription{CEP: "01001000", Unit: "F", Threshold: 86, Direction: DirectionAbove, WebhookURL: server.URL + "/hooks/T0KEN"})
+			if err != nil {
+				t.Fatalf("Create() erro inesperado = %v", err)
+			}
+			secret = sub.Secret
+
+			source := &fakeSource{tempC: 25}
+			notifier := NewNotifier(time.Second, retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}, true)
+			scheduler := NewScheduler(store, source, notifier, time.Minute)
+			cycle := func() {
+				scheduler.RunCycle(context.Background())
+				scheduler.deliveries.Wait()
+			}
+
+			cycle() // 77°F: abaixo do limite
+			source.set(31)
+			cycle() // 87.8°F: dispara
+			cycle() // continua acima: não dispara de novo
+
+			if receiver.requests != tt.wantRequests || len(receiver.events) != tt.wantDelivered {
+				t.Fatalf("requisições = %d, entregas = %d, esperava %d e %d",
+					receiver.requests, len(receiver.events), tt.wantRequests, tt.wantDelivered)
+			}
+			if tt.wantDelivered > 0 {
+				event := receiver.events[0]
+				if event.SubscriptionID != sub.ID || event.Temperature != 87.8 || event.Unit != "F" {
+					t.Errorf("evento = %+v, esperava assinatura %s a 87.8°F", event, sub.ID)
+				}
+			}
+			letters := store.DeadLetters("")
+			if (len(letters) == 1) != tt.wantDeadLetter {
+				t.Errorf("entregas com falha = %+v, esperava registro = %v", letters, tt.wantDeadLetter)
+			}
+			if len(letters) == 1 && (strings.Contains(letters[0].WebhookURL, "T0KEN") || strings.Contains(letters[0].LastError, "T0KEN")) {
+				t.Errorf("entrega com falha = %+v, esperava o webhook sem o token", letters[0])
+			}
+
+			// Volta abaixo do limite e cruza de novo: novo alerta
+			source.set(20)
+			cycle()
+			source.set(32)
+			cycle()
+			if got := receiver.requests; got <= tt.wantRequests {
+				t.Errorf("requisições após rearmar = %d, esperava mais que %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestStore_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	store, err := Open(path, 2)
+	if err != nil {
+		t.Fatalf("Open() erro inesperado = %v", err)
+	}
+
+	first, _ := store.Create(Subscription{CEP: "01001000", Unit: "C", Threshold: 30, Direction: DirectionAbove, WebhookURL: "http://example.com"})
+	second, _ := store.Create(Subscription{CEP: "20040020", Unit: "C", Threshold: 10, Direction: DirectionBelow, WebhookURL: "http://example.com"})
+	if _, err := store.Create(Subscription{CEP: "30130010"}); err != ErrLimitReached {
+		t.Errorf("Create() além do limite erro = %v, esperava ErrLimitReached", err)
+	}
+	if err := store.UpdateState(first.ID, 31, true, time.Now()); err != nil {
+		t.Fatalf("UpdateState() erro inesperado = %v", err)
+	}
+	if err := store.Delete("", second.ID); err != nil {
+		t.Fatalf("Delete() erro inesperado = %v", err)
+	}
+	if err := store.Delete("", second.ID); err != ErrNotFound {
+		t.Errorf("Delete() repetido erro = %v, esperava ErrNotFound", err)
+	}
+
+	reopened, err := Open(path, 2)
+	if err != nil {
+		t.Fatalf("Open() erro inesperado = %v", err)
+	}
+	subs := reopened.List("", "")
+	if len(subs) != 1 || subs[0].ID != first.ID || !subs[0].Triggered || subs[0].Secret != first.Secret {
+		t.Errorf("List() após reabrir = %+v, esperava somente %s, disparada e com o segredo", subs, first.ID)
+	}
+}
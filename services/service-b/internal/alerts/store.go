@@ -0,0 +1,313 @@
+package alerts
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/atomicfile"
+)
+
+// Direções de cruzamento do limite
+const (
+	DirectionAbove = "above" // temperatura acima do limite
+	DirectionBelow = "below" // temperatura abaixo do limite
+)
+
+// maxDeadLetters limita quantas entregas com falha são mantidas; as mais antigas são descartadas
+const maxDeadLetters = 1000
+
+var (
+	// ErrNotFound indica que a assinatura não existe
+	ErrNotFound = errors.New("assinatura não encontrada")
+	// ErrLimitReached indica que o número máximo de assinaturas foi atingido
+	ErrLimitReached = errors.New("limite de assinaturas atingido")
+)
+
+// Subscription assinatura de alerta de temperatura de um CEP
+type Subscription struct {
+	ID          string    `json:"id"`
+	Owner       string    `json:"owner"` // identificador da chave de API que criou a assinatura
+	CEP         string    `json:"cep"`
+	Unit        string    `json:"unit"` // C, F ou K
+	Threshold   float64   `json:"threshold"`
+	Direction   string    `json:"direction"`
+	WebhookURL  string    `json:"webhook_url"`
+	Secret      string    `json:"secret"` // chave HMAC das entregas
+	CreatedAt   time.Time `json:"created_at"`
+	Triggered   bool      `json:"triggered"` // limite cruzado e ainda não rearmado
+	LastValue   *float64  `json:"last_value,omitempty"`
+	LastChecked time.Time `json:"last_checked,omitempty"`
+}
+
+// Crossed indica se value está além do limite na direção da assinatura
+func (s *Subscription) Crossed(value float64) bool {
+	if s.Direction == DirectionBelow {
+		return value < s.Threshold
+	}
+	return value > s.Threshold
+}
+
+// Event alerta enviado ao webhook de uma assinatura
+type Event struct {
+	ID             string    `json:"id"`
+	SubscriptionID string    `json:"subscription_id"`
+	CEP            string    `json:"cep"`
+	City           string    `json:"city"`
+	Unit           string    `json:"unit"`
+	Threshold      float64   `json:"threshold"`
+	Direction      string    `json:"direction"`
+	Temperature    float64   `json:"temperature"`
+	TriggeredAt    time.Time `json:"triggered_at"`
+}
+
+// DeadLetter entrega que falhou em todas as tentativas
+type DeadLetter struct {
+	Owner      string    `json:"owner"`
+	Event      Event     `json:"event"`
+	WebhookURL string    `json:"webhook_url"` // somente esquema e host (RedactURL)
+	Attempts   int       `json:"attempts"`
+	LastError  string    `json:"last_error"`
+	FailedAt   time.Time `json:"failed_at"`
+}
+
+// state conteúdo do arquivo do Store
+type state struct {
+	Subscriptions []*Subscription `json:"subscriptions"`
+	DeadLetters   []DeadLetter    `json:"dead_letters"`
+}
+
+// Store mantém as assinaturas e as entregas com falha. Com path informado,
+// cada alteração é gravada no arquivo (substituído de forma atômica) e
+// restaurada ao abrir; sem path, os dados ficam somente em memória.
+type Store struct {
+	path             string
+	maxSubscriptions int
+	now              func() time.Time
+
+	mu            sync.Mutex
+	subscriptions map[string]*Subscription
+	deadLetters   []DeadLetter
+}
+
+// Open abre o store em path, que pode ser vazio; maxSubscriptions zero não limita
+func Open(path string, maxSubscriptions int) (*Store, error) {
+	s := &Store{
+		path:             path,
+		maxSubscriptions: maxSubscriptions,
+		now:              time.Now,
+		subscriptions:    make(map[string]*Subscription),
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler assinaturas de alertas: %w", err)
+	}
+	var loaded state
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return nil, fmt.Errorf("assinaturas de alertas inválidas: %w", err)
+	}
+	for _, sub := range loaded.Subscriptions {
+		s.subscriptions[sub.ID] = sub
+	}
+	s.deadLetters = loaded.DeadLetters
+	return s, nil
+}
+
+// Create grava uma nova assinatura, gerando ID, segredo e data de criação
+func (s *Store) Create(sub Subscription) (Subscription, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return Subscription{}, err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return Subscription{}, err
+	}
+	sub.ID = id
+	sub.Secret = secret
+	sub.CreatedAt = s.now().UTC()
+	sub.Triggered = false
+	sub.LastValue = nil
+	sub.LastChecked = time.Time{}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.maxSubscriptions > 0 && len(s.subscriptions) >= s.maxSubscriptions {
+		return Subscription{}, ErrLimitReached
+	}
+	s.subscriptions[sub.ID] = &sub
+	if err := s.save(); err != nil {
+		delete(s.subscriptions, sub.ID)
+		return Subscription{}, err
+	}
+	return sub, nil
+}
+
+// List retorna as assinaturas de owner no CEP, da mais antiga para a mais
+// recente; owner ou cep vazios não filtram
+func (s *Store) List(owner, cep string) []Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := make([]Subscription, 0, len(s.subscriptions))
+	for _, sub := range s.subscriptions {
+		if (owner == "" || sub.Owner == owner) && (cep == "" || sub.CEP == cep) {
+			subs = append(subs, *sub)
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		if !subs[i].CreatedAt.Equal(subs[j].CreatedAt) {
+			return subs[i].CreatedAt.Before(subs[j].CreatedAt)
+		}
+		return subs[i].ID < subs[j].ID
+	})
+	return subs
+}
+
+// Get retorna a assinatura id
+func (s *Store) Get(id string) (Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, found := s.subscriptions[id]
+	if !found {
+		return Subscription{}, ErrNotFound
+	}
+	return *sub, nil
+}
+
+// Delete remove a assinatura id de owner; assinaturas de outro dono são
+// tratadas como inexistentes
+func (s *Store) Delete(owner, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, found := s.subscriptions[id]
+	if !found || sub.Owner != owner {
+		return ErrNotFound
+	}
+	delete(s.subscriptions, id)
+	if err := s.save(); err != nil {
+		s.subscriptions[id] = sub
+		return err
+	}
+	return nil
+}
+
+// UpdateState registra a última temperatura verificada e se o limite está
+// cruzado; assinaturas removidas nesse meio-tempo são ignoradas. O arquivo
+// só é regravado quando o estado de disparo muda.
+func (s *Store) UpdateState(id string, value float64, triggered bool, checkedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, found := s.subscriptions[id]
+	if !found {
+		return nil
+	}
+	sub.LastValue = &value
+	sub.LastChecked = checkedAt.UTC()
+	if sub.Triggered == triggered {
+		return nil
+	}
+	sub.Triggered = triggered
+	return s.save()
+}
+
+// AddDeadLetter registra uma entrega que falhou em todas as tentativas
+func (s *Store) AddDeadLetter(letter DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deadLetters = append(s.deadLetters, letter)
+	if excess := len(s.deadLetters) - maxDeadLetters; excess > 0 {
+		s.deadLetters = append([]DeadLetter(nil), s.deadLetters[excess:]...)
+	}
+	return s.save()
+}
+
+// DeadLetters retorna as entregas com falha de owner, da mais antiga para a
+// mais recente; owner vazio não filtra
+func (s *Store) DeadLetters(owner string) []DeadLetter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	letters := make([]DeadLetter, 0, len(s.deadLetters))
+	for _, letter := range s.deadLetters {
+		if owner == "" || letter.Owner == owner {
+			letters = append(letters, letter)
+		}
+	}
+	return letters
+}
+
+// Stats retorna o número de assinaturas, de assinaturas disparadas e de entregas com falha
+func (s *Store) Stats() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	triggered := 0
+	for _, sub := range s.subscriptions {
+		if sub.Triggered {
+			triggered++
+		}
+	}
+	return map[string]interface{}{
+		"subscriptions": len(s.subscriptions),
+		"triggered":     triggered,
+		"dead_letters":  len(s.deadLetters),
+	}
+}
+
+// save grava o estado em path; deve ser chamado com mu travado
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	current := state{
+		Subscriptions: make([]*Subscription, 0, len(s.subscriptions)),
+		DeadLetters:   s.deadLetters,
+	}
+	for _, sub := range s.subscriptions {
+		current.Subscriptions = append(current.Subscriptions, sub)
+	}
+	data, err := json.Marshal(current)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar assinaturas de alertas: %w", err)
+	}
+	if err := atomicfile.WriteFile(s.path, data); err != nil {
+		return fmt.Errorf("erro ao gravar assinaturas de alertas: %w", err)
+	}
+	return nil
+}
+
+// OwnerID identifica o dono de assinaturas a partir da sua chave de API, sem
+// guardar a chave
+func OwnerID(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:8])
+}
+
+// randomHex gera n bytes aleatórios codificados em hexadecimal
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("erro ao gerar identificador: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,262 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lcidral/goExpertOtel/pkg/retry"
+	"github.com/lcidral/goExpertOtel/pkg/telemetry"
+)
+
+// Cabeçalhos das entregas. A assinatura é o HMAC-SHA256, com o segredo da
+// assinatura, de "<timestamp>.<corpo>", em hexadecimal e prefixado por "sha256=".
+const (
+	HeaderDelivery  = "X-Alert-Delivery"
+	HeaderTimestamp = "X-Alert-Timestamp"
+	HeaderSignature = "X-Alert-Signature"
+)
+
+var deliveriesCounter, _ = telemetry.Meter().Int64Counter("alerts.webhook.deliveries",
+	metric.WithDescription("Alert webhook deliveries, by outcome"),
+)
+
+// Sign calcula a assinatura de uma entrega feita em timestamp (Unix, em segundos)
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ErrForbiddenAddress indica um webhook em endereço interno (loopback,
+// link-local, rede privada etc.), recusado para evitar SSRF
+var ErrForbiddenAddress = errors.New("endereço do webhook não permitido")
+
+// sharedAddressSpace faixa 100.64.0.0/10 (CGNAT), usada também em redes internas de nuvem
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// CheckAddress recusa endereços que não são públicos: loopback, link-local
+// (inclusive o serviço de metadados 169.254.169.254), redes privadas,
+// CGNAT, multicast e o endereço não especificado
+func CheckAddress(addr netip.Addr) error {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() || sharedAddressSpace.Contains(addr) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+	}
+	return nil
+}
+
+// CheckHost recusa hosts que são, literalmente, um endereço não público ou
+// localhost; nomes são verificados de novo a cada conexão, após a resolução
+func CheckHost(host string) error {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return CheckAddress(addr)
+	}
+	return nil
+}
+
+// controlDial verifica o endereço já resolvido de cada conexão, o que também
+// cobre DNS rebinding (nome público na validação, interno na entrega)
+func controlDial(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	return CheckAddress(addrPort.Addr())
+}
+
+// RedactURL reduz a URL de um webhook ao esquema e ao host, para logs e para
+// a lista de entregas com falha: o caminho e a consulta costumam carregar o
+// token do webhook (Slack, Discord etc.)
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "[webhook inválido]"
+	}
+	if u.Path == "" && u.RawQuery == "" && u.User == nil {
+		return u.Scheme + "://" + u.Host
+	}
+	return u.Scheme + "://" + u.Host + "/[redacted]"
+}
+
+// DeliveryError falha de uma entrega após todas as tentativas
+type DeliveryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *DeliveryError) Error() string {
+	return fmt.Sprintf("entrega falhou após %d tentativas: %v", e.Attempts, e.Err)
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// Notifier entrega os alertas aos webhooks. Falhas transitórias (rede e
+// status como 429 e 5xx) são repetidas conforme a política; demais respostas
+// de erro encerram a entrega. Cada tentativa propaga o contexto de trace
+// (traceparent) para o receptor.
+type Notifier struct {
+	httpClient *http.Client
+	policy     retry.Policy
+	now        func() time.Time
+}
+
+// NewNotifier cria o notificador; timeout limita cada tentativa. Sem
+// allowPrivate, conexões a endereços internos são recusadas (veja
+// CheckAddress). Redirecionamentos nunca são seguidos, e proxies do ambiente
+// não são usados, para que o endereço verificado seja o do webhook.
+func NewNotifier(timeout time.Duration, policy retry.Policy, allowPrivate bool) *Notifier {
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = controlDial
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Notifier{
+		httpClient: &http.Client{
+			Timeout:   timeout,
+			Transport: otelhttp.NewTransport(transport),
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		policy: policy,
+		now:    time.Now,
+	}
+}
+
+// Deliver envia o evento para webhookURL, assinado com secret
+func (n *Notifier) Deliver(ctx context.Context, webhookURL, secret string, event Event) error {
+	ctx, span := telemetry.StartSpan(ctx, "alerts.webhook.deliver",
+		attribute.String("alert.subscription_id", event.SubscriptionID),
+		attribute.String("alert.delivery_id", event.ID),
+		attribute.String("cep.value", event.CEP),
+	)
+	defer span.End()
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to encode event")
+		return &DeliveryError{Attempts: 0, Err: fmt.Errorf("erro ao serializar alerta: %w", err)}
+	}
+
+	maxAttempts := n.policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		delay, retryable, err := n.attempt(ctx, webhookURL, secret, event.ID, body)
+
+		attrs := []attribute.KeyValue{attribute.Int("http.attempt", attempt)}
+		if err != nil {
+			attrs = append(attrs, attribute.String("error.message", err.Error()))
+		}
+		span.AddEvent("alerts.webhook.attempt", trace.WithAttributes(attrs...))
+
+		if err == nil {
+			span.SetAttributes(attribute.Int("alert.attempts", attempt))
+			span.SetStatus(codes.Ok, "Alert delivered")
+			deliveriesCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("alert.outcome", "delivered")))
+			return nil
+		}
+
+		if !retryable || attempt >= maxAttempts || ctx.Err() != nil {
+			span.SetAttributes(attribute.Int("alert.attempts", attempt))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Alert delivery failed")
+			deliveriesCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("alert.outcome", "failed")))
+			return &DeliveryError{Attempts: attempt, Err: err}
+		}
+
+		if delay <= 0 {
+			delay = n.policy.Backoff(attempt)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			span.SetAttributes(attribute.Int("alert.attempts", attempt))
+			span.SetStatus(codes.Error, "Alert delivery canceled")
+			deliveriesCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("alert.outcome", "canceled")))
+			return &DeliveryError{Attempts: attempt, Err: ctx.Err()}
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt faz uma tentativa de entrega, retornando se uma falha pode ser
+// repetida e a espera pedida pelo receptor (Retry-After), se houver
+func (n *Notifier) attempt(ctx context.Context, webhookURL, secret, deliveryID string, body []byte) (time.Duration, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+	timestamp := n.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDelivery, deliveryID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+
+	resp, err := n.httpClient.Do(req)
+	// The client's errors quote the full URL, token included
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = RedactURL(urlErr.URL)
+	}
+	if errors.Is(err, ErrForbiddenAddress) {
+		return 0, false, err
+	}
+	if err != nil {
+		return 0, true, fmt.Errorf("erro ao executar requisição: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return 0, false, nil
+	}
+
+	err = fmt.Errorf("webhook respondeu com status %d", resp.StatusCode)
+	if !retry.IsRetryableStatus(resp.StatusCode) {
+		return 0, false, err
+	}
+	delay, _ := retry.RetryAfter(resp, n.now())
+	if n.policy.MaxDelay > 0 && delay > n.policy.MaxDelay {
+		delay = n.policy.MaxDelay
+	}
+	return delay, true, err
+}
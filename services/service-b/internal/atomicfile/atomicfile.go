@@ -0,0 +1,48 @@
+// Package atomicfile grava arquivos de forma atômica: o conteúdo vai para um
+// arquivo temporário no mesmo diretório, é sincronizado com o disco e só
+// então substitui o destino com rename. Uma gravação interrompida nunca
+// deixa o arquivo de destino pela metade.
+package atomicfile
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile grava data em path de forma atômica
+func WriteFile(path string, data []byte) error {
+	return Write(path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// Write grava em path, de forma atômica, o conteúdo produzido por write; se
+// write falhar o destino não é alterado e o erro é retornado sem modificação
+func Write(path string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	buffered := bufio.NewWriter(tmp)
+	if err := write(buffered); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,51 @@
+package atomicfile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dados.json")
+
+	for _, content := range []string{"primeira versão", "segunda"} {
+		if err := WriteFile(path, []byte(content)); err != nil {
+			t.Fatalf("WriteFile() erro inesperado = %v", err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != content {
+			t.Errorf("conteúdo = %q, esperava %q", data, content)
+		}
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("diretório com %d arquivos, esperava somente o destino", len(entries))
+	}
+}
+
+func TestWrite_FailureKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dados.json")
+	if err := WriteFile(path, []byte("original")); err != nil {
+		t.Fatalf("WriteFile() erro inesperado = %v", err)
+	}
+
+	errWrite := errors.New("falha na serialização")
+	err := Write(path, func(w io.Writer) error {
+		io.WriteString(w, "parcial")
+		return errWrite
+	})
+	if !errors.Is(err, errWrite) {
+		t.Fatalf("Write() erro = %v, esperava %v", err, errWrite)
+	}
+
+	if data, _ := os.ReadFile(path); string(data) != "original" {
+		t.Errorf("conteúdo = %q, esperava o original", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("diretório com %d arquivos, esperava o temporário removido", len(entries))
+	}
+}
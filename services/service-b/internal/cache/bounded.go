@@ -0,0 +1,310 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/lcidral/goExpertOtel/pkg/telemetry"
+)
+
+// Tipos de item usados nos limites por tipo
+const (
+	TypeLocation   = "location"
+	TypeWeather    = "weather"
+	TypeForecast   = "forecast"
+	TypeHistory    = "history"
+	TypeAirQuality = "airquality"
+	TypeNegative   = "negative" // CEPs e localizações inexistentes
+)
+
+// itemTypes tipos de item, na ordem usada nas estatísticas
+var itemTypes = []string{TypeLocation, TypeWeather, TypeForecast, TypeHistory, TypeAirQuality, TypeNegative}
+
+// EvictionPolicy define qual item é removido quando um tipo atinge o limite
+type EvictionPolicy string
+
+const (
+	// EvictLRU remove o item acessado há mais tempo
+	EvictLRU EvictionPolicy = "lru"
+	// EvictLFU remove o item menos acessado; empates removem o acessado há mais tempo
+	EvictLFU EvictionPolicy = "lfu"
+)
+
+// Limit limites de um tipo de item; zero desativa o limite correspondente
+type Limit struct {
+	MaxItems int
+	MaxBytes int64 // tamanho aproximado, pela serialização JSON do valor
+}
+
+// itemType identifica o tipo do item pela chave
+func itemType(key string) string {
+	switch {
+	case strings.HasPrefix(key, "notfound:"):
+		return TypeNegative
+	case strings.HasPrefix(key, "location:"):
+		return TypeLocation
+	case strings.HasPrefix(key, "weather:"):
+		return TypeWeather
+	case strings.HasPrefix(key, "forecast:"):
+		return TypeForecast
+	case strings.HasPrefix(key, "history:"):
+		return TypeHistory
+	case strings.HasPrefix(key, "airquality:"):
+		return TypeAirQuality
+	default:
+		return ""
+	}
+}
+
+// approximateSize estima a memória ocupada pelo item
+func approximateSize(key string, value interface{}) int64 {
+	if entry, ok := value.(*Entry); ok {
+		value = entry.Value
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return int64(len(key))
+	}
+	return int64(len(key) + len(data))
+}
+
+var evictionsCounter, _ = telemetry.Meter().Int64Counter("cache.evictions",
+	metric.WithDescription("Items evicted from the in-memory cache by its size limits"),
+)
+
+// trackedItem item acompanhado pelo limitador
+type trackedItem struct {
+	key    string
+	size   int64
+	hits   int64
+	bucket *list.Element // faixa de frequência com os itens de mesmo hits
+	entry  *list.Element // posição do item dentro da faixa
+}
+
+// freqBucket itens com o mesmo número de acessos, usados pelo LFU
+type freqBucket struct {
+	hits  int64
+	items *list.List // frente: acessado mais recentemente
+}
+
+// typeLimiter acompanha os itens de um tipo, em ordem de acesso e em faixas
+// de frequência, o que mantém a escolha da vítima em O(1) nas duas políticas
+type typeLimiter struct {
+	limit     Limit
+	order     *list.List // frente: acessado mais recentemente
+	buckets   *list.List // faixas de frequência, da menor para a maior
+	items     map[string]*list.Element
+	bytes     int64
+	evictions int64
+}
+
+func (l *typeLimiter) overLimit() bool {
+	return (l.limit.MaxItems > 0 && len(l.items) > l.limit.MaxItems) ||
+		(l.limit.MaxBytes > 0 && l.bytes > l.limit.MaxBytes)
+}
+
+// victim escolhe o próximo item a ser removido, ignorando exclude (o item
+// que acabou de ser armazenado)
+func (l *typeLimiter) victim(policy EvictionPolicy, exclude string) *list.Element {
+	// No LRU fica o acessado há mais tempo; no LFU, o acessado há mais tempo
+	// da faixa com menos acessos. Como só exclude é ignorado, cada laço
+	// descarta no máximo um item.
+	if policy != EvictLFU {
+		for e := l.order.Back(); e != nil; e = e.Prev() {
+			if e.Value.(*trackedItem).key != exclude {
+				return e
+			}
+		}
+		return nil
+	}
+
+	for b := l.buckets.Front(); b != nil; b = b.Next() {
+		for e := b.Value.(*freqBucket).items.Back(); e != nil; e = e.Prev() {
+			if key := e.Value.(*trackedItem).key; key != exclude {
+				return l.items[key]
+			}
+		}
+	}
+	return nil
+}
+
+// insert passa a acompanhar um item novo, ainda sem acessos
+func (l *typeLimiter) insert(item *trackedItem) {
+	first := l.buckets.Front()
+	if first == nil || first.Value.(*freqBucket).hits != 0 {
+		first = l.buckets.PushFront(&freqBucket{items: list.New()})
+	}
+	item.bucket = first
+	item.entry = first.Value.(*freqBucket).items.PushFront(item)
+	l.items[item.key] = l.order.PushFront(item)
+	l.bytes += item.size
+}
+
+// access registra um acesso ao item, movendo-o para a faixa seguinte
+func (l *typeLimiter) access(e *list.Element) {
+	item := e.Value.(*trackedItem)
+	l.order.MoveToFront(e)
+
+	current := item.bucket
+	next := current.Next()
+	if next == nil || next.Value.(*freqBucket).hits != item.hits+1 {
+		next = l.buckets.InsertAfter(&freqBucket{hits: item.hits + 1, items: list.New()}, current)
+	}
+	l.unlink(item)
+	item.hits++
+	item.bucket = next
+	item.entry = next.Value.(*freqBucket).items.PushFront(item)
+}
+
+// unlink retira o item da sua faixa, descartando a faixa vazia
+func (l *typeLimiter) unlink(item *trackedItem) {
+	bucket := item.bucket.Value.(*freqBucket)
+	bucket.items.Remove(item.entry)
+	if bucket.items.Len() == 0 {
+		l.buckets.Remove(item.bucket)
+	}
+}
+
+func (l *typeLimiter) remove(e *list.Element) {
+	item := e.Value.(*trackedItem)
+	l.unlink(item)
+	l.order.Remove(e)
+	delete(l.items, item.key)
+	l.bytes -= item.size
+}
+
+// limiter mantém os itens de cada tipo dentro dos limites configurados
+type limiter struct {
+	policy EvictionPolicy
+
+	mu    sync.Mutex
+	types map[string]*typeLimiter
+}
+
+func newLimiter(policy EvictionPolicy, limits map[string]Limit) *limiter {
+	if policy == "" {
+		policy = EvictLRU
+	}
+	l := &limiter{policy: policy, types: make(map[string]*typeLimiter)}
+	for _, itemType := range itemTypes {
+		l.types[itemType] = &typeLimiter{
+			limit:   limits[itemType],
+			order:   list.New(),
+			buckets: list.New(),
+			items:   make(map[string]*list.Element),
+		}
+	}
+	return l
+}
+
+// add registra um item armazenado e retorna as chaves que devem ser
+// removidas do cache para respeitar os limites do tipo
+func (l *limiter) add(key string, value interface{}) []string {
+	tl, ok := l.types[itemType(key)]
+	if !ok {
+		return nil
+	}
+	var size int64
+	if tl.limit.MaxBytes > 0 {
+		size = approximateSize(key, value)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, found := tl.items[key]; found {
+		item := e.Value.(*trackedItem)
+		tl.bytes += size - item.size
+		item.size = size
+		tl.access(e)
+	} else {
+		tl.insert(&trackedItem{key: key, size: size})
+	}
+
+	var evicted []string
+	for tl.overLimit() {
+		victim := tl.victim(l.policy, key)
+		if victim == nil {
+			break
+		}
+		evicted = append(evicted, victim.Value.(*trackedItem).key)
+		tl.remove(victim)
+		tl.evictions++
+	}
+	return evicted
+}
+
+// touch registra um acesso ao item
+func (l *limiter) touch(key string) {
+	tl, ok := l.types[itemType(key)]
+	if !ok {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, found := tl.items[key]; found {
+		tl.access(e)
+	}
+}
+
+// remove esquece um item removido ou expirado do cache
+func (l *limiter) remove(key string) {
+	tl, ok := l.types[itemType(key)]
+	if !ok {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, found := tl.items[key]; found {
+		tl.remove(e)
+	}
+}
+
+// reset esquece todos os itens, mantendo os contadores de remoções
+func (l *limiter) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, tl := range l.types {
+		tl.order.Init()
+		tl.buckets.Init()
+		tl.items = make(map[string]*list.Element)
+		tl.bytes = 0
+	}
+}
+
+// recordEvictions contabiliza as remoções na métrica cache.evictions
+func (l *limiter) recordEvictions(key string, count int) {
+	evictionsCounter.Add(context.Background(), int64(count), metric.WithAttributes(
+		attribute.String("cache.type", itemType(key)),
+		attribute.String("cache.eviction_policy", string(l.policy)),
+	))
+}
+
+// stats adiciona às estatísticas do cache o tamanho e as remoções de cada tipo
+func (l *limiter) stats(stats map[string]interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats["eviction_policy"] = string(l.policy)
+	for _, itemType := range itemTypes {
+		tl := l.types[itemType]
+		stats[itemType+"_evictions"] = tl.evictions
+		if tl.limit.MaxBytes > 0 {
+			stats[itemType+"_bytes"] = tl.bytes
+		}
+		if tl.limit.MaxItems > 0 {
+			stats[itemType+"_max_items"] = tl.limit.MaxItems
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+)
+
+func TestMemoryCache_EvictionPolicies(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      EvictionPolicy
+		wantEvicted string
+	}{
+		// 01000001 é o acessado há mais tempo; 01000002 o menos acessado
+		{"LRU remove o acessado há mais tempo", EvictLRU, "01000001"},
+		{"LFU remove o menos acessado", EvictLFU, "01000002"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := NewMemoryCache(time.Hour, time.Hour, Options{
+				Limits:   map[string]Limit{TypeLocation: {MaxItems: 3}},
+				Eviction: tt.policy,
+			})
+
+			for i := 1; i <= 3; i++ {
+				mc.SetLocation(fmt.Sprintf("0100000%d", i), &model.ViaCEPResponse{}, time.Hour)
+			}
+			// 01000001: 3 acessos, o acessado há mais tempo; 01000002: 1 acesso
+			for _, cep := range []string{"01000001", "01000001", "01000001", "01000002", "01000003", "01000003"} {
+				mc.GetLocation(cep)
+			}
+
+			mc.SetLocation("01000004", &model.ViaCEPResponse{}, time.Hour)
+
+			if _, found := mc.GetLocation(tt.wantEvicted); found {
+				t.Errorf("GetLocation(%s) deveria ter sido removido", tt.wantEvicted)
+			}
+			if _, found := mc.GetLocation("01000004"); !found {
+				t.Error("GetLocation() deveria encontrar o item recém-armazenado")
+			}
+
+			stats := mc.Stats()
+			if stats["location_items"] != 3 || stats["location_evictions"] != int64(1) {
+				t.Errorf("Stats() = %d itens, %v remoções; esperava 3 e 1", stats["location_items"], stats["location_evictions"])
+			}
+		})
+	}
+}
+
+func TestLimiter_LFUTiesAndRemovals(t *testing.T) {
+	l := newLimiter(EvictLFU, map[string]Limit{TypeLocation: {MaxItems: 2}})
+
+	l.add("location:a", nil)
+	l.add("location:b", nil)
+	l.touch("location:a")
+	l.touch("location:b")
+	l.remove("location:b")
+	l.add("location:c", nil)
+	l.touch("location:c")
+
+	// a e c empatam com um acesso: sai a, acessada há mais tempo
+	if evicted := l.add("location:d", nil); len(evicted) != 1 || evicted[0] != "location:a" {
+		t.Errorf("add() removeu %v, esperava [location:a]", evicted)
+	}
+	// O item recém-armazenado nunca é a vítima, mesmo sendo o menos acessado
+	if evicted := l.add("location:e", nil); len(evicted) != 1 || evicted[0] != "location:d" {
+		t.Errorf("add() removeu %v, esperava [location:d]", evicted)
+	}
+
+	tl := l.types[TypeLocation]
+	if tl.buckets.Len() != 2 || len(tl.items) != 2 {
+		t.Errorf("faixas = %d, itens = %d; esperava 2 e 2", tl.buckets.Len(), len(tl.items))
+	}
+}
+
+func TestMemoryCache_NegativeEntriesBounded(t *testing.T) {
+	mc := NewMemoryCache(time.Hour, time.Hour, Options{
+		NegativeTTL: time.Hour,
+		Limits:      map[string]Limit{TypeNegative: {MaxItems: 100}},
+	})
+
+	// Enumeração de CEPs inexistentes não cresce além do limite
+	for i := 0; i < 1000; i++ {
+		mc.SetLocationNotFound(fmt.Sprintf("%08d", i))
+	}
+
+	stats := mc.Stats()
+	if stats["negative_location_items"] != 100 || stats["negative_evictions"] != int64(900) {
+		t.Errorf("Stats() = %v itens, %v remoções; esperava 100 e 900", stats["negative_location_items"], stats["negative_evictions"])
+	}
+	if !mc.IsLocationNotFound("00000999") {
+		t.Error("IsLocationNotFound() deveria encontrar o registro mais recente")
+	}
+}
+
+func TestMemoryCache_MaxBytes(t *testing.T) {
+	mc := NewMemoryCache(time.Hour, time.Hour, Options{
+		Limits: map[string]Limit{TypeWeather: {MaxBytes: 4096}},
+	})
+
+	weather := &model.WeatherAPIResponse{}
+	size := approximateSize("weather:Cidade 00, XX", &Entry{Value: weather})
+
+	for i := 0; i < 100; i++ {
+		mc.SetWeather(fmt.Sprintf("Cidade %02d, XX", i), weather, time.Hour)
+	}
+
+	stats := mc.Stats()
+	if bytes := stats["weather_bytes"].(int64); bytes > 4096 {
+		t.Errorf("Stats() weather_bytes = %d, esperava no máximo 4096", bytes)
+	}
+	if want := int(4096 / size); stats["weather_items"] != want {
+		t.Errorf("Stats() weather_items = %v, esperava %d", stats["weather_items"], want)
+	}
+
+	// Itens removidos explicitamente liberam espaço
+	mc.InvalidateWeather("Cidade 99, XX")
+	if bytes := mc.Stats()["weather_bytes"].(int64); bytes > 4096-size {
+		t.Errorf("Stats() weather_bytes = %d após invalidação, esperava no máximo %d", bytes, 4096-size)
+	}
+}
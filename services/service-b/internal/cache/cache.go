@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+)
+
+// Cache operações de cache usadas pelo Service B, independentes do backend
+// (memória local, Redis ou a combinação dos dois)
+type Cache interface {
+	GetLocation(cep string) (*model.ViaCEPResponse, bool)
+	GetLocationEntry(cep string) (*model.ViaCEPResponse, Freshness, time.Duration, bool)
+	SetLocation(cep string, location *model.ViaCEPResponse, duration time.Duration)
+	InvalidateLocation(cep string)
+
+	GetWeather(location string) (*model.WeatherAPIResponse, bool)
+	GetWeatherEntry(location string) (*model.WeatherAPIResponse, Freshness, time.Duration, bool)
+	SetWeather(location string, weather *model.WeatherAPIResponse, duration time.Duration)
+	InvalidateWeather(location string)
+
+	GetForecast(location string) (*model.WeatherAPIForecastResponse, bool)
+	GetForecastEntry(location string) (*model.WeatherAPIForecastResponse, Freshness, time.Duration, bool)
+	SetForecast(location string, forecast *model.WeatherAPIForecastResponse, duration time.Duration)
+
+	// Histórico de uma data (2006-01-02); imutável, sem stale-while-revalidate
+	GetHistory(location, date string) (*model.WeatherAPIHistoryResponse, bool)
+	SetHistory(location, date string, history *model.WeatherAPIHistoryResponse, duration time.Duration)
+
+	// Qualidade do ar, separada do clima para ter TTL próprio
+	GetAirQuality(location string) (*model.AirQuality, bool)
+	SetAirQuality(location string, airQuality *model.AirQuality, duration time.Duration)
+
+	SetLocationNotFound(cep string)
+	IsLocationNotFound(cep string) bool
+	SetWeatherNotFound(location string)
+	IsWeatherNotFound(location string) bool
+
+	Clear()
+	Stats() map[string]interface{}
+
+	// Inspeção e remoção por chave, usadas pela API de administração
+	Keys(prefix string, limit int) []KeyInfo
+	Inspect(key string) (*KeyInfo, bool)
+	Delete(key string) bool
+	DeletePrefix(prefix string) int
+}
+
+// KeyCounter é implementado pelos caches em que contar as chaves exige
+// percorrer um armazenamento externo; a contagem é feita somente sob demanda
+type KeyCounter interface {
+	CountKeys(ctx context.Context) (map[string]interface{}, error)
+}
+
+// KeyInfo descreve um item do cache para inspeção
+type KeyInfo struct {
+	Key       string     `json:"key"`
+	Type      string     `json:"type"`
+	Freshness string     `json:"freshness,omitempty"`
+	StoredAt  *time.Time `json:"stored_at,omitempty"`
+	// FreshUntil fim do TTL; após ele o item só é servido como stale
+	FreshUntil *time.Time `json:"fresh_until,omitempty"`
+	// ExpiresAt momento em que o item é removido do cache
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// TTLSeconds tempo restante até o fim do TTL (ou até a remoção, para entradas negativas)
+	TTLSeconds float64     `json:"ttl_remaining_seconds"`
+	Value      interface{} `json:"value,omitempty"`
+}
+
+// setExpiration registra quando o item será removido do cache
+func (k *KeyInfo) setExpiration(expiresAt, now time.Time) {
+	k.ExpiresAt = &expiresAt
+	if k.FreshUntil == nil {
+		k.TTLSeconds = positiveSeconds(expiresAt.Sub(now))
+	}
+}
+
+// setEntry registra os metadados de validade do item
+func (k *KeyInfo) setEntry(entry *Entry, freshness Freshness, now time.Time) {
+	storedAt, freshUntil := entry.StoredAt, entry.FreshUntil
+	k.StoredAt = &storedAt
+	k.FreshUntil = &freshUntil
+	k.Freshness = freshness.String()
+	k.TTLSeconds = positiveSeconds(freshUntil.Sub(now))
+}
+
+func positiveSeconds(d time.Duration) float64 {
+	if d < 0 {
+		return 0
+	}
+	return d.Seconds()
+}
+
+// newValue cria o destino da desserialização de um item do tipo informado
+func newValue(itemType string) interface{} {
+	switch itemType {
+	case TypeLocation:
+		return &model.ViaCEPResponse{}
+	case TypeWeather:
+		return &model.WeatherAPIResponse{}
+	case TypeForecast:
+		return &model.WeatherAPIForecastResponse{}
+	case TypeHistory:
+		return &model.WeatherAPIHistoryResponse{}
+	case TypeAirQuality:
+		return &model.AirQuality{}
+	default:
+		return nil
+	}
+}
+
+var (
+	_ Cache = (*MemoryCache)(nil)
+	_ Cache = (*RedisCache)(nil)
+	_ Cache = (*TieredCache)(nil)
+)
+
+// Cache Keys patterns
+const (
+	locationCacheKey   = "location:%s"   // location:12345678
+	weatherCacheKey    = "weather:%s"    // weather:São Paulo,SP
+	forecastCacheKey   = "forecast:%s"   // forecast:São Paulo,SP
+	historyCacheKey    = "history:%s:%s" // history:São Paulo,SP:2024-03-15
+	airQualityCacheKey = "airquality:%s" // airquality:São Paulo,SP
+
+	negativeLocationCacheKey = "notfound:location:%s" // notfound:location:12345678
+	negativeWeatherCacheKey  = "notfound:weather:%s"  // notfound:weather:Cidade,UF
+)
+
+// Options configura o comportamento do cache além do TTL de cada item
+type Options struct {
+	// StaleWhileRevalidate janela após o TTL em que o item ainda é servido
+	// enquanto é atualizado em background
+	StaleWhileRevalidate time.Duration
+	// MaxStale idade máxima após o TTL em que o item pode ser servido quando
+	// a API externa falha
+	MaxStale time.Duration
+	// NegativeTTL tempo em que um CEP ou localização inexistente é lembrado,
+	// evitando novas consultas às APIs externas
+	NegativeTTL time.Duration
+	// Limits limites de itens e bytes por tipo (TypeLocation, TypeWeather,
+	// TypeForecast, TypeHistory, TypeAirQuality e TypeNegative); usados
+	// somente pelo MemoryCache
+	Limits map[string]Limit
+	// Eviction política de remoção quando um tipo atinge o limite (padrão LRU)
+	Eviction EvictionPolicy
+}
+
+// Freshness indica o estado de um item encontrado no cache
+type Freshness int
+
+const (
+	// Fresh item dentro do TTL
+	Fresh Freshness = iota
+	// Stale item expirado, dentro da janela de stale-while-revalidate
+	Stale
+	// Expired item expirado, utilizável somente se a API externa falhar
+	Expired
+)
+
+func (f Freshness) String() string {
+	switch f {
+	case Fresh:
+		return "fresh"
+	case Stale:
+		return "stale"
+	default:
+		return "expired"
+	}
+}
+
+// Entry item armazenado no cache com os metadados de validade
+type Entry struct {
+	Value      interface{}
+	StoredAt   time.Time
+	FreshUntil time.Time
+}
+
+// retention tempo que um item é mantido além do TTL para as janelas de
+// stale-while-revalidate e stale-on-error
+func (o Options) retention() time.Duration {
+	if o.MaxStale > o.StaleWhileRevalidate {
+		return o.MaxStale
+	}
+	return o.StaleWhileRevalidate
+}
+
+// freshness classifica o item de acordo com as janelas de validade
+// configuradas; usable é falso para itens além da idade máxima
+func (o Options) freshness(entry *Entry, now time.Time) (Freshness, bool) {
+	switch {
+	case now.Before(entry.FreshUntil):
+		return Fresh, true
+	case now.Before(entry.FreshUntil.Add(o.StaleWhileRevalidate)):
+		return Stale, true
+	default:
+		return Expired, !now.After(entry.FreshUntil.Add(o.MaxStale))
+	}
+}
@@ -2,105 +2,337 @@ package cache
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/patrickmn/go-cache"
 
-	"github.com/lcidral/goExpertOtel/pkg/models"
 	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
 )
 
 // MemoryCache cache em memória para otimizar chamadas às APIs
 type MemoryCache struct {
-	cache *cache.Cache
+	cache   *cache.Cache
+	options Options
+	now     func() time.Time
+	limiter *limiter
+
+	negativeLocationHits atomic.Int64
+	negativeWeatherHits  atomic.Int64
 }
 
 // NewMemoryCache cria uma nova instância do cache
-func NewMemoryCache(defaultExpiration, cleanupInterval time.Duration) *MemoryCache {
-	return &MemoryCache{
-		cache: cache.New(defaultExpiration, cleanupInterval),
+func NewMemoryCache(defaultExpiration, cleanupInterval time.Duration, options Options) *MemoryCache {
+	mc := &MemoryCache{
+		cache:   cache.New(defaultExpiration, cleanupInterval),
+		options: options,
+		now:     time.Now,
+		limiter: newLimiter(options.Eviction, options.Limits),
 	}
+	// Itens removidos ou expirados deixam de contar para os limites
+	mc.cache.OnEvicted(func(key string, _ interface{}) {
+		mc.limiter.remove(key)
+	})
+	return mc
 }
 
-// Cache Keys patterns
-const (
-	locationCacheKey = "location:%s"    // location:12345678
-	weatherCacheKey  = "weather:%s"     // weather:São Paulo,SP
-	tempCacheKey     = "temp:%s"        // temp:12345678
-)
+// store armazena o item e remove os itens escolhidos pela política de
+// remoção caso o tipo ultrapasse seus limites
+func (mc *MemoryCache) store(key string, value interface{}, expiration time.Duration) {
+	mc.cache.Set(key, value, expiration)
+	if evicted := mc.limiter.add(key, value); len(evicted) > 0 {
+		for _, victim := range evicted {
+			mc.cache.Delete(victim)
+		}
+		mc.limiter.recordEvictions(key, len(evicted))
+	}
+}
+
+// lookup busca o item, registrando o acesso para a política de remoção
+func (mc *MemoryCache) lookup(key string) (interface{}, bool) {
+	item, found := mc.cache.Get(key)
+	if found {
+		mc.limiter.touch(key)
+	}
+	return item, found
+}
+
+// set armazena o valor mantendo-o no cache além do TTL pelo tempo
+// necessário para as janelas de stale-while-revalidate e stale-on-error
+func (mc *MemoryCache) set(key string, value interface{}, duration time.Duration) {
+	now := mc.now()
+	mc.store(key, &Entry{
+		Value:      value,
+		StoredAt:   now,
+		FreshUntil: now.Add(duration),
+	}, duration+mc.options.retention())
+}
+
+// setEntry armazena um item com metadados de validade já definidos, como os
+// copiados de outro nível de cache; itens além da idade máxima são ignorados
+func (mc *MemoryCache) setEntry(key string, entry *Entry) {
+	expiration := entry.FreshUntil.Add(mc.options.retention()).Sub(mc.now())
+	if expiration <= 0 {
+		return
+	}
+	mc.store(key, entry, expiration)
+}
+
+// get busca o item e sua validade; itens além da idade máxima são ignorados
+func (mc *MemoryCache) get(key string) (*Entry, Freshness, bool) {
+	item, found := mc.lookup(key)
+	if !found {
+		return nil, Expired, false
+	}
+	entry, ok := item.(*Entry)
+	if !ok {
+		return nil, Expired, false
+	}
+
+	freshness, usable := mc.options.freshness(entry, mc.now())
+	if !usable {
+		return nil, Expired, false
+	}
+	return entry, freshness, true
+}
 
 // GetLocation busca localização no cache
 func (mc *MemoryCache) GetLocation(cep string) (*model.ViaCEPResponse, bool) {
-	key := fmt.Sprintf(locationCacheKey, cep)
-	if item, found := mc.cache.Get(key); found {
-		if location, ok := item.(*model.ViaCEPResponse); ok {
-			return location, true
-		}
+	location, freshness, _, found := mc.GetLocationEntry(cep)
+	if !found || freshness != Fresh {
+		return nil, false
+	}
+	return location, true
+}
+
+// GetLocationEntry busca localização no cache, incluindo itens expirados ainda
+// dentro da idade máxima, junto com a validade e a idade do item
+func (mc *MemoryCache) GetLocationEntry(cep string) (*model.ViaCEPResponse, Freshness, time.Duration, bool) {
+	entry, freshness, found := mc.get(fmt.Sprintf(locationCacheKey, cep))
+	if !found {
+		return nil, Expired, 0, false
+	}
+	location, ok := entry.Value.(*model.ViaCEPResponse)
+	if !ok {
+		return nil, Expired, 0, false
 	}
-	return nil, false
+	return location, freshness, mc.now().Sub(entry.StoredAt), true
 }
 
 // SetLocation armazena localização no cache
 func (mc *MemoryCache) SetLocation(cep string, location *model.ViaCEPResponse, duration time.Duration) {
 	key := fmt.Sprintf(locationCacheKey, cep)
-	mc.cache.Set(key, location, duration)
+	mc.set(key, location, duration)
 }
 
 // GetWeather busca dados meteorológicos no cache
 func (mc *MemoryCache) GetWeather(location string) (*model.WeatherAPIResponse, bool) {
-	key := fmt.Sprintf(weatherCacheKey, location)
-	if item, found := mc.cache.Get(key); found {
-		if weather, ok := item.(*model.WeatherAPIResponse); ok {
-			return weather, true
-		}
+	weather, freshness, _, found := mc.GetWeatherEntry(location)
+	if !found || freshness != Fresh {
+		return nil, false
+	}
+	return weather, true
+}
+
+// GetWeatherEntry busca dados meteorológicos no cache, incluindo itens expirados ainda
+// dentro da idade máxima, junto com a validade e a idade do item
+func (mc *MemoryCache) GetWeatherEntry(location string) (*model.WeatherAPIResponse, Freshness, time.Duration, bool) {
+	entry, freshness, found := mc.get(fmt.Sprintf(weatherCacheKey, location))
+	if !found {
+		return nil, Expired, 0, false
 	}
-	return nil, false
+	weather, ok := entry.Value.(*model.WeatherAPIResponse)
+	if !ok {
+		return nil, Expired, 0, false
+	}
+	return weather, freshness, mc.now().Sub(entry.StoredAt), true
 }
 
 // SetWeather armazena dados meteorológicos no cache
 func (mc *MemoryCache) SetWeather(location string, weather *model.WeatherAPIResponse, duration time.Duration) {
 	key := fmt.Sprintf(weatherCacheKey, location)
-	mc.cache.Set(key, weather, duration)
+	mc.set(key, weather, duration)
 }
 
-// GetTemperature busca temperatura completa no cache
-func (mc *MemoryCache) GetTemperature(cep string) (*models.TemperatureResponse, bool) {
-	key := fmt.Sprintf(tempCacheKey, cep)
-	if item, found := mc.cache.Get(key); found {
-		if temp, ok := item.(*models.TemperatureResponse); ok {
-			return temp, true
-		}
+// GetForecast busca a previsão do tempo no cache
+func (mc *MemoryCache) GetForecast(location string) (*model.WeatherAPIForecastResponse, bool) {
+	forecast, freshness, _, found := mc.GetForecastEntry(location)
+	if !found || freshness != Fresh {
+		return nil, false
+	}
+	return forecast, true
+}
+
+// GetForecastEntry busca a previsão do tempo no cache, incluindo itens expirados ainda
+// dentro da idade máxima, junto com a validade e a idade do item
+func (mc *MemoryCache) GetForecastEntry(location string) (*model.WeatherAPIForecastResponse, Freshness, time.Duration, bool) {
+	entry, freshness, found := mc.get(fmt.Sprintf(forecastCacheKey, location))
+	if !found {
+		return nil, Expired, 0, false
+	}
+	forecast, ok := entry.Value.(*model.WeatherAPIForecastResponse)
+	if !ok {
+		return nil, Expired, 0, false
+	}
+	return forecast, freshness, mc.now().Sub(entry.StoredAt), true
+}
+
+// SetForecast armazena a previsão do tempo no cache
+func (mc *MemoryCache) SetForecast(location string, forecast *model.WeatherAPIForecastResponse, duration time.Duration) {
+	mc.set(fmt.Sprintf(forecastCacheKey, location), forecast, duration)
+}
+
+// GetHistory busca o histórico de uma data no cache
+func (mc *MemoryCache) GetHistory(location, date string) (*model.WeatherAPIHistoryResponse, bool) {
+	entry, freshness, found := mc.get(fmt.Sprintf(historyCacheKey, location, date))
+	if !found || freshness != Fresh {
+		return nil, false
+	}
+	history, ok := entry.Value.(*model.WeatherAPIHistoryResponse)
+	return history, ok
+}
+
+// SetHistory armazena o histórico de uma data no cache
+func (mc *MemoryCache) SetHistory(location, date string, history *model.WeatherAPIHistoryResponse, duration time.Duration) {
+	mc.set(fmt.Sprintf(historyCacheKey, location, date), history, duration)
+}
+
+// GetAirQuality busca a qualidade do ar de uma localização no cache
+func (mc *MemoryCache) GetAirQuality(location string) (*model.AirQuality, bool) {
+	entry, freshness, found := mc.get(fmt.Sprintf(airQualityCacheKey, location))
+	if !found || freshness != Fresh {
+		return nil, false
+	}
+	airQuality, ok := entry.Value.(*model.AirQuality)
+	return airQuality, ok
+}
+
+// SetAirQuality armazena a qualidade do ar de uma localização no cache
+func (mc *MemoryCache) SetAirQuality(location string, airQuality *model.AirQuality, duration time.Duration) {
+	mc.set(fmt.Sprintf(airQualityCacheKey, location), airQuality, duration)
+}
+
+// SetLocationNotFound registra que o CEP não existe, pelo NegativeTTL configurado.
+// Deve ser usado somente para respostas "não encontrado", nunca para erros transitórios.
+func (mc *MemoryCache) SetLocationNotFound(cep string) {
+	if mc.options.NegativeTTL <= 0 {
+		return
+	}
+	mc.store(fmt.Sprintf(negativeLocationCacheKey, cep), true, mc.options.NegativeTTL)
+}
+
+// IsLocationNotFound verifica se o CEP está registrado como inexistente
+func (mc *MemoryCache) IsLocationNotFound(cep string) bool {
+	if _, found := mc.lookup(fmt.Sprintf(negativeLocationCacheKey, cep)); found {
+		mc.negativeLocationHits.Add(1)
+		return true
+	}
+	return false
+}
+
+// SetWeatherNotFound registra que a localização não existe na API de clima, pelo NegativeTTL configurado
+func (mc *MemoryCache) SetWeatherNotFound(location string) {
+	if mc.options.NegativeTTL <= 0 {
+		return
 	}
-	return nil, false
+	mc.store(fmt.Sprintf(negativeWeatherCacheKey, location), true, mc.options.NegativeTTL)
 }
 
-// SetTemperature armazena temperatura completa no cache
-func (mc *MemoryCache) SetTemperature(cep string, temp *models.TemperatureResponse, duration time.Duration) {
-	key := fmt.Sprintf(tempCacheKey, cep)
-	mc.cache.Set(key, temp, duration)
+// IsWeatherNotFound verifica se a localização está registrada como inexistente
+func (mc *MemoryCache) IsWeatherNotFound(location string) bool {
+	if _, found := mc.lookup(fmt.Sprintf(negativeWeatherCacheKey, location)); found {
+		mc.negativeWeatherHits.Add(1)
+		return true
+	}
+	return false
 }
 
 // InvalidateLocation remove localização do cache
 func (mc *MemoryCache) InvalidateLocation(cep string) {
 	key := fmt.Sprintf(locationCacheKey, cep)
 	mc.cache.Delete(key)
+	mc.cache.Delete(fmt.Sprintf(negativeLocationCacheKey, cep))
 }
 
 // InvalidateWeather remove dados meteorológicos do cache
 func (mc *MemoryCache) InvalidateWeather(location string) {
 	key := fmt.Sprintf(weatherCacheKey, location)
 	mc.cache.Delete(key)
+	mc.cache.Delete(fmt.Sprintf(negativeWeatherCacheKey, location))
 }
 
-// InvalidateTemperature remove temperatura do cache
-func (mc *MemoryCache) InvalidateTemperature(cep string) {
-	key := fmt.Sprintf(tempCacheKey, cep)
+// Delete remove uma chave do cache
+func (mc *MemoryCache) Delete(key string) bool {
+	_, found := mc.cache.Get(key)
 	mc.cache.Delete(key)
+	return found
+}
+
+// DeletePrefix remove as chaves iniciadas por prefix, retornando quantas foram removidas
+func (mc *MemoryCache) DeletePrefix(prefix string) int {
+	deleted := 0
+	for key := range mc.cache.Items() {
+		if strings.HasPrefix(key, prefix) {
+			mc.cache.Delete(key)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// Keys lista as chaves iniciadas por prefix com a validade de cada uma, até limit itens
+func (mc *MemoryCache) Keys(prefix string, limit int) []KeyInfo {
+	items := mc.cache.Items()
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	infos := make([]KeyInfo, 0, len(keys))
+	for _, key := range keys {
+		info := mc.keyInfo(key, items[key])
+		info.Value = nil
+		infos = append(infos, *info)
+	}
+	return infos
+}
+
+// Inspect retorna o item armazenado na chave, com valor e validade
+func (mc *MemoryCache) Inspect(key string) (*KeyInfo, bool) {
+	item, found := mc.cache.Items()[key]
+	if !found {
+		return nil, false
+	}
+	return mc.keyInfo(key, item), true
+}
+
+// keyInfo descreve o item armazenado na chave
+func (mc *MemoryCache) keyInfo(key string, item cache.Item) *KeyInfo {
+	now := mc.now()
+	info := &KeyInfo{Key: key, Type: itemType(key)}
+	if entry, ok := item.Object.(*Entry); ok {
+		freshness, _ := mc.options.freshness(entry, now)
+		info.setEntry(entry, freshness, now)
+		info.Value = entry.Value
+	}
+	if item.Expiration > 0 {
+		info.setExpiration(time.Unix(0, item.Expiration), now)
+	}
+	return info
 }
 
 // Clear limpa todo o cache
 func (mc *MemoryCache) Clear() {
 	mc.cache.Flush()
+	mc.limiter.reset()
 }
 
 // Stats retorna estatísticas do cache
@@ -108,25 +340,48 @@ func (mc *MemoryCache) Stats() map[string]interface{} {
 	items := mc.cache.Items()
 	locationCount := 0
 	weatherCount := 0
-	tempCount := 0
+	forecastCount := 0
+	historyCount := 0
+	airQualityCount := 0
+	negativeLocationCount := 0
+	negativeWeatherCount := 0
 
 	for key := range items {
 		switch {
+		case len(key) > 18 && key[:18] == "notfound:location:":
+			negativeLocationCount++
+		case len(key) > 17 && key[:17] == "notfound:weather:":
+			negativeWeatherCount++
 		case len(key) > 9 && key[:9] == "location:":
 			locationCount++
 		case len(key) > 8 && key[:8] == "weather:":
 			weatherCount++
-		case len(key) > 5 && key[:5] == "temp:":
-			tempCount++
+		case len(key) > 9 && key[:9] == "forecast:":
+			forecastCount++
+		case len(key) > 8 && key[:8] == "history:":
+			historyCount++
+		case len(key) > 11 && key[:11] == "airquality:":
+			airQualityCount++
 		}
 	}
 
-	return map[string]interface{}{
-		"total_items":    len(items),
-		"location_items": locationCount,
-		"weather_items":  weatherCount,
-		"temp_items":     tempCount,
+	stats := map[string]interface{}{
+		"backend": "memory",
+
+		"total_items":       len(items),
+		"location_items":    locationCount,
+		"weather_items":     weatherCount,
+		"forecast_items":    forecastCount,
+		"history_items":     historyCount,
+		"air_quality_items": airQualityCount,
+
+		"negative_location_items": negativeLocationCount,
+		"negative_weather_items":  negativeWeatherCount,
+		"negative_location_hits":  mc.negativeLocationHits.Load(),
+		"negative_weather_hits":   mc.negativeWeatherHits.Load(),
 	}
+	mc.limiter.stats(stats)
+	return stats
 }
 
 // GetSize retorna o número total de itens no cache
@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+)
+
+// newTestCache cria um cache com relógio controlado pelo teste
+func newTestCache(now *time.Time, options Options) *MemoryCache {
+	mc := NewMemoryCache(time.Hour, time.Hour, options)
+	mc.now = func() time.Time { return *now }
+	return mc
+}
+
+func TestMemoryCache_WeatherFreshness(t *testing.T) {
+	now := time.Now()
+	mc := newTestCache(&now, Options{
+		StaleWhileRevalidate: 5 * time.Minute,
+		MaxStale:             time.Hour,
+	})
+
+	weather := &model.WeatherAPIResponse{}
+	weather.Current.TempC = 25
+	mc.SetWeather("São Paulo, SP", weather, 10*time.Minute)
+
+	tests := []struct {
+		name          string
+		elapsed       time.Duration
+		wantFound     bool
+		wantFreshness Freshness
+		wantFreshHit  bool
+	}{
+		{"Dentro do TTL", 5 * time.Minute, true, Fresh, true},
+		{"Janela de stale-while-revalidate", 12 * time.Minute, true, Stale, false},
+		{"Expirado dentro da idade máxima", 30 * time.Minute, true, Expired, false},
+		{"Além da idade máxima", 80 * time.Minute, false, Expired, false},
+	}
+
+	start := now
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = start.Add(tt.elapsed)
+
+			got, freshness, age, found := mc.GetWeatherEntry("São Paulo, SP")
+			if found != tt.wantFound {
+				t.Fatalf("GetWeatherEntry() found = %v, esperava %v", found, tt.wantFound)
+			}
+			if found {
+				if freshness != tt.wantFreshness {
+					t.Errorf("GetWeatherEntry() freshness = %v, esperava %v", freshness, tt.wantFreshness)
+				}
+				if age != tt.elapsed {
+					t.Errorf("GetWeatherEntry() age = %v, esperava %v", age, tt.elapsed)
+				}
+				if got.Current.TempC != 25 {
+					t.Errorf("GetWeatherEntry() temp_c = %v, esperava 25", got.Current.TempC)
+				}
+			}
+
+			if _, hit := mc.GetWeather("São Paulo, SP"); hit != tt.wantFreshHit {
+				t.Errorf("GetWeather() hit = %v, esperava %v", hit, tt.wantFreshHit)
+			}
+		})
+	}
+}
+
+func TestMemoryCache_WithoutStaleness(t *testing.T) {
+	now := time.Now()
+	mc := newTestCache(&now, Options{})
+
+	mc.SetLocation("01310100", &model.ViaCEPResponse{Localidade: "São Paulo", UF: "SP"}, time.Hour)
+
+	if _, found := mc.GetLocation("01310100"); !found {
+		t.Fatal("GetLocation() deveria encontrar o item dentro do TTL")
+	}
+
+	now = now.Add(time.Hour + time.Second)
+	if _, _, _, found := mc.GetLocationEntry("01310100"); found {
+		t.Error("GetLocationEntry() não deveria retornar item expirado sem janela de staleness")
+	}
+}
+
+func TestMemoryCache_NegativeEntries(t *testing.T) {
+	mc := NewMemoryCache(time.Hour, time.Hour, Options{NegativeTTL: time.Minute})
+
+	if mc.IsLocationNotFound("99999999") {
+		t.Fatal("IsLocationNotFound() não deveria encontrar CEP não registrado")
+	}
+
+	mc.SetLocationNotFound("99999999")
+	mc.SetWeatherNotFound("Cidade Inexistente, XX")
+
+	if !mc.IsLocationNotFound("99999999") {
+		t.Error("IsLocationNotFound() deveria encontrar o CEP registrado como inexistente")
+	}
+	if !mc.IsWeatherNotFound("Cidade Inexistente, XX") {
+		t.Error("IsWeatherNotFound() deveria encontrar a localização registrada como inexistente")
+	}
+
+	// Entradas negativas não aparecem como dados positivos
+	if _, found := mc.GetLocation("99999999"); found {
+		t.Error("GetLocation() não deveria retornar entrada negativa")
+	}
+
+	stats := mc.Stats()
+	if stats["negative_location_items"] != 1 || stats["negative_weather_items"] != 1 {
+		t.Errorf("Stats() itens negativos = %v/%v, esperava 1/1", stats["negative_location_items"], stats["negative_weather_items"])
+	}
+	if stats["negative_location_hits"] != int64(1) || stats["negative_weather_hits"] != int64(1) {
+		t.Errorf("Stats() hits negativos = %v/%v, esperava 1/1", stats["negative_location_hits"], stats["negative_weather_hits"])
+	}
+
+	mc.InvalidateLocation("99999999")
+	if mc.IsLocationNotFound("99999999") {
+		t.Error("InvalidateLocation() deveria remover a entrada negativa")
+	}
+}
+
+func TestMemoryCache_NegativeEntriesDisabled(t *testing.T) {
+	mc := NewMemoryCache(time.Hour, time.Hour, Options{})
+
+	mc.SetLocationNotFound("99999999")
+	if mc.IsLocationNotFound("99999999") {
+		t.Error("IsLocationNotFound() não deveria registrar com NegativeTTL zero")
+	}
+}
+
+func TestMemoryCache_InspectAndDeletePrefix(t *testing.T) {
+	now := time.Now()
+	mc := newTestCache(&now, Options{NegativeTTL: time.Minute})
+
+	mc.SetLocation("01310100", &model.ViaCEPResponse{Localidade: "São Paulo"}, time.Hour)
+	mc.SetLocation("80010000", &model.ViaCEPResponse{Localidade: "Curitiba"}, time.Hour)
+	mc.SetLocationNotFound("99999999")
+
+	now = now.Add(15 * time.Minute)
+
+	keys := mc.Keys("location:", 0)
+	if len(keys) != 2 || keys[0].Key != "location:01310100" || keys[0].Value != nil {
+		t.Fatalf("Keys() = %+v, esperava as 2 localizações ordenadas e sem valor", keys)
+	}
+	if keys[0].TTLSeconds != (45*time.Minute).Seconds() || keys[0].Freshness != "fresh" {
+		t.Errorf("Keys() ttl = %v (%s), esperava 2700s fresco", keys[0].TTLSeconds, keys[0].Freshness)
+	}
+
+	info, found := mc.Inspect("location:80010000")
+	if !found || info.Value.(*model.ViaCEPResponse).Localidade != "Curitiba" {
+		t.Errorf("Inspect() = %+v, %v; esperava Curitiba", info, found)
+	}
+	if info, found := mc.Inspect("notfound:location:99999999"); !found || info.Type != TypeNegative {
+		t.Errorf("Inspect() = %+v, %v; esperava entrada negativa", info, found)
+	}
+
+	if deleted := mc.DeletePrefix("location:"); deleted != 2 {
+		t.Errorf("DeletePrefix() = %d, esperava 2", deleted)
+	}
+	if !mc.IsLocationNotFound("99999999") {
+		t.Error("DeletePrefix() não deveria remover chaves fora do prefixo")
+	}
+	if mc.Delete("location:01310100") {
+		t.Error("Delete() de chave inexistente deveria retornar false")
+	}
+}
@@ -0,0 +1,719 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+)
+
+// Codec serializa os itens armazenados no Redis
+type Codec interface {
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                               { return "json" }
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return "msgpack" }
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+var (
+	// JSONCodec serializa os itens em JSON, legível pelo redis-cli
+	JSONCodec Codec = jsonCodec{}
+	// MsgpackCodec serializa os itens em MessagePack, mais compacto
+	MsgpackCodec Codec = msgpackCodec{}
+)
+
+// CodecByName retorna o codec pelo nome ("json" ou "msgpack")
+func CodecByName(name string) (Codec, error) {
+	switch name {
+	case "", "json":
+		return JSONCodec, nil
+	case "msgpack":
+		return MsgpackCodec, nil
+	default:
+		return nil, fmt.Errorf("codec de cache desconhecido: %s", name)
+	}
+}
+
+// redisTimeout tempo máximo de cada operação no Redis; o cache nunca deve
+// atrasar mais a requisição do que a chamada à API externa que ele evita
+const redisTimeout = 500 * time.Millisecond
+
+// redisEntry envelope armazenado no Redis com os metadados de validade
+type redisEntry struct {
+	StoredAt   int64  `json:"stored_at" msgpack:"stored_at"`     // unix millis
+	FreshUntil int64  `json:"fresh_until" msgpack:"fresh_until"` // unix millis
+	Value      []byte `json:"value" msgpack:"value"`
+}
+
+// RedisOptions configura a conexão e a serialização do RedisCache
+type RedisOptions struct {
+	Addr      string
+	Password  string
+	DB        int
+	KeyPrefix string // namespace das chaves, ex.: "goexpertotel:service-b:"
+	Codec     Codec
+}
+
+// RedisCache cache compartilhado entre as réplicas do Service B, armazenado
+// em um servidor Redis. Falhas de comunicação com o Redis são registradas
+// e tratadas como cache miss.
+type RedisCache struct {
+	client  *redis.Client
+	prefix  string
+	codec   Codec
+	options Options
+	now     func() time.Time
+
+	errors               atomic.Int64
+	negativeLocationHits atomic.Int64
+	negativeWeatherHits  atomic.Int64
+	writes               map[string]*atomic.Int64 // itens gravados por tipo, nesta réplica
+}
+
+// NewRedisCache cria um cache conectado ao Redis
+func NewRedisCache(redisOptions RedisOptions, options Options) *RedisCache {
+	codec := redisOptions.Codec
+	if codec == nil {
+		codec = JSONCodec
+	}
+	writes := make(map[string]*atomic.Int64, len(itemTypes))
+	for _, itemType := range itemTypes {
+		writes[itemType] = new(atomic.Int64)
+	}
+	return &RedisCache{
+		client: redis.NewClient(&redis.Options{
+			Addr:     redisOptions.Addr,
+			Password: redisOptions.Password,
+			DB:       redisOptions.DB,
+		}),
+		prefix:  redisOptions.KeyPrefix,
+		codec:   codec,
+		options: options,
+		now:     time.Now,
+		writes:  writes,
+	}
+}
+
+// Ping verifica a conexão com o Redis
+func (rc *RedisCache) Ping(ctx context.Context) error {
+	return rc.client.Ping(ctx).Err()
+}
+
+// Close encerra as conexões com o Redis
+func (rc *RedisCache) Close() error {
+	return rc.client.Close()
+}
+
+// logError registra falhas de comunicação com o Redis
+func (rc *RedisCache) logError(operation, key string, err error) {
+	rc.errors.Add(1)
+	log.Printf("Erro no cache Redis (%s %s): %v", operation, key, err)
+}
+
+// set serializa e armazena o valor, mantendo-o além do TTL pelo tempo
+// necessário para as janelas de stale-while-revalidate e stale-on-error
+func (rc *RedisCache) set(key string, value interface{}, duration time.Duration) {
+	data, err := rc.codec.Marshal(value)
+	if err != nil {
+		rc.logError("serializar", key, err)
+		return
+	}
+
+	now := rc.now()
+	envelope, err := rc.codec.Marshal(&redisEntry{
+		StoredAt:   now.UnixMilli(),
+		FreshUntil: now.Add(duration).UnixMilli(),
+		Value:      data,
+	})
+	if err != nil {
+		rc.logError("serializar", key, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := rc.client.Set(ctx, rc.prefix+key, envelope, duration+rc.options.retention()).Err(); err != nil {
+		rc.logError("SET", key, err)
+		return
+	}
+	rc.countWrite(key)
+}
+
+// countWrite contabiliza um item gravado, pelo tipo da chave
+func (rc *RedisCache) countWrite(key string) {
+	if counter, ok := rc.writes[itemType(key)]; ok {
+		counter.Add(1)
+	}
+}
+
+// get busca e desserializa o item em value, retornando sua validade e idade;
+// itens além da idade máxima são ignorados
+func (rc *RedisCache) get(key string, value interface{}) (Freshness, time.Duration, bool) {
+	entry, freshness, found := rc.getEntry(key, value)
+	if !found {
+		return Expired, 0, false
+	}
+	return freshness, rc.now().Sub(entry.StoredAt), true
+}
+
+// getEntry busca e desserializa o item em value, retornando também os
+// metadados de validade
+func (rc *RedisCache) getEntry(key string, value interface{}) (*Entry, Freshness, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	data, err := rc.client.Get(ctx, rc.prefix+key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			rc.logError("GET", key, err)
+		}
+		return nil, Expired, false
+	}
+
+	var envelope redisEntry
+	if err := rc.codec.Unmarshal(data, &envelope); err != nil {
+		rc.logError("desserializar", key, err)
+		return nil, Expired, false
+	}
+
+	entry := &Entry{
+		Value:      value,
+		StoredAt:   time.UnixMilli(envelope.StoredAt),
+		FreshUntil: time.UnixMilli(envelope.FreshUntil),
+	}
+	freshness, usable := rc.options.freshness(entry, rc.now())
+	if !usable {
+		return nil, Expired, false
+	}
+
+	if err := rc.codec.Unmarshal(envelope.Value, value); err != nil {
+		rc.logError("desserializar", key, err)
+		return nil, Expired, false
+	}
+	return entry, freshness, true
+}
+
+// exists verifica se a chave existe
+func (rc *RedisCache) exists(key string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	count, err := rc.client.Exists(ctx, rc.prefix+key).Result()
+	if err != nil {
+		rc.logError("EXISTS", key, err)
+		return false
+	}
+	return count > 0
+}
+
+// del remove as chaves
+func (rc *RedisCache) del(keys ...string) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = rc.prefix + key
+	}
+	if err := rc.client.Del(ctx, prefixed...).Err(); err != nil {
+		rc.logError("DEL", strings.Join(keys, ","), err)
+	}
+}
+
+// GetLocation busca localização no cache
+func (rc *RedisCache) GetLocation(cep string) (*model.ViaCEPResponse, bool) {
+	location, freshness, _, found := rc.GetLocationEntry(cep)
+	if !found || freshness != Fresh {
+		return nil, false
+	}
+	return location, true
+}
+
+// GetLocationEntry busca localização no cache, incluindo itens expirados ainda
+// dentro da idade máxima, junto com a validade e a idade do item
+func (rc *RedisCache) GetLocationEntry(cep string) (*model.ViaCEPResponse, Freshness, time.Duration, bool) {
+	var location model.ViaCEPResponse
+	freshness, age, found := rc.get(fmt.Sprintf(locationCacheKey, cep), &location)
+	if !found {
+		return nil, Expired, 0, false
+	}
+	return &location, freshness, age, true
+}
+
+// SetLocation armazena localização no cache
+func (rc *RedisCache) SetLocation(cep string, location *model.ViaCEPResponse, duration time.Duration) {
+	rc.set(fmt.Sprintf(locationCacheKey, cep), location, duration)
+}
+
+// GetWeather busca dados meteorológicos no cache
+func (rc *RedisCache) GetWeather(location string) (*model.WeatherAPIResponse, bool) {
+	weather, freshness, _, found := rc.GetWeatherEntry(location)
+	if !found || freshness != Fresh {
+		return nil, false
+	}
+	return weather, true
+}
+
+// GetWeatherEntry busca dados meteorológicos no cache, incluindo itens expirados ainda
+// dentro da idade máxima, junto com a validade e a idade do item
+func (rc *RedisCache) GetWeatherEntry(location string) (*model.WeatherAPIResponse, Freshness, time.Duration, bool) {
+	var weather model.WeatherAPIResponse
+	freshness, age, found := rc.get(fmt.Sprintf(weatherCacheKey, location), &weather)
+	if !found {
+		return nil, Expired, 0, false
+	}
+	return &weather, freshness, age, true
+}
+
+// SetWeather armazena dados meteorológicos no cache
+func (rc *RedisCache) SetWeather(location string, weather *model.WeatherAPIResponse, duration time.Duration) {
+	rc.set(fmt.Sprintf(weatherCacheKey, location), weather, duration)
+}
+
+// GetForecast busca a previsão do tempo no cache
+func (rc *RedisCache) GetForecast(location string) (*model.WeatherAPIForecastResponse, bool) {
+	forecast, freshness, _, found := rc.GetForecastEntry(location)
+	if !found || freshness != Fresh {
+		return nil, false
+	}
+	return forecast, true
+}
+
+// GetForecastEntry busca a previsão do tempo no cache, incluindo itens expirados ainda
+// dentro da idade máxima, junto com a validade e a idade do item
+func (rc *RedisCache) GetForecastEntry(location string) (*model.WeatherAPIForecastResponse, Freshness, time.Duration, bool) {
+	var forecast model.WeatherAPIForecastResponse
+	freshness, age, found := rc.get(fmt.Sprintf(forecastCacheKey, location), &forecast)
+	if !found {
+		return nil, Expired, 0, false
+	}
+	return &forecast, freshness, age, true
+}
+
+// SetForecast armazena a previsão do tempo no cache
+func (rc *RedisCache) SetForecast(location string, forecast *model.WeatherAPIForecastResponse, duration time.Duration) {
+	rc.set(fmt.Sprintf(forecastCacheKey, location), forecast, duration)
+}
+
+// GetHistory busca o histórico de uma data no cache
+func (rc *RedisCache) GetHistory(location, date string) (*model.WeatherAPIHistoryResponse, bool) {
+	var history model.WeatherAPIHistoryResponse
+	freshness, _, found := rc.get(fmt.Sprintf(historyCacheKey, location, date), &history)
+	if !found || freshness != Fresh {
+		return nil, false
+	}
+	return &history, true
+}
+
+// SetHistory armazena o histórico de uma data no cache
+func (rc *RedisCache) SetHistory(location, date string, history *model.WeatherAPIHistoryResponse, duration time.Duration) {
+	rc.set(fmt.Sprintf(historyCacheKey, location, date), history, duration)
+}
+
+// GetAirQuality busca a qualidade do ar de uma localização no cache
+func (rc *RedisCache) GetAirQuality(location string) (*model.AirQuality, bool) {
+	var airQuality model.AirQuality
+	freshness, _, found := rc.get(fmt.Sprintf(airQualityCacheKey, location), &airQuality)
+	if !found || freshness != Fresh {
+		return nil, false
+	}
+	return &airQuality, true
+}
+
+// SetAirQuality armazena a qualidade do ar de uma localização no cache
+func (rc *RedisCache) SetAirQuality(location string, airQuality *model.AirQuality, duration time.Duration) {
+	rc.set(fmt.Sprintf(airQualityCacheKey, location), airQuality, duration)
+}
+
+// setNegative registra uma entrada negativa pelo NegativeTTL configurado
+func (rc *RedisCache) setNegative(key string) {
+	if rc.options.NegativeTTL <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := rc.client.Set(ctx, rc.prefix+key, "1", rc.options.NegativeTTL).Err(); err != nil {
+		rc.logError("SET", key, err)
+		return
+	}
+	rc.countWrite(key)
+}
+
+// SetLocationNotFound registra que o CEP não existe, pelo NegativeTTL configurado.
+// Deve ser usado somente para respostas "não encontrado", nunca para erros transitórios.
+func (rc *RedisCache) SetLocationNotFound(cep string) {
+	rc.setNegative(fmt.Sprintf(negativeLocationCacheKey, cep))
+}
+
+// IsLocationNotFound verifica se o CEP está registrado como inexistente
+func (rc *RedisCache) IsLocationNotFound(cep string) bool {
+	if rc.exists(fmt.Sprintf(negativeLocationCacheKey, cep)) {
+		rc.negativeLocationHits.Add(1)
+		return true
+	}
+	return false
+}
+
+// SetWeatherNotFound registra que a localização não existe na API de clima, pelo NegativeTTL configurado
+func (rc *RedisCache) SetWeatherNotFound(location string) {
+	rc.setNegative(fmt.Sprintf(negativeWeatherCacheKey, location))
+}
+
+// IsWeatherNotFound verifica se a localização está registrada como inexistente
+func (rc *RedisCache) IsWeatherNotFound(location string) bool {
+	if rc.exists(fmt.Sprintf(negativeWeatherCacheKey, location)) {
+		rc.negativeWeatherHits.Add(1)
+		return true
+	}
+	return false
+}
+
+// InvalidateLocation remove localização do cache
+func (rc *RedisCache) InvalidateLocation(cep string) {
+	rc.del(fmt.Sprintf(locationCacheKey, cep), fmt.Sprintf(negativeLocationCacheKey, cep))
+}
+
+// InvalidateWeather remove dados meteorológicos do cache
+func (rc *RedisCache) InvalidateWeather(location string) {
+	rc.del(fmt.Sprintf(weatherCacheKey, location), fmt.Sprintf(negativeWeatherCacheKey, location))
+}
+
+// invalidationsChannel canal de pub/sub usado para propagar invalidações entre réplicas
+const invalidationsChannel = "invalidations"
+
+// invalidation mensagem publicada quando um item é invalidado em uma réplica
+type invalidation struct {
+	Origin string `json:"origin"`        // réplica que publicou a mensagem
+	Kind   string `json:"kind"`          // location, weather, clear, key ou prefix
+	Key    string `json:"key,omitempty"` // CEP ou localização
+}
+
+// publishInvalidation publica a invalidação para as demais réplicas
+func (rc *RedisCache) publishInvalidation(msg invalidation) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		rc.logError("serializar", invalidationsChannel, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := rc.client.Publish(ctx, rc.prefix+invalidationsChannel, data).Err(); err != nil {
+		rc.logError("PUBLISH", invalidationsChannel, err)
+	}
+}
+
+// subscribeInvalidations recebe as invalidações publicadas até ctx ser
+// cancelado. Retorna após a inscrição ser confirmada pelo Redis; a conexão é
+// refeita automaticamente, mas mensagens publicadas enquanto ela estiver
+// interrompida são perdidas.
+func (rc *RedisCache) subscribeInvalidations(ctx context.Context, fn func(invalidation)) {
+	pubsub := rc.client.Subscribe(ctx, rc.prefix+invalidationsChannel)
+
+	confirmCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	if _, err := pubsub.Receive(confirmCtx); err != nil {
+		rc.logError("SUBSCRIBE", invalidationsChannel, err)
+	}
+	cancel()
+
+	go func() {
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+				var msg invalidation
+				if err := json.Unmarshal([]byte(message.Payload), &msg); err != nil {
+					rc.logError("desserializar", invalidationsChannel, err)
+					continue
+				}
+				fn(msg)
+			}
+		}
+	}()
+}
+
+// scan percorre as chaves do namespace iniciadas por prefix, sem bloquear o
+// Redis como o KEYS faria
+func (rc *RedisCache) scan(ctx context.Context, prefix string, fn func(key string)) error {
+	iter := rc.client.Scan(ctx, 0, escapeGlob(rc.prefix+prefix)+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		fn(strings.TrimPrefix(iter.Val(), rc.prefix))
+	}
+	return iter.Err()
+}
+
+// escapeGlob escapa os caracteres especiais do padrão MATCH do SCAN
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Clear remove todas as chaves do namespace; chaves de outros serviços no
+// mesmo Redis são preservadas
+func (rc *RedisCache) Clear() {
+	rc.DeletePrefix("")
+}
+
+// Delete remove uma chave do cache
+func (rc *RedisCache) Delete(key string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	count, err := rc.client.Del(ctx, rc.prefix+key).Result()
+	if err != nil {
+		rc.logError("DEL", key, err)
+		return false
+	}
+	return count > 0
+}
+
+// DeletePrefix remove as chaves iniciadas por prefix, retornando quantas foram removidas
+func (rc *RedisCache) DeletePrefix(prefix string) int {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var keys []string
+	err := rc.scan(ctx, prefix, func(key string) {
+		keys = append(keys, rc.prefix+key)
+	})
+	if err != nil {
+		rc.logError("SCAN", rc.prefix+prefix+"*", err)
+		return 0
+	}
+
+	deleted := 0
+	for start := 0; start < len(keys); start += 1000 {
+		end := start + 1000
+		if end > len(keys) {
+			end = len(keys)
+		}
+		count, err := rc.client.Del(ctx, keys[start:end]...).Result()
+		if err != nil {
+			rc.logError("DEL", rc.prefix+prefix+"*", err)
+			break
+		}
+		deleted += int(count)
+	}
+	return deleted
+}
+
+// Keys lista as chaves iniciadas por prefix com a validade de cada uma, até limit itens
+func (rc *RedisCache) Keys(prefix string, limit int) []KeyInfo {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var keys []string
+	err := rc.scan(ctx, prefix, func(key string) {
+		keys = append(keys, key)
+	})
+	if err != nil {
+		rc.logError("SCAN", rc.prefix+prefix+"*", err)
+	}
+	sort.Strings(keys)
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	// Consulta as chaves em lotes, com um pipeline por lote
+	infos := make([]KeyInfo, 0, len(keys))
+	for start := 0; start < len(keys); start += 1000 {
+		end := start + 1000
+		if end > len(keys) {
+			end = len(keys)
+		}
+		pipe := rc.client.Pipeline()
+		cmds := make([]inspectCmds, 0, end-start)
+		for _, key := range keys[start:end] {
+			cmds = append(cmds, rc.queueInspect(ctx, pipe, key))
+		}
+		pipe.Exec(ctx)
+		for i, key := range keys[start:end] {
+			if info, found := rc.keyInfo(key, cmds[i], false); found {
+				infos = append(infos, *info)
+			}
+		}
+	}
+	return infos
+}
+
+// Inspect retorna o item armazenado na chave, com valor e validade
+func (rc *RedisCache) Inspect(key string) (*KeyInfo, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	return rc.inspect(ctx, key, true)
+}
+
+// inspect busca a validade do item e, se withValue, o valor desserializado
+func (rc *RedisCache) inspect(ctx context.Context, key string, withValue bool) (*KeyInfo, bool) {
+	pipe := rc.client.Pipeline()
+	cmds := rc.queueInspect(ctx, pipe, key)
+	pipe.Exec(ctx)
+	return rc.keyInfo(key, cmds, withValue)
+}
+
+// inspectCmds comandos que consultam um item em um pipeline
+type inspectCmds struct {
+	get *redis.StringCmd
+	ttl *redis.DurationCmd
+}
+
+// queueInspect adiciona ao pipeline a consulta do valor e da validade do item
+func (rc *RedisCache) queueInspect(ctx context.Context, pipe redis.Pipeliner, key string) inspectCmds {
+	return inspectCmds{
+		get: pipe.Get(ctx, rc.prefix+key),
+		ttl: pipe.PTTL(ctx, rc.prefix+key),
+	}
+}
+
+// keyInfo descreve o item a partir das consultas executadas no pipeline; os
+// erros são verificados por comando, já que Exec retorna somente o primeiro
+func (rc *RedisCache) keyInfo(key string, cmds inspectCmds, withValue bool) (*KeyInfo, bool) {
+	data, err := cmds.get.Bytes()
+	if err == nil {
+		err = cmds.ttl.Err()
+	}
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			rc.logError("GET", key, err)
+		}
+		return nil, false
+	}
+
+	now := rc.now()
+	info := &KeyInfo{Key: key, Type: itemType(key)}
+	if ttl := cmds.ttl.Val(); ttl > 0 {
+		info.setExpiration(now.Add(ttl), now)
+	}
+	if info.Type == TypeNegative {
+		return info, true
+	}
+
+	var envelope redisEntry
+	if err := rc.codec.Unmarshal(data, &envelope); err != nil {
+		rc.logError("desserializar", key, err)
+		return nil, false
+	}
+	entry := &Entry{
+		StoredAt:   time.UnixMilli(envelope.StoredAt),
+		FreshUntil: time.UnixMilli(envelope.FreshUntil),
+	}
+	freshness, _ := rc.options.freshness(entry, now)
+	info.setEntry(entry, freshness, now)
+
+	if withValue {
+		value := newValue(info.Type)
+		if value == nil {
+			return info, true
+		}
+		if err := rc.codec.Unmarshal(envelope.Value, value); err != nil {
+			rc.logError("desserializar", key, err)
+			return nil, false
+		}
+		info.Value = value
+	}
+	return info, true
+}
+
+// Stats retorna estatísticas do cache sem consultar o Redis: os itens
+// gravados por esta réplica em cada tipo, os acertos do cache negativo e os
+// erros de comunicação. O número exato de chaves exige percorrer o namespace
+// e fica em CountKeys.
+func (rc *RedisCache) Stats() map[string]interface{} {
+	stats := map[string]interface{}{
+		"backend":    "redis",
+		"codec":      rc.codec.Name(),
+		"key_prefix": rc.prefix,
+
+		"negative_location_hits": rc.negativeLocationHits.Load(),
+		"negative_weather_hits":  rc.negativeWeatherHits.Load(),
+
+		"errors": rc.errors.Load(),
+	}
+	for _, itemType := range itemTypes {
+		stats[itemType+"_writes"] = rc.writes[itemType].Load()
+	}
+	return stats
+}
+
+// CountKeys conta as chaves do namespace por tipo percorrendo-o com SCAN, ao
+// custo de uma ida ao Redis a cada 1000 chaves. Usado somente sob demanda
+// pelo /cache/stats, nunca pelo health check.
+func (rc *RedisCache) CountKeys(ctx context.Context) (map[string]interface{}, error) {
+	total := 0
+	counts := map[string]int{}
+	err := rc.scan(ctx, "", func(key string) {
+		total++
+		switch {
+		case strings.HasPrefix(key, "notfound:location:"):
+			counts["negative_location_items"]++
+		case strings.HasPrefix(key, "notfound:weather:"):
+			counts["negative_weather_items"]++
+		case strings.HasPrefix(key, "location:"):
+			counts["location_items"]++
+		case strings.HasPrefix(key, "weather:"):
+			counts["weather_items"]++
+		case strings.HasPrefix(key, "forecast:"):
+			counts["forecast_items"]++
+		case strings.HasPrefix(key, "history:"):
+			counts["history_items"]++
+		case strings.HasPrefix(key, "airquality:"):
+			counts["air_quality_items"]++
+		}
+	})
+	if err != nil {
+		rc.logError("SCAN", rc.prefix+"*", err)
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"total_items":             total,
+		"location_items":          counts["location_items"],
+		"weather_items":           counts["weather_items"],
+		"forecast_items":          counts["forecast_items"],
+		"history_items":           counts["history_items"],
+		"air_quality_items":       counts["air_quality_items"],
+		"negative_location_items": counts["negative_location_items"],
+		"negative_weather_items":  counts["negative_weather_items"],
+	}, nil
+}
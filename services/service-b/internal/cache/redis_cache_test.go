@@ -0,0 +1,185 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+)
+
+// newTestRedisCache cria um cache conectado a um Redis em memória, com relógio
+// controlado pelo teste
+func newTestRedisCache(t *testing.T, now *time.Time, codec Codec, options Options) (*RedisCache, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	rc := NewRedisCache(RedisOptions{
+		Addr:      server.Addr(),
+		KeyPrefix: "test:service-b:",
+		Codec:     codec,
+	}, options)
+	rc.now = func() time.Time { return *now }
+	t.Cleanup(func() { rc.Close() })
+	return rc, server
+}
+
+func TestRedisCache_RoundTrip(t *testing.T) {
+	for _, codec := range []Codec{JSONCodec, MsgpackCodec} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			now := time.Now()
+			rc, server := newTestRedisCache(t, &now, codec, Options{})
+
+			rc.SetLocation("01310100", &model.ViaCEPResponse{CEP: "01310-100", Localidade: "São Paulo", UF: "SP"}, time.Hour)
+
+			weather := &model.WeatherAPIResponse{}
+			weather.Current.TempC = 25.5
+			rc.SetWeather("São Paulo, SP", weather, time.Hour)
+
+			location, found := rc.GetLocation("01310100")
+			if !found || location.Localidade != "São Paulo" || location.UF != "SP" {
+				t.Errorf("GetLocation() = %+v, %v; esperava São Paulo/SP", location, found)
+			}
+			if got, found := rc.GetWeather("São Paulo, SP"); !found || got.Current.TempC != 25.5 {
+				t.Errorf("GetWeather() = %+v, %v; esperava temp_c 25.5", got, found)
+			}
+
+			// As chaves ficam no namespace configurado
+			if !server.Exists("test:service-b:location:01310100") {
+				t.Errorf("chave com namespace não encontrada, chaves = %v", server.Keys())
+			}
+
+			// Stats não percorre o Redis: conta as gravações desta réplica
+			stats := rc.Stats()
+			if stats["location_writes"] != int64(1) || stats["weather_writes"] != int64(1) || stats["codec"] != codec.Name() {
+				t.Errorf("Stats() = %v, esperava uma gravação de cada tipo com codec %s", stats, codec.Name())
+			}
+			counts, err := rc.CountKeys(context.Background())
+			if err != nil || counts["total_items"] != 2 || counts["location_items"] != 1 {
+				t.Errorf("CountKeys() = %v, %v; esperava 2 itens, 1 de localização", counts, err)
+			}
+
+			info, found := rc.Inspect("location:01310100")
+			if !found || info.Value.(*model.ViaCEPResponse).Localidade != "São Paulo" || info.ExpiresAt == nil {
+				t.Errorf("Inspect() = %+v, %v; esperava São Paulo com expiração", info, found)
+			}
+			if keys := rc.Keys("weather:", 10); len(keys) != 1 || keys[0].Key != "weather:São Paulo, SP" {
+				t.Errorf("Keys() = %+v, esperava somente o clima", keys)
+			}
+
+			rc.InvalidateLocation("01310100")
+			if _, found := rc.GetLocation("01310100"); found {
+				t.Error("InvalidateLocation() deveria remover a localização")
+			}
+		})
+	}
+}
+
+func TestRedisCache_Freshness(t *testing.T) {
+	// O Redis armazena os instantes com precisão de milissegundos
+	now := time.Now().Truncate(time.Millisecond)
+	rc, server := newTestRedisCache(t, &now, JSONCodec, Options{
+		StaleWhileRevalidate: 5 * time.Minute,
+		MaxStale:             time.Hour,
+	})
+
+	rc.SetLocation("01310100", &model.ViaCEPResponse{Localidade: "São Paulo", UF: "SP"}, 10*time.Minute)
+
+	// O Redis mantém o item pelo TTL mais a janela de staleness
+	if ttl := server.TTL("test:service-b:location:01310100"); ttl != 70*time.Minute {
+		t.Errorf("TTL no Redis = %v, esperava 70m", ttl)
+	}
+
+	start := now
+	tests := []struct {
+		name          string
+		elapsed       time.Duration
+		wantFound     bool
+		wantFreshness Freshness
+	}{
+		{"Dentro do TTL", 5 * time.Minute, true, Fresh},
+		{"Janela de stale-while-revalidate", 12 * time.Minute, true, Stale},
+		{"Expirado dentro da idade máxima", 30 * time.Minute, true, Expired},
+		{"Além da idade máxima", 80 * time.Minute, false, Expired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = start.Add(tt.elapsed)
+
+			_, freshness, age, found := rc.GetLocationEntry("01310100")
+			if found != tt.wantFound {
+				t.Fatalf("GetLocationEntry() found = %v, esperava %v", found, tt.wantFound)
+			}
+			if found && (freshness != tt.wantFreshness || age != tt.elapsed) {
+				t.Errorf("GetLocationEntry() = (%v, %v), esperava (%v, %v)", freshness, age, tt.wantFreshness, tt.elapsed)
+			}
+		})
+	}
+}
+
+func TestRedisCache_KeysSortedBeforeLimit(t *testing.T) {
+	now := time.Now().Truncate(time.Millisecond)
+	rc, _ := newTestRedisCache(t, &now, JSONCodec, Options{})
+
+	for _, cep := range []string{"03000000", "01000000", "04000000", "02000000"} {
+		rc.SetLocation(cep, &model.ViaCEPResponse{CEP: cep, Localidade: "São Paulo", UF: "SP"}, time.Hour)
+	}
+
+	// O limite é aplicado depois da ordenação, como no cache em memória
+	keys := rc.Keys("location:", 2)
+	if len(keys) != 2 || keys[0].Key != "location:01000000" || keys[1].Key != "location:02000000" {
+		t.Fatalf("Keys() = %+v, esperava as 2 primeiras localizações em ordem", keys)
+	}
+	if keys[0].Freshness != "fresh" || keys[0].TTLSeconds != 3600 || keys[0].Value != nil {
+		t.Errorf("Keys() = %+v, esperava item fresco, com 3600s e sem valor", keys[0])
+	}
+}
+
+func TestRedisCache_NegativeEntriesAndClear(t *testing.T) {
+	now := time.Now()
+	rc, server := newTestRedisCache(t, &now, JSONCodec, Options{NegativeTTL: time.Minute})
+
+	// Chaves de outros serviços no mesmo Redis não são afetadas
+	server.Set("other-service:key", "value")
+
+	rc.SetLocationNotFound("99999999")
+	if !rc.IsLocationNotFound("99999999") {
+		t.Error("IsLocationNotFound() deveria encontrar o CEP registrado como inexistente")
+	}
+	if ttl := server.TTL("test:service-b:notfound:location:99999999"); ttl != time.Minute {
+		t.Errorf("TTL da entrada negativa = %v, esperava 1m", ttl)
+	}
+
+	rc.SetWeatherNotFound("Cidade Inexistente, XX")
+
+	// Caracteres especiais do padrão do SCAN no prefixo são tratados literalmente
+	if deleted := rc.DeletePrefix("notfound:*"); deleted != 0 {
+		t.Errorf("DeletePrefix() = %d, esperava 0", deleted)
+	}
+
+	rc.Clear()
+
+	if rc.IsLocationNotFound("99999999") || rc.IsWeatherNotFound("Cidade Inexistente, XX") {
+		t.Error("Clear() deveria remover as entradas negativas")
+	}
+	if !server.Exists("other-service:key") {
+		t.Error("Clear() não deveria remover chaves fora do namespace")
+	}
+}
+
+func TestRedisCache_UnavailableIsMiss(t *testing.T) {
+	now := time.Now()
+	rc, server := newTestRedisCache(t, &now, JSONCodec, Options{})
+	server.Close()
+
+	rc.SetLocation("01310100", &model.ViaCEPResponse{Localidade: "São Paulo"}, time.Hour)
+	if _, found := rc.GetLocation("01310100"); found {
+		t.Error("GetLocation() deveria retornar miss com o Redis indisponível")
+	}
+	if errors := rc.Stats()["errors"].(int64); errors == 0 {
+		t.Error("Stats() deveria contabilizar os erros de comunicação com o Redis")
+	}
+}
@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/atomicfile"
+)
+
+// snapshotVersion versão do formato do arquivo de snapshot
+const snapshotVersion = 1
+
+// snapshot conteúdo do arquivo gravado por SaveSnapshot
+type snapshot struct {
+	Version int             `json:"version"`
+	SavedAt time.Time       `json:"saved_at"`
+	Entries []snapshotEntry `json:"entries"`
+}
+
+// snapshotEntry item persistido com os metadados de validade originais
+type snapshotEntry struct {
+	Key        string          `json:"key"`
+	StoredAt   time.Time       `json:"stored_at"`
+	FreshUntil time.Time       `json:"fresh_until"`
+	Value      json.RawMessage `json:"value"`
+}
+
+// SaveSnapshot grava em path as localizações e os dados meteorológicos ainda
+// utilizáveis, para que sejam restaurados por LoadSnapshot no próximo start.
+// Entradas negativas têm TTL curto e não são persistidas. O arquivo é
+// substituído de forma atômica.
+func (mc *MemoryCache) SaveSnapshot(path string) (int, error) {
+	now := mc.now()
+	snap := snapshot{Version: snapshotVersion, SavedAt: now}
+
+	for key, item := range mc.cache.Items() {
+		if newValue(itemType(key)) == nil {
+			continue
+		}
+		entry, ok := item.Object.(*Entry)
+		if !ok {
+			continue
+		}
+		if _, usable := mc.options.freshness(entry, now); !usable {
+			continue
+		}
+
+		value, err := json.Marshal(entry.Value)
+		if err != nil {
+			return 0, fmt.Errorf("erro ao serializar item %s: %w", key, err)
+		}
+		snap.Entries = append(snap.Entries, snapshotEntry{
+			Key:        key,
+			StoredAt:   entry.StoredAt,
+			FreshUntil: entry.FreshUntil,
+			Value:      value,
+		})
+	}
+
+	data, err := json.Marshal(&snap)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao serializar snapshot: %w", err)
+	}
+
+	if err := atomicfile.WriteFile(path, data); err != nil {
+		return 0, fmt.Errorf("erro ao gravar snapshot: %w", err)
+	}
+
+	return len(snap.Entries), nil
+}
+
+// LoadSnapshot restaura os itens gravados por SaveSnapshot preservando o
+// tempo restante de cada um; itens que expiraram enquanto o serviço estava
+// parado são descartados. Um arquivo inexistente não é erro.
+func (mc *MemoryCache) LoadSnapshot(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("erro ao ler snapshot: %w", err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return 0, fmt.Errorf("snapshot inválido: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return 0, fmt.Errorf("versão de snapshot não suportada: %d", snap.Version)
+	}
+
+	now := mc.now()
+	restored := 0
+	for _, item := range snap.Entries {
+		value := newValue(itemType(item.Key))
+		if value == nil {
+			continue
+		}
+		if err := json.Unmarshal(item.Value, value); err != nil {
+			return restored, fmt.Errorf("erro ao restaurar item %s: %w", item.Key, err)
+		}
+
+		entry := &Entry{Value: value, StoredAt: item.StoredAt, FreshUntil: item.FreshUntil}
+		if _, usable := mc.options.freshness(entry, now); !usable {
+			continue
+		}
+		mc.setEntry(item.Key, entry)
+		restored++
+	}
+
+	return restored, nil
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+)
+
+func TestMemoryCache_SnapshotRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	options := Options{StaleWhileRevalidate: 5 * time.Minute, NegativeTTL: time.Minute}
+
+	now := time.Now()
+	source := newTestCache(&now, options)
+
+	source.SetLocation("01310100", &model.ViaCEPResponse{Localidade: "São Paulo", UF: "SP"}, 24*time.Hour)
+	weather := &model.WeatherAPIResponse{}
+	weather.Location.Name = "São Paulo"
+	weather.Current.TempC = 25
+	source.SetWeather("São Paulo, SP", weather, 10*time.Minute)
+	source.SetWeather("Curitiba, PR", weather, time.Minute)
+	source.SetLocationNotFound("99999999")
+
+	// Curitiba já está além da janela de staleness quando o snapshot é gravado
+	now = now.Add(7 * time.Minute)
+	saved, err := source.SaveSnapshot(path)
+	if err != nil {
+		t.Fatalf("SaveSnapshot() erro inesperado = %v", err)
+	}
+	if saved != 2 {
+		t.Errorf("SaveSnapshot() = %d itens, esperava 2", saved)
+	}
+
+	// O serviço volta 2 minutos depois
+	now = now.Add(2 * time.Minute)
+	restoredCache := newTestCache(&now, options)
+	restored, err := restoredCache.LoadSnapshot(path)
+	if err != nil {
+		t.Fatalf("LoadSnapshot() erro inesperado = %v", err)
+	}
+	if restored != 2 {
+		t.Errorf("LoadSnapshot() = %d itens, esperava 2", restored)
+	}
+
+	location, freshness, age, found := restoredCache.GetLocationEntry("01310100")
+	if !found || location.Localidade != "São Paulo" || freshness != Fresh || age != 9*time.Minute {
+		t.Errorf("GetLocationEntry() = (%+v, %v, %v, %v), esperava São Paulo fresco com 9m", location, freshness, age, found)
+	}
+
+	// O TTL restante é preservado: o clima expira 10 minutos após ter sido armazenado
+	if _, found := restoredCache.GetWeather("São Paulo, SP"); !found {
+		t.Error("GetWeather() deveria encontrar o clima restaurado dentro do TTL")
+	}
+	now = now.Add(2 * time.Minute)
+	if _, freshness, _, _ := restoredCache.GetWeatherEntry("São Paulo, SP"); freshness != Stale {
+		t.Errorf("GetWeatherEntry() freshness = %v, esperava stale após o TTL original", freshness)
+	}
+
+	if restoredCache.IsLocationNotFound("99999999") {
+		t.Error("entradas negativas não deveriam ser persistidas")
+	}
+}
+
+func TestMemoryCache_LoadSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	unsupported := filepath.Join(dir, "v2.json")
+	if err := os.WriteFile(unsupported, []byte(`{"version":2}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{"Arquivo inexistente", filepath.Join(dir, "missing.json"), false},
+		{"JSON inválido", invalid, true},
+		{"Versão não suportada", unsupported, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := NewMemoryCache(time.Hour, time.Hour, Options{})
+			restored, err := mc.LoadSnapshot(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadSnapshot() erro = %v, esperava erro: %v", err, tt.wantErr)
+			}
+			if restored != 0 {
+				t.Errorf("LoadSnapshot() = %d itens, esperava 0", restored)
+			}
+		})
+	}
+}
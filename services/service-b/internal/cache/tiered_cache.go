@@ -0,0 +1,375 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+)
+
+// Tipos de item propagados nas invalidações entre réplicas
+const (
+	invalidateLocation = "location"
+	invalidateWeather  = "weather"
+	invalidateAll      = "clear"
+	invalidateKey      = "key"
+	invalidatePrefix   = "prefix"
+)
+
+// TieredCache combina um cache local (L1) com um cache compartilhado (L2).
+// Leituras consultam o L1 e, se o item não estiver fresco, o L2, copiando o
+// resultado para o L1; escritas vão para os dois níveis. Invalidações são
+// publicadas no Redis para que as demais réplicas removam o item do seu L1.
+type TieredCache struct {
+	l1     *MemoryCache
+	l2     *RedisCache
+	l1TTL  time.Duration
+	origin string
+	cancel context.CancelFunc
+
+	l1Hits                 atomic.Int64
+	l2Hits                 atomic.Int64
+	misses                 atomic.Int64
+	invalidationsPublished atomic.Int64
+	invalidationsReceived  atomic.Int64
+}
+
+// NewTieredCache cria o cache em dois níveis e passa a receber as
+// invalidações das demais réplicas. l1TTL limita por quanto tempo um item é
+// considerado fresco no L1, o que restringe a inconsistência caso uma
+// invalidação seja perdida; zero mantém o TTL original do item.
+func NewTieredCache(l1 *MemoryCache, l2 *RedisCache, l1TTL time.Duration) *TieredCache {
+	ctx, cancel := context.WithCancel(context.Background())
+	tc := &TieredCache{
+		l1:     l1,
+		l2:     l2,
+		l1TTL:  l1TTL,
+		origin: newOrigin(),
+		cancel: cancel,
+	}
+	l2.subscribeInvalidations(ctx, tc.applyInvalidation)
+	return tc
+}
+
+// newOrigin gera o identificador desta réplica nas mensagens de invalidação
+func newOrigin() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// Close encerra a inscrição nas invalidações e as conexões com o L2
+func (tc *TieredCache) Close() error {
+	tc.cancel()
+	return tc.l2.Close()
+}
+
+// applyInvalidation remove do L1 o item invalidado por outra réplica
+func (tc *TieredCache) applyInvalidation(msg invalidation) {
+	if msg.Origin == tc.origin {
+		return
+	}
+	tc.invalidationsReceived.Add(1)
+
+	switch msg.Kind {
+	case invalidateLocation:
+		tc.l1.InvalidateLocation(msg.Key)
+	case invalidateWeather:
+		tc.l1.InvalidateWeather(msg.Key)
+	case invalidateAll:
+		tc.l1.Clear()
+	case invalidateKey:
+		tc.l1.Delete(msg.Key)
+	case invalidatePrefix:
+		tc.l1.DeletePrefix(msg.Key)
+	}
+}
+
+// publish propaga a invalidação para as demais réplicas
+func (tc *TieredCache) publish(kind, key string) {
+	tc.invalidationsPublished.Add(1)
+	tc.l2.publishInvalidation(invalidation{Origin: tc.origin, Kind: kind, Key: key})
+}
+
+// l1Entry limita a validade do item copiado para o L1
+func (tc *TieredCache) l1Entry(entry *Entry) *Entry {
+	if tc.l1TTL <= 0 {
+		return entry
+	}
+	if limit := tc.l1.now().Add(tc.l1TTL); limit.Before(entry.FreshUntil) {
+		limited := *entry
+		limited.FreshUntil = limit
+		return &limited
+	}
+	return entry
+}
+
+// get busca o item no L1 e, se não estiver fresco, no L2
+func (tc *TieredCache) get(key string) (*Entry, Freshness, bool) {
+	local, localFreshness, localFound := tc.l1.get(key)
+	if localFound && localFreshness == Fresh {
+		tc.l1Hits.Add(1)
+		return local, Fresh, true
+	}
+
+	if remote, freshness, found := tc.l2.getEntry(key, newValue(itemType(key))); found {
+		tc.l2Hits.Add(1)
+		tc.l1.setEntry(key, tc.l1Entry(remote))
+		return remote, freshness, true
+	}
+
+	// Sem o item no L2 (ou com o Redis indisponível) o item local ainda
+	// pode ser servido dentro das janelas de staleness
+	if localFound {
+		tc.l1Hits.Add(1)
+		return local, localFreshness, true
+	}
+
+	tc.misses.Add(1)
+	return nil, Expired, false
+}
+
+// set armazena o item nos dois níveis
+func (tc *TieredCache) set(key string, value interface{}, duration time.Duration) {
+	localDuration := duration
+	if tc.l1TTL > 0 && tc.l1TTL < localDuration {
+		localDuration = tc.l1TTL
+	}
+	tc.l1.set(key, value, localDuration)
+	tc.l2.set(key, value, duration)
+}
+
+// GetLocation busca localização no cache
+func (tc *TieredCache) GetLocation(cep string) (*model.ViaCEPResponse, bool) {
+	location, freshness, _, found := tc.GetLocationEntry(cep)
+	if !found || freshness != Fresh {
+		return nil, false
+	}
+	return location, true
+}
+
+// GetLocationEntry busca localização no cache, incluindo itens expirados ainda
+// dentro da idade máxima, junto com a validade e a idade do item
+func (tc *TieredCache) GetLocationEntry(cep string) (*model.ViaCEPResponse, Freshness, time.Duration, bool) {
+	entry, freshness, found := tc.get(fmt.Sprintf(locationCacheKey, cep))
+	if !found {
+		return nil, Expired, 0, false
+	}
+	location, ok := entry.Value.(*model.ViaCEPResponse)
+	if !ok {
+		return nil, Expired, 0, false
+	}
+	return location, freshness, tc.l1.now().Sub(entry.StoredAt), true
+}
+
+// SetLocation armazena localização no cache
+func (tc *TieredCache) SetLocation(cep string, location *model.ViaCEPResponse, duration time.Duration) {
+	tc.set(fmt.Sprintf(locationCacheKey, cep), location, duration)
+}
+
+// GetWeather busca dados meteorológicos no cache
+func (tc *TieredCache) GetWeather(location string) (*model.WeatherAPIResponse, bool) {
+	weather, freshness, _, found := tc.GetWeatherEntry(location)
+	if !found || freshness != Fresh {
+		return nil, false
+	}
+	return weather, true
+}
+
+// GetWeatherEntry busca dados meteorológicos no cache, incluindo itens expirados ainda
+// dentro da idade máxima, junto com a validade e a idade do item
+func (tc *TieredCache) GetWeatherEntry(location string) (*model.WeatherAPIResponse, Freshness, time.Duration, bool) {
+	entry, freshness, found := tc.get(fmt.Sprintf(weatherCacheKey, location))
+	if !found {
+		return nil, Expired, 0, false
+	}
+	weather, ok := entry.Value.(*model.WeatherAPIResponse)
+	if !ok {
+		return nil, Expired, 0, false
+	}
+	return weather, freshness, tc.l1.now().Sub(entry.StoredAt), true
+}
+
+// SetWeather armazena dados meteorológicos no cache
+func (tc *TieredCache) SetWeather(location string, weather *model.WeatherAPIResponse, duration time.Duration) {
+	tc.set(fmt.Sprintf(weatherCacheKey, location), weather, duration)
+}
+
+// GetForecast busca a previsão do tempo no cache
+func (tc *TieredCache) GetForecast(location string) (*model.WeatherAPIForecastResponse, bool) {
+	forecast, freshness, _, found := tc.GetForecastEntry(location)
+	if !found || freshness != Fresh {
+		return nil, false
+	}
+	return forecast, true
+}
+
+// GetForecastEntry busca a previsão do tempo no cache, incluindo itens expirados ainda
+// dentro da idade máxima, junto com a validade e a idade do item
+func (tc *TieredCache) GetForecastEntry(location string) (*model.WeatherAPIForecastResponse, Freshness, time.Duration, bool) {
+	entry, freshness, found := tc.get(fmt.Sprintf(forecastCacheKey, location))
+	if !found {
+		return nil, Expired, 0, false
+	}
+	forecast, ok := entry.Value.(*model.WeatherAPIForecastResponse)
+	if !ok {
+		return nil, Expired, 0, false
+	}
+	return forecast, freshness, tc.l1.now().Sub(entry.StoredAt), true
+}
+
+// SetForecast armazena a previsão do tempo no cache
+func (tc *TieredCache) SetForecast(location string, forecast *model.WeatherAPIForecastResponse, duration time.Duration) {
+	tc.set(fmt.Sprintf(forecastCacheKey, location), forecast, duration)
+}
+
+// GetHistory busca o histórico de uma data no cache
+func (tc *TieredCache) GetHistory(location, date string) (*model.WeatherAPIHistoryResponse, bool) {
+	entry, freshness, found := tc.get(fmt.Sprintf(historyCacheKey, location, date))
+	if !found || freshness != Fresh {
+		return nil, false
+	}
+	history, ok := entry.Value.(*model.WeatherAPIHistoryResponse)
+	return history, ok
+}
+
+// SetHistory armazena o histórico de uma data no cache
+func (tc *TieredCache) SetHistory(location, date string, history *model.WeatherAPIHistoryResponse, duration time.Duration) {
+	tc.set(fmt.Sprintf(historyCacheKey, location, date), history, duration)
+}
+
+// GetAirQuality busca a qualidade do ar de uma localização no cache
+func (tc *TieredCache) GetAirQuality(location string) (*model.AirQuality, bool) {
+	entry, freshness, found := tc.get(fmt.Sprintf(airQualityCacheKey, location))
+	if !found || freshness != Fresh {
+		return nil, false
+	}
+	airQuality, ok := entry.Value.(*model.AirQuality)
+	return airQuality, ok
+}
+
+// SetAirQuality armazena a qualidade do ar de uma localização no cache
+func (tc *TieredCache) SetAirQuality(location string, airQuality *model.AirQuality, duration time.Duration) {
+	tc.set(fmt.Sprintf(airQualityCacheKey, location), airQuality, duration)
+}
+
+// SetLocationNotFound registra nos dois níveis que o CEP não existe
+func (tc *TieredCache) SetLocationNotFound(cep string) {
+	tc.l1.SetLocationNotFound(cep)
+	tc.l2.SetLocationNotFound(cep)
+}
+
+// IsLocationNotFound verifica se o CEP está registrado como inexistente em
+// algum dos níveis, copiando o registro do L2 para o L1
+func (tc *TieredCache) IsLocationNotFound(cep string) bool {
+	if tc.l1.IsLocationNotFound(cep) {
+		return true
+	}
+	if tc.l2.IsLocationNotFound(cep) {
+		tc.l1.SetLocationNotFound(cep)
+		return true
+	}
+	return false
+}
+
+// SetWeatherNotFound registra nos dois níveis que a localização não existe
+func (tc *TieredCache) SetWeatherNotFound(location string) {
+	tc.l1.SetWeatherNotFound(location)
+	tc.l2.SetWeatherNotFound(location)
+}
+
+// IsWeatherNotFound verifica se a localização está registrada como inexistente
+// em algum dos níveis, copiando o registro do L2 para o L1
+func (tc *TieredCache) IsWeatherNotFound(location string) bool {
+	if tc.l1.IsWeatherNotFound(location) {
+		return true
+	}
+	if tc.l2.IsWeatherNotFound(location) {
+		tc.l1.SetWeatherNotFound(location)
+		return true
+	}
+	return false
+}
+
+// InvalidateLocation remove localização dos dois níveis e do L1 das demais réplicas
+func (tc *TieredCache) InvalidateLocation(cep string) {
+	tc.l1.InvalidateLocation(cep)
+	tc.l2.InvalidateLocation(cep)
+	tc.publish(invalidateLocation, cep)
+}
+
+// InvalidateWeather remove dados meteorológicos dos dois níveis e do L1 das demais réplicas
+func (tc *TieredCache) InvalidateWeather(location string) {
+	tc.l1.InvalidateWeather(location)
+	tc.l2.InvalidateWeather(location)
+	tc.publish(invalidateWeather, location)
+}
+
+// Clear limpa os dois níveis e o L1 das demais réplicas
+func (tc *TieredCache) Clear() {
+	tc.l1.Clear()
+	tc.l2.Clear()
+	tc.publish(invalidateAll, "")
+}
+
+// Delete remove a chave dos dois níveis e do L1 das demais réplicas
+func (tc *TieredCache) Delete(key string) bool {
+	local := tc.l1.Delete(key)
+	remote := tc.l2.Delete(key)
+	tc.publish(invalidateKey, key)
+	return local || remote
+}
+
+// DeletePrefix remove as chaves iniciadas por prefix dos dois níveis e do L1
+// das demais réplicas, retornando quantas foram removidas do L2
+func (tc *TieredCache) DeletePrefix(prefix string) int {
+	tc.l1.DeletePrefix(prefix)
+	deleted := tc.l2.DeletePrefix(prefix)
+	tc.publish(invalidatePrefix, prefix)
+	return deleted
+}
+
+// Keys lista as chaves do L2, compartilhado entre as réplicas
+func (tc *TieredCache) Keys(prefix string, limit int) []KeyInfo {
+	return tc.l2.Keys(prefix, limit)
+}
+
+// Inspect retorna o item do L2 ou, se ausente, do L1 desta réplica
+func (tc *TieredCache) Inspect(key string) (*KeyInfo, bool) {
+	if info, found := tc.l2.Inspect(key); found {
+		return info, true
+	}
+	return tc.l1.Inspect(key)
+}
+
+// ClearLocal limpa somente o L1 desta réplica, preservando o L2 compartilhado
+func (tc *TieredCache) ClearLocal() {
+	tc.l1.Clear()
+}
+
+// CountKeys conta as chaves do L2, compartilhado entre as réplicas
+func (tc *TieredCache) CountKeys(ctx context.Context) (map[string]interface{}, error) {
+	return tc.l2.CountKeys(ctx)
+}
+
+// Stats retorna estatísticas dos dois níveis
+func (tc *TieredCache) Stats() map[string]interface{} {
+	return map[string]interface{}{
+		"backend": "tiered",
+		"l1":      tc.l1.Stats(),
+		"l2":      tc.l2.Stats(),
+
+		"l1_hits": tc.l1Hits.Load(),
+		"l2_hits": tc.l2Hits.Load(),
+		"misses":  tc.misses.Load(),
+
+		"invalidations_published": tc.invalidationsPublished.Load(),
+		"invalidations_received":  tc.invalidationsReceived.Load(),
+	}
+}
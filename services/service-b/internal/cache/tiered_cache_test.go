@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+)
+
+// newTestTieredCache cria uma réplica com L1 próprio e L2 no Redis informado
+func newTestTieredCache(t *testing.T, server *miniredis.Miniredis, l1TTL time.Duration) *TieredCache {
+	t.Helper()
+
+	l1 := NewMemoryCache(time.Hour, time.Hour, Options{NegativeTTL: time.Minute})
+	l2 := NewRedisCache(RedisOptions{Addr: server.Addr(), KeyPrefix: "test:service-b:"}, Options{NegativeTTL: time.Minute})
+	tc := NewTieredCache(l1, l2, l1TTL)
+	t.Cleanup(func() { tc.Close() })
+	return tc
+}
+
+func TestTieredCache_ReadThrough(t *testing.T) {
+	server := miniredis.RunT(t)
+	replicaA := newTestTieredCache(t, server, 0)
+	replicaB := newTestTieredCache(t, server, 0)
+
+	replicaA.SetLocation("01310100", &model.ViaCEPResponse{Localidade: "São Paulo", UF: "SP"}, time.Hour)
+
+	// Primeira leitura na outra réplica vem do L2 e popula o L1
+	location, found := replicaB.GetLocation("01310100")
+	if !found || location.Localidade != "São Paulo" {
+		t.Fatalf("GetLocation() = %+v, %v; esperava São Paulo", location, found)
+	}
+	if _, found := replicaB.l1.GetLocation("01310100"); !found {
+		t.Error("GetLocation() deveria popular o L1 com o item lido do L2")
+	}
+
+	replicaB.GetLocation("01310100")
+	stats := replicaB.Stats()
+	if stats["l1_hits"] != int64(1) || stats["l2_hits"] != int64(1) {
+		t.Errorf("Stats() hits = L1 %v / L2 %v, esperava 1/1", stats["l1_hits"], stats["l2_hits"])
+	}
+
+	// Com o Redis indisponível o L1 continua respondendo
+	server.Close()
+	if _, found := replicaB.GetLocation("01310100"); !found {
+		t.Error("GetLocation() deveria responder pelo L1 com o L2 indisponível")
+	}
+}
+
+func TestTieredCache_CrossReplicaInvalidation(t *testing.T) {
+	server := miniredis.RunT(t)
+	replicaA := newTestTieredCache(t, server, 0)
+	replicaB := newTestTieredCache(t, server, 0)
+
+	replicaA.SetLocation("01310100", &model.ViaCEPResponse{Localidade: "São Paulo", UF: "SP"}, time.Hour)
+	if _, found := replicaB.GetLocation("01310100"); !found {
+		t.Fatal("GetLocation() deveria encontrar o item gravado pela outra réplica")
+	}
+
+	replicaA.InvalidateLocation("01310100")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, found := replicaB.l1.GetLocation("01310100"); !found {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("InvalidateLocation() deveria remover o item do L1 da outra réplica")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, found := replicaB.GetLocation("01310100"); found {
+		t.Error("GetLocation() não deveria encontrar o item invalidado")
+	}
+	if received := replicaB.Stats()["invalidations_received"]; received != int64(1) {
+		t.Errorf("Stats() invalidations_received = %v, esperava 1", received)
+	}
+	if received := replicaA.Stats()["invalidations_received"]; received != int64(0) {
+		t.Errorf("a réplica de origem não deveria processar a própria invalidação, recebidas = %v", received)
+	}
+}
+
+func TestTieredCache_L1TTL(t *testing.T) {
+	server := miniredis.RunT(t)
+	tc := newTestTieredCache(t, server, time.Minute)
+
+	now := time.Now()
+	tc.l1.now = func() time.Time { return now }
+
+	weather := &model.WeatherAPIResponse{}
+	weather.Current.TempC = 20
+	tc.SetWeather("Curitiba, PR", weather, 10*time.Minute)
+
+	// Após o limite do L1 o item ainda fresco no L2 é relido de lá
+	now = now.Add(2 * time.Minute)
+	if _, found := tc.GetWeather("Curitiba, PR"); !found {
+		t.Fatal("GetWeather() deveria encontrar o item ainda fresco no L2")
+	}
+	if hits := tc.Stats()["l2_hits"]; hits != int64(1) {
+		t.Errorf("Stats() l2_hits = %v, esperava 1", hits)
+	}
+}
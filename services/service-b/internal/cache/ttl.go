@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+)
+
+// Origem do TTL escolhido para um item
+const (
+	TTLSourceFixed    = "fixed"    // TTL configurado para o tipo
+	TTLSourceUpstream = "upstream" // TTL alinhado à atualização dos dados na API externa
+)
+
+// TTLPolicy define por quanto tempo cada tipo de item é considerado fresco
+type TTLPolicy struct {
+	Location time.Duration
+	Weather  time.Duration
+	Forecast time.Duration
+	History  time.Duration
+	// AirQuality TTL da qualidade do ar, atualizada pelos provedores de hora em hora
+	AirQuality time.Duration
+	// WeatherUpdateInterval cadência com que a WeatherAPI atualiza as
+	// condições atuais. Quando positivo, o clima expira quando a próxima
+	// atualização é esperada (last_updated_epoch + intervalo), limitado a
+	// WeatherMinTTL e Weather; zero usa sempre o TTL fixo.
+	WeatherUpdateInterval time.Duration
+	// WeatherMinTTL TTL mínimo do clima quando a próxima atualização já
+	// passou ou está muito próxima, evitando consultas repetidas à API
+	WeatherMinTTL time.Duration
+}
+
+// DefaultTTLPolicy TTLs usados quando nada é configurado
+func DefaultTTLPolicy() TTLPolicy {
+	return TTLPolicy{
+		Location:   24 * time.Hour,
+		Weather:    10 * time.Minute,
+		Forecast:   time.Hour,
+		History:    30 * 24 * time.Hour,
+		AirQuality: 30 * time.Minute,
+	}
+}
+
+// WeatherTTL TTL dos dados meteorológicos recebidos em now, junto com a
+// origem do valor escolhido
+func (p TTLPolicy) WeatherTTL(weather *model.WeatherAPIResponse, now time.Time) (time.Duration, string) {
+	if p.WeatherUpdateInterval <= 0 || weather.Current.LastUpdatedEpoch <= 0 {
+		return p.Weather, TTLSourceFixed
+	}
+
+	lastUpdated := time.Unix(weather.Current.LastUpdatedEpoch, 0)
+	ttl := lastUpdated.Add(p.WeatherUpdateInterval).Sub(now)
+	if ttl < p.WeatherMinTTL {
+		ttl = p.WeatherMinTTL
+	}
+	if p.Weather > 0 && ttl > p.Weather {
+		ttl = p.Weather
+	}
+	// Sem WeatherMinTTL positivo a próxima atualização já vencida daria um TTL
+	// nulo ou negativo, que o cache trataria como "sem expiração"
+	if ttl <= 0 {
+		return p.Weather, TTLSourceFixed
+	}
+	return ttl, TTLSourceUpstream
+}
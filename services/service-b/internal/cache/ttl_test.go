@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+)
+
+func TestTTLPolicy_WeatherTTL(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	upstream := TTLPolicy{
+		Weather:               20 * time.Minute,
+		WeatherUpdateInterval: 15 * time.Minute,
+		WeatherMinTTL:         time.Minute,
+	}
+
+	tests := []struct {
+		name        string
+		policy      TTLPolicy
+		lastUpdated time.Duration // antes de now; zero sem last_updated_epoch
+		wantTTL     time.Duration
+		wantSource  string
+	}{
+		{"TTL fixo", DefaultTTLPolicy(), 5 * time.Minute, 10 * time.Minute, TTLSourceFixed},
+		{"Alinhado à próxima atualização", upstream, 5 * time.Minute, 10 * time.Minute, TTLSourceUpstream},
+		{"Atualização atrasada usa o mínimo", upstream, 20 * time.Minute, time.Minute, TTLSourceUpstream},
+		{"Limitado ao TTL máximo", upstream, -10 * time.Minute, 20 * time.Minute, TTLSourceUpstream},
+		{"Sem last_updated_epoch usa o TTL fixo", upstream, 0, 20 * time.Minute, TTLSourceFixed},
+		{"Sem TTL mínimo usa o TTL fixo", TTLPolicy{Weather: 20 * time.Minute, WeatherUpdateInterval: 15 * time.Minute}, 20 * time.Minute, 20 * time.Minute, TTLSourceFixed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weather := &model.WeatherAPIResponse{}
+			if tt.lastUpdated != 0 {
+				weather.Current.LastUpdatedEpoch = now.Add(-tt.lastUpdated).Unix()
+			}
+
+			ttl, source := tt.policy.WeatherTTL(weather, now)
+			if ttl != tt.wantTTL || source != tt.wantSource {
+				t.Errorf("WeatherTTL() = (%v, %s), esperava (%v, %s)", ttl, source, tt.wantTTL, tt.wantSource)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package cepindex
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// csvColumns mapeia os nomes de coluna aceitos no CSV para os campos do registro
+var csvColumns = map[string]string{
+	"cep":         "cep",
+	"logradouro":  "logradouro",
+	"complemento": "complemento",
+	"bairro":      "bairro",
+	"localidade":  "localidade",
+	"cidade":      "localidade",
+	"municipio":   "localidade",
+	"uf":          "uf",
+	"estado":      "uf",
+	"ibge":        "ibge",
+}
+
+// ParseCSV lê um export CSV de CEPs. A primeira linha deve ser o cabeçalho
+// com os nomes das colunas (cep, logradouro, complemento, bairro,
+// localidade/cidade, uf, ibge); colunas desconhecidas são ignoradas e o
+// separador (vírgula ou ponto e vírgula) é detectado a partir do cabeçalho.
+func ParseCSV(r io.Reader) ([]Record, error) {
+	br := bufio.NewReader(r)
+	headerLine, err := br.Peek(4096)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, fmt.Errorf("erro ao ler cabeçalho do CSV: %w", err)
+	}
+
+	reader := csv.NewReader(br)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if firstLine, _, _ := strings.Cut(string(headerLine), "\n"); strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		reader.Comma = ';'
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler cabeçalho do CSV: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if field, ok := csvColumns[name]; ok {
+			columns[field] = i
+		}
+	}
+	if _, ok := columns["cep"]; !ok {
+		return nil, fmt.Errorf("CSV sem coluna 'cep'")
+	}
+
+	get := func(row []string, field string) string {
+		if i, ok := columns[field]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var records []Record
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler CSV na linha %d: %w", line, err)
+		}
+
+		cep := onlyDigits(get(row, "cep"))
+		if len(cep) != 8 {
+			return nil, fmt.Errorf("CEP inválido no CSV na linha %d: %q", line, get(row, "cep"))
+		}
+
+		records = append(records, Record{
+			CEP:         cep,
+			Logradouro:  get(row, "logradouro"),
+			Complemento: get(row, "complemento"),
+			Bairro:      get(row, "bairro"),
+			Localidade:  get(row, "localidade"),
+			UF:          strings.ToUpper(get(row, "uf")),
+			IBGE:        get(row, "ibge"),
+		})
+	}
+
+	return records, nil
+}
+
+// Column posição de um campo em um registro de largura fixa (1-based, como na documentação dos Correios)
+type Column struct {
+	Start  int
+	Length int
+}
+
+// DNELayout descreve as posições dos campos em um arquivo de largura fixa do DNE
+type DNELayout struct {
+	RecordType  byte
+	UF          Column
+	Localidade  Column
+	Bairro      Column
+	Logradouro  Column
+	Complemento Column
+	CEP         Column
+	IBGE        Column
+}
+
+// DefaultDNELayout layout dos registros de logradouro ("D") do DNE em formato
+// fixo (arquivos DNE_GU_<UF>_LOGRADOUROS.TXT). Ajuste as posições caso a
+// versão do arquivo fornecido pelos Correios seja diferente.
+var DefaultDNELayout = DNELayout{
+	RecordType:  'D',
+	UF:          Column{Start: 2, Length: 2},
+	Localidade:  Column{Start: 18, Length: 72},
+	Bairro:      Column{Start: 103, Length: 72},
+	Logradouro:  Column{Start: 375, Length: 72},
+	Complemento: Column{Start: 447, Length: 36},
+	CEP:         Column{Start: 519, Length: 8},
+	IBGE:        Column{Start: 591, Length: 7},
+}
+
+// ParseDNE lê um arquivo de largura fixa do DNE usando o layout informado.
+// Linhas de outros tipos de registro (cabeçalho, trailer) são ignoradas.
+func ParseDNE(r io.Reader, layout DNELayout) ([]Record, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var records []Record
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if !utf8.ValidString(text) {
+			// Arquivos do DNE são distribuídos em ISO-8859-1
+			text = latin1ToUTF8(scanner.Bytes())
+		}
+		runes := []rune(strings.TrimRight(text, "\r"))
+		if len(runes) == 0 || byte(runes[0]) != layout.RecordType {
+			continue
+		}
+
+		cep := onlyDigits(slice(runes, layout.CEP))
+		if len(cep) != 8 {
+			return nil, fmt.Errorf("CEP inválido no arquivo DNE na linha %d", line)
+		}
+
+		records = append(records, Record{
+			CEP:         cep,
+			Logradouro:  slice(runes, layout.Logradouro),
+			Complemento: slice(runes, layout.Complemento),
+			Bairro:      slice(runes, layout.Bairro),
+			Localidade:  slice(runes, layout.Localidade),
+			UF:          strings.ToUpper(slice(runes, layout.UF)),
+			IBGE:        onlyDigits(slice(runes, layout.IBGE)),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo DNE: %w", err)
+	}
+
+	return records, nil
+}
+
+// slice extrai e normaliza um campo de largura fixa
+func slice(runes []rune, col Column) string {
+	start := col.Start - 1
+	if start < 0 || start >= len(runes) {
+		return ""
+	}
+	end := start + col.Length
+	if end > len(runes) {
+		end = len(runes)
+	}
+	return strings.TrimSpace(string(runes[start:end]))
+}
+
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func latin1ToUTF8(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
@@ -0,0 +1,272 @@
+package cepindex
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/atomicfile"
+)
+
+// Formato do arquivo de índice (little endian):
+//
+//	header  : magic "CEPX" | versão (uint32) | nº de registros (uint32) | nº de strings (uint32)
+//	records : nº de registros × [cep (uint32) | 6 × índice de string (uint32)]
+//	offsets : (nº de strings + 1) × uint32, posição de cada string no blob
+//	blob    : strings concatenadas
+//
+// Os registros ficam ordenados por CEP para permitir busca binária e as
+// strings (cidade, UF, bairro...) são deduplicadas, o que mantém o arquivo
+// compacto mesmo com centenas de milhares de CEPs da mesma cidade.
+const (
+	magic         = "CEPX"
+	formatVersion = 1
+	headerSize    = 16
+	fieldCount    = 6
+	recordSize    = 4 + fieldCount*4
+)
+
+// ErrNotFound indica que o CEP não está presente no índice
+var ErrNotFound = errors.New("cep não encontrado no índice")
+
+// Record representa um CEP com os dados de localização armazenados no índice
+type Record struct {
+	CEP         string
+	Logradouro  string
+	Complemento string
+	Bairro      string
+	Localidade  string
+	UF          string
+	IBGE        string
+}
+
+func (r *Record) fields() [fieldCount]string {
+	return [fieldCount]string{r.Logradouro, r.Complemento, r.Bairro, r.Localidade, r.UF, r.IBGE}
+}
+
+// Write grava os registros no formato do índice. Registros com CEP repetido
+// são resolvidos mantendo a última ocorrência.
+func Write(w io.Writer, records []Record) error {
+	byCEP := make(map[uint32]Record, len(records))
+	for _, rec := range records {
+		cep, err := parseCEP(rec.CEP)
+		if err != nil {
+			return err
+		}
+		byCEP[cep] = rec
+	}
+
+	ceps := make([]uint32, 0, len(byCEP))
+	for cep := range byCEP {
+		ceps = append(ceps, cep)
+	}
+	sort.Slice(ceps, func(i, j int) bool { return ceps[i] < ceps[j] })
+
+	// Tabela de strings deduplicadas; a string vazia sempre ocupa o índice 0
+	stringIndex := map[string]uint32{"": 0}
+	strs := []string{""}
+	intern := func(s string) uint32 {
+		if idx, ok := stringIndex[s]; ok {
+			return idx
+		}
+		idx := uint32(len(strs))
+		stringIndex[s] = idx
+		strs = append(strs, s)
+		return idx
+	}
+
+	recordsBuf := make([]byte, 0, len(ceps)*recordSize)
+	for _, cep := range ceps {
+		rec := byCEP[cep]
+		recordsBuf = binary.LittleEndian.AppendUint32(recordsBuf, cep)
+		for _, field := range rec.fields() {
+			recordsBuf = binary.LittleEndian.AppendUint32(recordsBuf, intern(field))
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+
+	header := make([]byte, 0, headerSize)
+	header = append(header, magic...)
+	header = binary.LittleEndian.AppendUint32(header, formatVersion)
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(ceps)))
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(strs)))
+	if _, err := bw.Write(header); err != nil {
+		return fmt.Errorf("erro ao gravar cabeçalho: %w", err)
+	}
+
+	if _, err := bw.Write(recordsBuf); err != nil {
+		return fmt.Errorf("erro ao gravar registros: %w", err)
+	}
+
+	offsets := make([]byte, 0, (len(strs)+1)*4)
+	var offset uint32
+	for _, s := range strs {
+		offsets = binary.LittleEndian.AppendUint32(offsets, offset)
+		offset += uint32(len(s))
+	}
+	offsets = binary.LittleEndian.AppendUint32(offsets, offset)
+	if _, err := bw.Write(offsets); err != nil {
+		return fmt.Errorf("erro ao gravar tabela de strings: %w", err)
+	}
+
+	for _, s := range strs {
+		if _, err := bw.WriteString(s); err != nil {
+			return fmt.Errorf("erro ao gravar strings: %w", err)
+		}
+	}
+
+	return bw.Flush()
+}
+
+// WriteFile grava o índice em disco de forma atômica (arquivo temporário + rename)
+func WriteFile(path string, records []Record) error {
+	err := atomicfile.Write(path, func(w io.Writer) error {
+		return Write(w, records)
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao gravar arquivo de índice: %w", err)
+	}
+	return nil
+}
+
+// Index índice de CEPs carregado em memória, somente leitura e seguro para uso concorrente
+type Index struct {
+	data    []byte
+	count   int
+	records []byte
+	offsets []byte
+	blob    []byte
+}
+
+// Open carrega um índice do disco
+func Open(path string) (*Index, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler índice de CEPs: %w", err)
+	}
+	return Load(data)
+}
+
+// Load interpreta um índice já carregado em memória
+func Load(data []byte) (*Index, error) {
+	if len(data) < headerSize || string(data[:4]) != magic {
+		return nil, fmt.Errorf("arquivo de índice de CEPs inválido")
+	}
+	if version := binary.LittleEndian.Uint32(data[4:8]); version != formatVersion {
+		return nil, fmt.Errorf("versão de índice de CEPs não suportada: %d", version)
+	}
+
+	count := int(binary.LittleEndian.Uint32(data[8:12]))
+	strCount := int(binary.LittleEndian.Uint32(data[12:16]))
+
+	recordsEnd := headerSize + count*recordSize
+	offsetsEnd := recordsEnd + (strCount+1)*4
+	if len(data) < offsetsEnd {
+		return nil, fmt.Errorf("arquivo de índice de CEPs truncado")
+	}
+
+	idx := &Index{
+		data:    data,
+		count:   count,
+		records: data[headerSize:recordsEnd],
+		offsets: data[recordsEnd:offsetsEnd],
+		blob:    data[offsetsEnd:],
+	}
+
+	if blobSize := binary.LittleEndian.Uint32(idx.offsets[strCount*4:]); int(blobSize) != len(idx.blob) {
+		return nil, fmt.Errorf("arquivo de índice de CEPs truncado")
+	}
+	if err := idx.validate(strCount); err != nil {
+		return nil, err
+	}
+
+	return idx, nil
+}
+
+// validate confere a consistência interna do índice, para que Lookup não
+// precise checar limites: offsets crescentes e dentro do blob, índices de
+// string existentes e registros em ordem estritamente crescente de CEP
+func (idx *Index) validate(strCount int) error {
+	var prev uint32
+	for i := 0; i <= strCount; i++ {
+		offset := binary.LittleEndian.Uint32(idx.offsets[i*4:])
+		if offset < prev || int(offset) > len(idx.blob) {
+			return fmt.Errorf("arquivo de índice de CEPs corrompido: offset da string %d inválido", i)
+		}
+		prev = offset
+	}
+
+	for i := 0; i < idx.count; i++ {
+		if i > 0 && idx.cepAt(i) <= idx.cepAt(i-1) {
+			return fmt.Errorf("arquivo de índice de CEPs corrompido: registro %d fora de ordem", i)
+		}
+		rec := idx.records[i*recordSize+4 : (i+1)*recordSize]
+		for field := 0; field < fieldCount; field++ {
+			if s := binary.LittleEndian.Uint32(rec[field*4:]); int(s) >= strCount {
+				return fmt.Errorf("arquivo de índice de CEPs corrompido: registro %d referencia string inexistente", i)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Len retorna o número de CEPs no índice
+func (idx *Index) Len() int {
+	return idx.count
+}
+
+// Lookup busca um CEP (8 dígitos) no índice
+func (idx *Index) Lookup(cep string) (*Record, error) {
+	key, err := parseCEP(cep)
+	if err != nil {
+		return nil, err
+	}
+
+	i := sort.Search(idx.count, func(i int) bool {
+		return idx.cepAt(i) >= key
+	})
+	if i >= idx.count || idx.cepAt(i) != key {
+		return nil, ErrNotFound
+	}
+
+	rec := idx.records[i*recordSize+4 : (i+1)*recordSize]
+	return &Record{
+		CEP:         fmt.Sprintf("%08d", key),
+		Logradouro:  idx.stringAt(rec, 0),
+		Complemento: idx.stringAt(rec, 1),
+		Bairro:      idx.stringAt(rec, 2),
+		Localidade:  idx.stringAt(rec, 3),
+		UF:          idx.stringAt(rec, 4),
+		IBGE:        idx.stringAt(rec, 5),
+	}, nil
+}
+
+func (idx *Index) cepAt(i int) uint32 {
+	return binary.LittleEndian.Uint32(idx.records[i*recordSize:])
+}
+
+func (idx *Index) stringAt(rec []byte, field int) string {
+	s := binary.LittleEndian.Uint32(rec[field*4:])
+	start := binary.LittleEndian.Uint32(idx.offsets[s*4:])
+	end := binary.LittleEndian.Uint32(idx.offsets[(s+1)*4:])
+	return string(idx.blob[start:end])
+}
+
+// parseCEP converte um CEP de 8 dígitos para a chave numérica do índice
+func parseCEP(cep string) (uint32, error) {
+	if len(cep) != 8 {
+		return 0, fmt.Errorf("CEP inválido para o índice: %q", cep)
+	}
+	value, err := strconv.ParseUint(cep, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("CEP inválido para o índice: %q", cep)
+	}
+	return uint32(value), nil
+}
@@ -0,0 +1,220 @@
+package cepindex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIndex_WriteAndLookup(t *testing.T) {
+	records := []Record{
+		{CEP: "01310100", Logradouro: "Avenida Paulista", Bairro: "Bela Vista", Localidade: "São Paulo", UF: "SP", IBGE: "3550308"},
+		{CEP: "20040020", Logradouro: "Rua da Assembleia", Bairro: "Centro", Localidade: "Rio de Janeiro", UF: "RJ", IBGE: "3304557"},
+		{CEP: "01001000", Logradouro: "Praça da Sé", Complemento: "lado ímpar", Bairro: "Sé", Localidade: "São Paulo", UF: "SP", IBGE: "3550308"},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, records); err != nil {
+		t.Fatalf("Write() erro inesperado = %v", err)
+	}
+
+	index, err := Load(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Load() erro inesperado = %v", err)
+	}
+
+	if index.Len() != len(records) {
+		t.Errorf("Len() = %d, esperava %d", index.Len(), len(records))
+	}
+
+	for _, want := range records {
+		t.Run(want.CEP, func(t *testing.T) {
+			got, err := index.Lookup(want.CEP)
+			if err != nil {
+				t.Fatalf("Lookup(%s) erro inesperado = %v", want.CEP, err)
+			}
+			if *got != want {
+				t.Errorf("Lookup(%s) = %+v, esperava %+v", want.CEP, *got, want)
+			}
+		})
+	}
+
+	if _, err := index.Lookup("99999999"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Lookup(99999999) erro = %v, esperava ErrNotFound", err)
+	}
+	if _, err := index.Lookup("123"); err == nil {
+		t.Error("Lookup(123) esperava erro de CEP inválido")
+	}
+}
+
+func TestIndex_WriteFileAndOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ceps.idx")
+	records := []Record{
+		{CEP: "89010000", Localidade: "Blumenau", UF: "SC"},
+		{CEP: "89010000", Localidade: "Blumenau", UF: "SC", Bairro: "Centro"},
+	}
+
+	if err := WriteFile(path, records); err != nil {
+		t.Fatalf("WriteFile() erro inesperado = %v", err)
+	}
+
+	index, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() erro inesperado = %v", err)
+	}
+
+	if index.Len() != 1 {
+		t.Errorf("Len() = %d, esperava 1 (CEP duplicado)", index.Len())
+	}
+
+	got, err := index.Lookup("89010000")
+	if err != nil {
+		t.Fatalf("Lookup() erro inesperado = %v", err)
+	}
+	if got.Bairro != "Centro" {
+		t.Errorf("Lookup().Bairro = %q, esperava a última ocorrência %q", got.Bairro, "Centro")
+	}
+}
+
+func TestIndex_LoadInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"Vazio", nil},
+		{"Magic inválido", []byte("XXXX000000000000")},
+		{"Truncado", []byte("CEPX\x01\x00\x00\x00\x05\x00\x00\x00\x01\x00\x00\x00")},
+		{"Índice de string inexistente", corrupt(t, func(data []byte) { data[headerSize+4] = 0xff })},
+		{"Offsets decrescentes", corrupt(t, func(data []byte) { binary.LittleEndian.PutUint32(data[len(data)-len("São PauloSP")-3*4:], 11) })},
+		{"Registros fora de ordem", corrupt(t, func(data []byte) { binary.LittleEndian.PutUint32(data[headerSize+recordSize:], 1) })},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(tt.data); err == nil {
+				t.Error("Load() esperava erro")
+			}
+		})
+	}
+}
+
+// corrupt grava um índice válido de dois CEPs e aplica a corrupção sobre os bytes
+func corrupt(t *testing.T, apply func(data []byte)) []byte {
+	t.Helper()
+	records := []Record{
+		{CEP: "01001000", Localidade: "São Paulo", UF: "SP"},
+		{CEP: "01310100", Localidade: "São Paulo", UF: "SP"},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, records); err != nil {
+		t.Fatalf("Write() erro inesperado = %v", err)
+	}
+	if _, err := Load(buf.Bytes()); err != nil {
+		t.Fatalf("Load() erro inesperado antes da corrupção = %v", err)
+	}
+
+	data := buf.Bytes()
+	apply(data)
+	return data
+}
+
+func TestParseCSV(t *testing.T) {
+	input := "\ufeffCEP;Logradouro;Bairro;Cidade;UF;IBGE\n" +
+		"01310-100;Avenida Paulista;Bela Vista;São Paulo;sp;3550308\n" +
+		"20040020;Rua da Assembleia;Centro;Rio de Janeiro;RJ;3304557\n"
+
+	records, err := ParseCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseCSV() erro inesperado = %v", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("ParseCSV() retornou %d registros, esperava 2", len(records))
+	}
+
+	want := Record{CEP: "01310100", Logradouro: "Avenida Paulista", Bairro: "Bela Vista", Localidade: "São Paulo", UF: "SP", IBGE: "3550308"}
+	if records[0] != want {
+		t.Errorf("ParseCSV()[0] = %+v, esperava %+v", records[0], want)
+	}
+}
+
+func TestParseCSV_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"Sem coluna cep", "cidade,uf\nSão Paulo,SP\n"},
+		{"CEP inválido", "cep,cidade,uf\n123,São Paulo,SP\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseCSV(strings.NewReader(tt.input)); err == nil {
+				t.Error("ParseCSV() esperava erro")
+			}
+		})
+	}
+}
+
+func TestParseDNE(t *testing.T) {
+	layout := DefaultDNELayout
+	line := []rune(strings.Repeat(" ", 600))
+	put := func(col Column, value string) {
+		copy(line[col.Start-1:], []rune(value))
+	}
+	line[0] = 'D'
+	put(layout.UF, "SP")
+	put(layout.Localidade, "São Paulo")
+	put(layout.Bairro, "Bela Vista")
+	put(layout.Logradouro, "Paulista")
+	put(layout.CEP, "01310100")
+	put(layout.IBGE, "3550308")
+
+	input := "C cabeçalho\n" + string(line) + "\r\n"
+
+	records, err := ParseDNE(strings.NewReader(input), layout)
+	if err != nil {
+		t.Fatalf("ParseDNE() erro inesperado = %v", err)
+	}
+
+	if len(records) != 1 {
+		t.Fatalf("ParseDNE() retornou %d registros, esperava 1", len(records))
+	}
+
+	want := Record{CEP: "01310100", Logradouro: "Paulista", Bairro: "Bela Vista", Localidade: "São Paulo", UF: "SP", IBGE: "3550308"}
+	if records[0] != want {
+		t.Errorf("ParseDNE()[0] = %+v, esperava %+v", records[0], want)
+	}
+}
+
+func BenchmarkIndex_Lookup(b *testing.B) {
+	records := make([]Record, 0, 100000)
+	for i := 0; i < 100000; i++ {
+		records = append(records, Record{
+			CEP:        fmt.Sprintf("%08d", i*97),
+			Localidade: "São Paulo",
+			UF:         "SP",
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, records); err != nil {
+		b.Fatal(err)
+	}
+	index, err := Load(buf.Bytes())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := index.Lookup(records[i%len(records)].CEP); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/keypool"
+)
+
+// ErrQuotaExhausted indica que o orçamento de chamadas à WeatherAPI foi
+// atingido ou que a API recusou a chamada por cota esgotada
+var ErrQuotaExhausted = errors.New("cota da WeatherAPI esgotada")
+
+// ErrHistoryUnavailable indica que o provedor não tem o histórico da data
+// pedida, por exemplo fora do período coberto pelo plano
+var ErrHistoryUnavailable = errors.New("histórico não disponível para a data")
+
+// ErrAirQualityUnavailable indica que o provedor não informou a qualidade do
+// ar da localização
+var ErrAirQualityUnavailable = errors.New("qualidade do ar não disponível")
+
+// historyError converte a recusa da data pelo provedor (400) em ErrHistoryUnavailable
+func historyError(err error) error {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusBadRequest {
+		return fmt.Errorf("%w: %s", ErrHistoryUnavailable, statusErr.Message)
+	}
+	return err
+}
+
+// StatusError representa uma resposta HTTP inesperada de uma API externa
+type StatusError struct {
+	API        string
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return fmt.Sprintf("erro na API %s: status %d", e.API, e.StatusCode)
+}
+
+// isUpstreamFailure indica se o erro representa uma falha da API externa
+// (rede, timeout, 5xx, 429), que deve contar para o circuit breaker.
+// Respostas esperadas como "não encontrado" não são falhas da dependência,
+// nem o cancelamento ou o prazo esgotado do próprio chamador.
+func isUpstreamFailure(ctx context.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || (errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil) {
+		return false
+	}
+
+	var cepNotFound *CEPNotFoundError
+	var locationNotFound *LocationNotFoundError
+	if errors.As(err, &cepNotFound) || errors.As(err, &locationNotFound) {
+		return false
+	}
+	// Cota esgotada e chaves recusadas são tratadas pelo controle de cota e
+	// pelo pool de chaves, não pelo circuit breaker
+	if errors.Is(err, ErrQuotaExhausted) || errors.Is(err, keypool.ErrNoKeys) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError ||
+			statusErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	return true
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+)
+
+// LocationProvider fonte de dados de localização por CEP
+type LocationProvider interface {
+	GetLocationByCEP(ctx context.Context, cep string) (*model.ViaCEPResponse, error)
+	Name() string
+}
+
+// ChainLocationProvider consulta uma lista de provedores em ordem, retornando
+// o primeiro resultado encontrado
+type ChainLocationProvider struct {
+	providers []LocationProvider
+}
+
+// NewChainLocationProvider cria uma cadeia de provedores de localização
+func NewChainLocationProvider(providers ...LocationProvider) *ChainLocationProvider {
+	return &ChainLocationProvider{
+		providers: providers,
+	}
+}
+
+// GetLocationByCEP busca o CEP em cada provedor da cadeia. O CEP só é
+// considerado inexistente quando todos os provedores respondem "não
+// encontrado"; qualquer outro erro é repassado se nenhum provedor responder.
+func (c *ChainLocationProvider) GetLocationByCEP(ctx context.Context, cep string) (*model.ViaCEPResponse, error) {
+	var lastErr error
+	notFound := true
+
+	for _, provider := range c.providers {
+		location, err := provider.GetLocationByCEP(ctx, cep)
+		if err == nil {
+			return location, nil
+		}
+
+		var cepNotFound *CEPNotFoundError
+		if !errors.As(err, &cepNotFound) {
+			notFound = false
+			lastErr = fmt.Errorf("%s: %w", provider.Name(), err)
+		}
+	}
+
+	if notFound {
+		return nil, &CEPNotFoundError{CEP: cep}
+	}
+	return nil, lastErr
+}
+
+// Name retorna o nome da cadeia de provedores
+func (c *ChainLocationProvider) Name() string {
+	name := "chain"
+	for i, provider := range c.providers {
+		if i == 0 {
+			name += ":"
+		} else {
+			name += ","
+		}
+		name += provider.Name()
+	}
+	return name
+}
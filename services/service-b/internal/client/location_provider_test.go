@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+)
+
+// fakeLocationProvider provedor com resposta fixa que conta as chamadas
+type fakeLocationProvider struct {
+	name  string
+	city  string
+	err   error
+	calls int
+}
+
+func (p *fakeLocationProvider) GetLocationByCEP(ctx context.Context, cep string) (*model.ViaCEPResponse, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &model.ViaCEPResponse{CEP: cep, Localidade: p.city}, nil
+}
+
+func (p *fakeLocationProvider) Name() string {
+	return p.name
+}
+
+func TestChainLocationProvider_GetLocationByCEP(t *testing.T) {
+	errUpstream := errors.New("timeout")
+
+	tests := []struct {
+		name         string
+		offlineErr   error
+		opencepErr   error
+		wantCity     string
+		wantNotFound bool
+		wantErr      error
+	}{
+		{
+			name:     "Encontrado no primeiro provedor",
+			wantCity: "Base Offline",
+		},
+		{
+			name:       "Ausente na base offline e encontrado na OpenCEP",
+			offlineErr: &CEPNotFoundError{CEP: "01310100"},
+			wantCity:   "OpenCEP",
+		},
+		{
+			name:         "Nenhum provedor encontra o CEP",
+			offlineErr:   &CEPNotFoundError{CEP: "01310100"},
+			opencepErr:   &CEPNotFoundError{CEP: "01310100"},
+			wantNotFound: true,
+		},
+		{
+			name:         "Não encontrado embrulhado em outro erro",
+			offlineErr:   &CEPNotFoundError{CEP: "01310100"},
+			opencepErr:   errors.Join(errors.New("opencep"), &CEPNotFoundError{CEP: "01310100"}),
+			wantNotFound: true,
+		},
+		{
+			name:       "Falha de um provedor não vira CEP inexistente",
+			offlineErr: errUpstream,
+			opencepErr: &CEPNotFoundError{CEP: "01310100"},
+			wantErr:    errUpstream,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offline := &fakeLocationProvider{name: "offline", city: "Base Offline", err: tt.offlineErr}
+			opencep := &fakeLocationProvider{name: "opencep", city: "OpenCEP", err: tt.opencepErr}
+			chain := NewChainLocationProvider(offline, opencep)
+
+			location, err := chain.GetLocationByCEP(context.Background(), "01310100")
+
+			var notFound *CEPNotFoundError
+			if got := errors.As(err, &notFound); got != tt.wantNotFound {
+				t.Fatalf("GetLocationByCEP() erro = %v, esperava CEP não encontrado = %v", err, tt.wantNotFound)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetLocationByCEP() erro = %v, esperava %v", err, tt.wantErr)
+			}
+			if tt.wantCity == "" {
+				if err == nil {
+					t.Error("GetLocationByCEP() esperava erro")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetLocationByCEP() erro inesperado = %v", err)
+			}
+			if location.Localidade != tt.wantCity {
+				t.Errorf("Localidade = %q, esperava %q", location.Localidade, tt.wantCity)
+			}
+			if tt.offlineErr == nil && opencep.calls != 0 {
+				t.Errorf("chamadas à OpenCEP = %d, esperava 0", opencep.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/cepindex"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+)
+
+// OfflineCEPClient provedor de localização baseado em um índice local de CEPs,
+// sem nenhuma chamada externa
+type OfflineCEPClient struct {
+	index *cepindex.Index
+}
+
+// NewOfflineCEPClient carrega o índice de CEPs gerado pelo comando cepimport
+func NewOfflineCEPClient(indexPath string) (*OfflineCEPClient, error) {
+	index, err := cepindex.Open(indexPath)
+	if err != nil {
+		return nil, err
+	}
+	return &OfflineCEPClient{
+		index: index,
+	}, nil
+}
+
+// GetLocationByCEP busca informações de localização no índice local
+func (c *OfflineCEPClient) GetLocationByCEP(ctx context.Context, cep string) (*model.ViaCEPResponse, error) {
+	record, err := c.index.Lookup(cep)
+	if err != nil {
+		if errors.Is(err, cepindex.ErrNotFound) {
+			return nil, &CEPNotFoundError{CEP: cep}
+		}
+		return nil, err
+	}
+
+	location := &model.ViaCEPResponse{
+		CEP:         record.CEP[:5] + "-" + record.CEP[5:],
+		Logradouro:  record.Logradouro,
+		Complemento: record.Complemento,
+		Bairro:      record.Bairro,
+		Localidade:  record.Localidade,
+		UF:          record.UF,
+		IBGE:        record.IBGE,
+	}
+	if !location.IsValid() {
+		return nil, &CEPNotFoundError{CEP: cep}
+	}
+
+	return location, nil
+}
+
+// Name retorna o nome do provedor
+func (c *OfflineCEPClient) Name() string {
+	return "offline"
+}
+
+// Size retorna o número de CEPs disponíveis no índice
+func (c *OfflineCEPClient) Size() int {
+	return c.index.Len()
+}
@@ -7,6 +7,10 @@ import (
 	"net/http"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lcidral/goExpertOtel/pkg/breaker"
+	"github.com/lcidral/goExpertOtel/pkg/retry"
 	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
 )
 
@@ -14,20 +18,40 @@ import (
 type OpenCEPClient struct {
 	baseURL    string
 	httpClient *http.Client
+	breaker    *breaker.Breaker
 }
 
 // NewOpenCEPClient cria uma nova instância do cliente OpenCEP
-func NewOpenCEPClient(baseURL string, timeout time.Duration) *OpenCEPClient {
+func NewOpenCEPClient(baseURL string, timeout time.Duration, retryPolicy retry.Policy, cb *breaker.Breaker) *OpenCEPClient {
 	return &OpenCEPClient{
 		baseURL: baseURL,
 		httpClient: &http.Client{
-			Timeout: timeout,
+			Timeout:   timeout,
+			Transport: retry.NewTransport(http.DefaultTransport, retryPolicy, "opencep"),
 		},
+		breaker: cb,
 	}
 }
 
-// GetLocationByCEP busca informações de localização pelo CEP
+// GetLocationByCEP busca informações de localização pelo CEP, falhando
+// imediatamente enquanto o circuit breaker da OpenCEP estiver aberto
 func (c *OpenCEPClient) GetLocationByCEP(ctx context.Context, cep string) (*model.ViaCEPResponse, error) {
+	span := trace.SpanFromContext(ctx)
+	generation, err := c.breaker.Allow()
+	if err != nil {
+		span.SetAttributes(c.breaker.Attributes()...)
+		return nil, err
+	}
+
+	location, err := c.fetchLocation(ctx, cep)
+	c.breaker.Record(generation, isUpstreamFailure(ctx, err))
+	span.SetAttributes(c.breaker.Attributes()...)
+
+	return location, err
+}
+
+// fetchLocation executa a consulta na API OpenCEP
+func (c *OpenCEPClient) fetchLocation(ctx context.Context, cep string) (*model.ViaCEPResponse, error) {
 	// Constrói a URL da API (OpenCEP usa formato /v1/{cep}.json)
 	url := fmt.Sprintf("%s/v1/%s.json", c.baseURL, cep)
 
@@ -53,7 +77,7 @@ func (c *OpenCEPClient) GetLocationByCEP(ctx context.Context, cep string) (*mode
 		if resp.StatusCode == http.StatusNotFound {
 			return nil, &CEPNotFoundError{CEP: cep}
 		}
-		return nil, fmt.Errorf("erro na API OpenCEP: status %d", resp.StatusCode)
+		return nil, &StatusError{API: "OpenCEP", StatusCode: resp.StatusCode}
 	}
 
 	// Decodifica a resposta
@@ -75,6 +99,11 @@ func (c *OpenCEPClient) GetLocationByCEP(ctx context.Context, cep string) (*mode
 	return &openCepResp, nil
 }
 
+// Name retorna o nome do provedor
+func (c *OpenCEPClient) Name() string {
+	return "opencep"
+}
+
 // CEPNotFoundError representa erro quando CEP não é encontrado
 type CEPNotFoundError struct {
 	CEP string
@@ -0,0 +1,326 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lcidral/goExpertOtel/pkg/retry"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+)
+
+// ufNames nome dos estados por UF, usado para escolher a cidade certa entre
+// homônimas no geocoding
+var ufNames = map[string]string{
+	"AC": "Acre", "AL": "Alagoas", "AP": "Amapá", "AM": "Amazonas", "BA": "Bahia",
+	"CE": "Ceará", "DF": "Distrito Federal", "ES": "Espírito Santo", "GO": "Goiás",
+	"MA": "Maranhão", "MT": "Mato Grosso", "MS": "Mato Grosso do Sul", "MG": "Minas Gerais",
+	"PA": "Pará", "PB": "Paraíba", "PR": "Paraná", "PE": "Pernambuco", "PI": "Piauí",
+	"RJ": "Rio de Janeiro", "RN": "Rio Grande do Norte", "RS": "Rio Grande do Sul",
+	"RO": "Rondônia", "RR": "Roraima", "SC": "Santa Catarina", "SP": "São Paulo",
+	"SE": "Sergipe", "TO": "Tocantins",
+}
+
+// openMeteoPlace cidade encontrada no geocoding da Open-Meteo
+type openMeteoPlace struct {
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Country   string  `json:"country"`
+	Admin1    string  `json:"admin1"`
+	Timezone  string  `json:"timezone"`
+}
+
+// openMeteoForecast resposta da API de previsão da Open-Meteo
+type openMeteoForecast struct {
+	Timezone string `json:"timezone"`
+	Current  struct {
+		Time                int64   `json:"time"`
+		Temperature         float64 `json:"temperature_2m"`
+		RelativeHumidity    int     `json:"relative_humidity_2m"`
+		ApparentTemperature float64 `json:"apparent_temperature"`
+		WindSpeed           float64 `json:"wind_speed_10m"`
+		IsDay               int     `json:"is_day"`
+	} `json:"current"`
+}
+
+// openMeteoArchive resposta da API de histórico da Open-Meteo; valores ainda
+// não disponíveis vêm como null
+type openMeteoArchive struct {
+	Timezone string `json:"timezone"`
+	Hourly   struct {
+		Time        []int64    `json:"time"`
+		Temperature []*float64 `json:"temperature_2m"`
+	} `json:"hourly"`
+	Daily struct {
+		Time    []int64    `json:"time"`
+		MaxTemp []*float64 `json:"temperature_2m_max"`
+		MinTemp []*float64 `json:"temperature_2m_min"`
+		AvgTemp []*float64 `json:"temperature_2m_mean"`
+	} `json:"daily"`
+}
+
+// openMeteoAirQuality resposta da API de qualidade do ar da Open-Meteo
+// (concentrações em μg/m³); valores indisponíveis vêm como null
+type openMeteoAirQuality struct {
+	Current struct {
+		USAQI *float64 `json:"us_aqi"`
+		CO    *float64 `json:"carbon_monoxide"`
+		NO2   *float64 `json:"nitrogen_dioxide"`
+		O3    *float64 `json:"ozone"`
+		SO2   *float64 `json:"sulphur_dioxide"`
+		PM2_5 *float64 `json:"pm2_5"`
+		PM10  *float64 `json:"pm10"`
+	} `json:"current"`
+}
+
+// OpenMeteoClient cliente para a Open-Meteo, provedor alternativo de clima
+// sem chave de API. As coordenadas de cada cidade são obtidas uma única vez
+// pelo geocoding.
+type OpenMeteoClient struct {
+	forecastURL   string
+	geocodingURL  string
+	archiveURL    string
+	airQualityURL string
+	httpClient    *http.Client
+
+	places sync.Map // localização -> *openMeteoPlace
+}
+
+// NewOpenMeteoClient cria uma nova instância do cliente Open-Meteo
+func NewOpenMeteoClient(forecastURL, geocodingURL, archiveURL, airQualityURL string, timeout time.Duration, retryPolicy retry.Policy) *OpenMeteoClient {
+	return &OpenMeteoClient{
+		forecastURL:   forecastURL,
+		geocodingURL:  geocodingURL,
+		archiveURL:    archiveURL,
+		airQualityURL: airQualityURL,
+		httpClient: &http.Client{
+			Timeout:   timeout,
+			Transport: retry.NewTransport(http.DefaultTransport, retryPolicy, "openmeteo"),
+		},
+	}
+}
+
+// Name identifica o provedor
+func (c *OpenMeteoClient) Name() string {
+	return "openmeteo"
+}
+
+// GetCurrentWeather busca as condições atuais de uma localização ("Cidade, UF"),
+// no mesmo formato da WeatherAPI
+func (c *OpenMeteoClient) GetCurrentWeather(ctx context.Context, location string) (*model.WeatherAPIResponse, error) {
+	place, err := c.geocode(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Add("latitude", fmt.Sprintf("%.4f", place.Latitude))
+	params.Add("longitude", fmt.Sprintf("%.4f", place.Longitude))
+	params.Add("current", "temperature_2m,relative_humidity_2m,apparent_temperature,wind_speed_10m,is_day")
+	params.Add("timezone", "auto")
+	params.Add("timeformat", "unixtime")
+
+	var forecast openMeteoForecast
+	if err := c.getJSON(ctx, c.forecastURL+"/v1/forecast?"+params.Encode(), &forecast); err != nil {
+		return nil, err
+	}
+
+	weather := &model.WeatherAPIResponse{Provider: c.Name()}
+	weather.Location.Name = place.Name
+	weather.Location.Region = place.Admin1
+	weather.Location.Country = place.Country
+	weather.Location.Lat = place.Latitude
+	weather.Location.Lon = place.Longitude
+	weather.Location.TzID = forecast.Timezone
+	weather.Current.LastUpdatedEpoch = forecast.Current.Time
+	weather.Current.LastUpdated = time.Unix(forecast.Current.Time, 0).UTC().Format("2006-01-02 15:04")
+	weather.Current.TempC = forecast.Current.Temperature
+	weather.Current.TempF = forecast.Current.Temperature*1.8 + 32
+	weather.Current.FeelslikeC = forecast.Current.ApparentTemperature
+	weather.Current.FeelslikeF = forecast.Current.ApparentTemperature*1.8 + 32
+	weather.Current.Humidity = forecast.Current.RelativeHumidity
+	weather.Current.WindKph = forecast.Current.WindSpeed
+	weather.Current.IsDay = forecast.Current.IsDay
+	return weather, nil
+}
+
+// GetHistory busca o histórico horário e diário de uma data para uma
+// localização, no mesmo formato da WeatherAPI. O arquivo da Open-Meteo é
+// publicado com alguns dias de atraso; datas ainda sem dados resultam em
+// ErrHistoryUnavailable.
+func (c *OpenMeteoClient) GetHistory(ctx context.Context, location string, date time.Time) (*model.WeatherAPIHistoryResponse, error) {
+	place, err := c.geocode(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	day := date.Format("2006-01-02")
+	params := url.Values{}
+	params.Add("latitude", fmt.Sprintf("%.4f", place.Latitude))
+	params.Add("longitude", fmt.Sprintf("%.4f", place.Longitude))
+	params.Add("start_date", day)
+	params.Add("end_date", day)
+	params.Add("hourly", "temperature_2m")
+	params.Add("daily", "temperature_2m_max,temperature_2m_min,temperature_2m_mean")
+	params.Add("timezone", "auto")
+	params.Add("timeformat", "unixtime")
+
+	var archive openMeteoArchive
+	if err := c.getJSON(ctx, c.archiveURL+"/v1/archive?"+params.Encode(), &archive); err != nil {
+		return nil, historyError(err)
+	}
+	daily := archive.Daily
+	if len(daily.Time) == 0 || len(daily.MaxTemp) == 0 || daily.MaxTemp[0] == nil ||
+		len(daily.MinTemp) == 0 || daily.MinTemp[0] == nil {
+		return nil, fmt.Errorf("%w: %s sem dados na Open-Meteo", ErrHistoryUnavailable, day)
+	}
+
+	forecastDay := model.ForecastDay{Date: day, DateEpoch: daily.Time[0]}
+	forecastDay.Day.MaxtempC = *daily.MaxTemp[0]
+	forecastDay.Day.MaxtempF = *daily.MaxTemp[0]*1.8 + 32
+	forecastDay.Day.MintempC = *daily.MinTemp[0]
+	forecastDay.Day.MintempF = *daily.MinTemp[0]*1.8 + 32
+	if len(daily.AvgTemp) > 0 && daily.AvgTemp[0] != nil {
+		forecastDay.Day.AvgtempC = *daily.AvgTemp[0]
+	}
+	for i, epoch := range archive.Hourly.Time {
+		if i >= len(archive.Hourly.Temperature) || archive.Hourly.Temperature[i] == nil {
+			continue
+		}
+		temp := *archive.Hourly.Temperature[i]
+		forecastDay.Hour = append(forecastDay.Hour, model.ForecastHour{
+			TimeEpoch: epoch,
+			TempC:     temp,
+			TempF:     temp*1.8 + 32,
+		})
+	}
+
+	history := &model.WeatherAPIHistoryResponse{Provider: c.Name()}
+	history.Location.Name = place.Name
+	history.Location.Region = place.Admin1
+	history.Location.Country = place.Country
+	history.Location.Lat = place.Latitude
+	history.Location.Lon = place.Longitude
+	history.Location.TzID = archive.Timezone
+	history.Forecast.Forecastday = []model.ForecastDay{forecastDay}
+	return history, nil
+}
+
+// GetAirQuality busca a qualidade do ar atual de uma localização, no mesmo
+// formato da WeatherAPI. O índice US EPA (1 a 6) é derivado do US AQI.
+func (c *OpenMeteoClient) GetAirQuality(ctx context.Context, location string) (*model.AirQuality, error) {
+	place, err := c.geocode(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Add("latitude", fmt.Sprintf("%.4f", place.Latitude))
+	params.Add("longitude", fmt.Sprintf("%.4f", place.Longitude))
+	params.Add("current", "us_aqi,carbon_monoxide,nitrogen_dioxide,ozone,sulphur_dioxide,pm2_5,pm10")
+
+	var resp openMeteoAirQuality
+	if err := c.getJSON(ctx, c.airQualityURL+"/v1/air-quality?"+params.Encode(), &resp); err != nil {
+		return nil, err
+	}
+	current := resp.Current
+	if current.USAQI == nil {
+		return nil, fmt.Errorf("%w: Open-Meteo sem dados para %s", ErrAirQualityUnavailable, location)
+	}
+
+	value := func(v *float64) float64 {
+		if v == nil {
+			return 0
+		}
+		return *v
+	}
+	return &model.AirQuality{
+		CO:         value(current.CO),
+		NO2:        value(current.NO2),
+		O3:         value(current.O3),
+		SO2:        value(current.SO2),
+		PM2_5:      value(current.PM2_5),
+		PM10:       value(current.PM10),
+		USEPAIndex: usEPAIndex(*current.USAQI),
+		Provider:   c.Name(),
+	}, nil
+}
+
+// usEPAIndex converte o US AQI (0 a 500) na faixa do índice US EPA (1 a 6)
+func usEPAIndex(aqi float64) int {
+	limits := []float64{50, 100, 150, 200, 300}
+	for i, limit := range limits {
+		if aqi <= limit {
+			return i + 1
+		}
+	}
+	return 6
+}
+
+// geocode obtém as coordenadas da cidade, preferindo a do estado informado
+func (c *OpenMeteoClient) geocode(ctx context.Context, location string) (*openMeteoPlace, error) {
+	if place, ok := c.places.Load(location); ok {
+		return place.(*openMeteoPlace), nil
+	}
+
+	city, uf, _ := strings.Cut(location, ", ")
+	params := url.Values{}
+	params.Add("name", city)
+	params.Add("count", "10")
+	params.Add("language", "pt")
+	params.Add("countryCode", "BR")
+
+	var result struct {
+		Results []openMeteoPlace `json:"results"`
+	}
+	if err := c.getJSON(ctx, c.geocodingURL+"/v1/search?"+params.Encode(), &result); err != nil {
+		return nil, err
+	}
+	if len(result.Results) == 0 {
+		return nil, &LocationNotFoundError{Location: location, Message: "cidade não encontrada na Open-Meteo"}
+	}
+
+	place := &result.Results[0]
+	for i := range result.Results {
+		if admin1 := result.Results[i].Admin1; admin1 != "" && admin1 == ufNames[uf] {
+			place = &result.Results[i]
+			break
+		}
+	}
+	c.places.Store(location, place)
+	return place, nil
+}
+
+// getJSON executa a consulta e decodifica a resposta
+func (c *OpenMeteoClient) getJSON(ctx context.Context, fullURL string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "goExpertOtel-service-b/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("erro ao executar requisição: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{
+			API:        "Open-Meteo",
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("erro na Open-Meteo: status %d", resp.StatusCode),
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("erro ao decodificar resposta: %w", err)
+	}
+	return nil
+}
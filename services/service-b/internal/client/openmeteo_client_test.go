@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lcidral/goExpertOtel/pkg/retry"
+)
+
+// newOpenMeteoServer simula o geocoding e a previsão da Open-Meteo
+func newOpenMeteoServer(t *testing.T, geocoding string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/search", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("name"); got != "São Paulo" {
+			t.Errorf("name = %q, esperava %q", got, "São Paulo")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(geocoding))
+	})
+	mux.HandleFunc("/v1/forecast", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("latitude"); got != "-23.5475" {
+			t.Errorf("latitude = %q, esperava %q", got, "-23.5475")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"timezone": "America/Sao_Paulo",
+			"current": {
+				"time": 1710504000,
+				"temperature_2m": 25,
+				"relative_humidity_2m": 60,
+				"apparent_temperature": 27,
+				"wind_speed_10m": 12.5,
+				"is_day": 1
+			}
+		}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOpenMeteoClient_GetCurrentWeather(t *testing.T) {
+	// Duas cidades com o mesmo nome: a do estado informado deve ser escolhida
+	geocoding := `{"results": [
+		{"name": "São Paulo", "latitude": -9.0, "longitude": -36.0, "country": "Brasil", "admin1": "Alagoas"},
+		{"name": "São Paulo", "latitude": -23.5475, "longitude": -46.6361, "country": "Brasil", "admin1": "São Paulo"}
+	]}`
+	server := newOpenMeteoServer(t, geocoding)
+	c := NewOpenMeteoClient(server.URL, server.URL, server.URL, server.URL, 5*time.Second, retry.Policy{MaxAttempts: 1})
+
+	weather, err := c.GetCurrentWeather(context.Background(), "São Paulo, SP")
+	if err != nil {
+		t.Fatalf("GetCurrentWeather() erro inesperado = %v", err)
+	}
+
+	if weather.Provider != "openmeteo" {
+		t.Errorf("Provider = %q, esperava %q", weather.Provider, "openmeteo")
+	}
+	if weather.Location.Name != "São Paulo" || weather.Location.Region != "São Paulo" {
+		t.Errorf("Location = %q/%q, esperava São Paulo/São Paulo", weather.Location.Name, weather.Location.Region)
+	}
+	if weather.Location.TzID != "America/Sao_Paulo" {
+		t.Errorf("TzID = %q, esperava %q", weather.Location.TzID, "America/Sao_Paulo")
+	}
+	if weather.Current.TempC != 25 || weather.Current.TempF != 77 {
+		t.Errorf("TempC/TempF = %v/%v, esperava 25/77", weather.Current.TempC, weather.Current.TempF)
+	}
+	if weather.Current.FeelslikeC != 27 {
+		t.Errorf("FeelslikeC = %v, esperava 27", weather.Current.FeelslikeC)
+	}
+	if weather.Current.Humidity != 60 {
+		t.Errorf("Humidity = %d, esperava 60", weather.Current.Humidity)
+	}
+	if weather.Current.WindKph != 12.5 {
+		t.Errorf("WindKph = %v, esperava 12.5", weather.Current.WindKph)
+	}
+	if weather.Current.LastUpdatedEpoch != 1710504000 || weather.Current.LastUpdated != "2024-03-15 12:00" {
+		t.Errorf("LastUpdated = %d/%q, esperava 1710504000/2024-03-15 12:00",
+			weather.Current.LastUpdatedEpoch, weather.Current.LastUpdated)
+	}
+}
+
+func TestOpenMeteoClient_LocationNotFound(t *testing.T) {
+	server := newOpenMeteoServer(t, `{"results": []}`)
+	c := NewOpenMeteoClient(server.URL, server.URL, server.URL, server.URL, 5*time.Second, retry.Policy{MaxAttempts: 1})
+
+	_, err := c.GetCurrentWeather(context.Background(), "São Paulo, SP")
+
+	var notFound *LocationNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("GetCurrentWeather() erro = %v, esperava LocationNotFoundError", err)
+	}
+	if notFound.Location != "São Paulo, SP" {
+		t.Errorf("Location = %q, esperava %q", notFound.Location, "São Paulo, SP")
+	}
+}
@@ -3,50 +3,196 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lcidral/goExpertOtel/pkg/breaker"
+	"github.com/lcidral/goExpertOtel/pkg/retry"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/keypool"
 	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/quota"
+)
+
+// Códigos de erro da WeatherAPI
+const (
+	weatherAPILocationNotFound = 1006 // nenhuma localização encontrada para q
+	weatherAPIQuotaExceeded    = 2007 // cota mensal esgotada
 )
 
-// WeatherClient cliente para a WeatherAPI
+// WeatherClient cliente para a WeatherAPI. A chave é obtida do pool a cada
+// requisição, de modo que a rotação das chaves (keypool.Pool.Replace) vale
+// para as próximas requisições sem afetar as que estão em andamento.
 type WeatherClient struct {
 	baseURL    string
-	apiKey     string
+	keys       *keypool.Pool
 	httpClient *http.Client
+	breaker    *breaker.Breaker
 }
 
-// NewWeatherClient cria uma nova instância do cliente WeatherAPI
-func NewWeatherClient(baseURL, apiKey string, timeout time.Duration) *WeatherClient {
+// NewWeatherClient cria uma nova instância do cliente WeatherAPI, usando as
+// chaves de keys. Quando tracker é informado, cada requisição (incluindo
+// novas tentativas) é contada na cota.
+func NewWeatherClient(baseURL string, keys *keypool.Pool, timeout time.Duration, retryPolicy retry.Policy, cb *breaker.Breaker, tracker *quota.Tracker) *WeatherClient {
+	var transport http.RoundTripper = http.DefaultTransport
+	if tracker != nil {
+		transport = tracker.Transport(transport)
+	}
 	return &WeatherClient{
 		baseURL: baseURL,
-		apiKey:  apiKey,
+		keys:    keys,
 		httpClient: &http.Client{
-			Timeout: timeout,
+			Timeout:   timeout,
+			Transport: retry.NewTransport(transport, retryPolicy, "weatherapi"),
 		},
+		breaker: cb,
 	}
 }
 
-// GetCurrentWeather busca informações meteorológicas atuais para uma localização
+// Name identifica o provedor
+func (c *WeatherClient) Name() string {
+	return "weatherapi"
+}
+
+// GetCurrentWeather busca informações meteorológicas atuais para uma
+// localização, falhando imediatamente enquanto o circuit breaker da
+// WeatherAPI estiver aberto
 func (c *WeatherClient) GetCurrentWeather(ctx context.Context, location string) (*model.WeatherAPIResponse, error) {
-	// Constrói a URL da API
-	endpoint := fmt.Sprintf("%s/current.json", c.baseURL)
-	
-	// Cria os parâmetros da query
 	params := url.Values{}
-	params.Add("key", c.apiKey)
 	params.Add("q", location)
 	params.Add("aqi", "no") // Não precisamos de dados de qualidade do ar
 
+	var weatherResp model.WeatherAPIResponse
+	if err := c.get(ctx, "current.json", params, location, &weatherResp); err != nil {
+		return nil, err
+	}
+	weatherResp.Provider = c.Name()
+	return &weatherResp, nil
+}
+
+// GetForecast busca a previsão horária e diária de days dias para uma
+// localização, com as mesmas proteções de GetCurrentWeather
+func (c *WeatherClient) GetForecast(ctx context.Context, location string, days int) (*model.WeatherAPIForecastResponse, error) {
+	params := url.Values{}
+	params.Add("q", location)
+	params.Add("days", strconv.Itoa(days))
+	params.Add("aqi", "no")
+	params.Add("alerts", "no")
+
+	var forecast model.WeatherAPIForecastResponse
+	if err := c.get(ctx, "forecast.json", params, location, &forecast); err != nil {
+		return nil, err
+	}
+	return &forecast, nil
+}
+
+// GetAirQuality busca a qualidade do ar atual de uma localização (current.json
+// com aqi=yes), com as mesmas proteções de GetCurrentWeather. É uma consulta
+// separada do clima para que o GetCurrentWeather continue leve e a
+// qualidade do ar tenha TTL próprio no cache.
+func (c *WeatherClient) GetAirQuality(ctx context.Context, location string) (*model.AirQuality, error) {
+	params := url.Values{}
+	params.Add("q", location)
+	params.Add("aqi", "yes")
+
+	var resp model.WeatherAPIAirQualityResponse
+	if err := c.get(ctx, "current.json", params, location, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Current.AirQuality == nil {
+		return nil, fmt.Errorf("%w: WeatherAPI não informou air_quality para %s", ErrAirQualityUnavailable, location)
+	}
+	airQuality := resp.Current.AirQuality
+	airQuality.Provider = c.Name()
+	return airQuality, nil
+}
+
+// GetHistory busca o histórico horário e diário de uma data para uma
+// localização, com as mesmas proteções de GetCurrentWeather. Datas fora do
+// período coberto pelo plano resultam em ErrHistoryUnavailable.
+func (c *WeatherClient) GetHistory(ctx context.Context, location string, date time.Time) (*model.WeatherAPIHistoryResponse, error) {
+	params := url.Values{}
+	params.Add("q", location)
+	params.Add("dt", date.Format("2006-01-02"))
+
+	var history model.WeatherAPIHistoryResponse
+	if err := c.get(ctx, "history.json", params, location, &history); err != nil {
+		return nil, historyError(err)
+	}
+	history.Provider = c.Name()
+	return &history, nil
+}
+
+// validResponse respostas da WeatherAPI que sabem validar os dados essenciais
+type validResponse interface {
+	IsValid() bool
+}
+
+// get executa a consulta ao endpoint da WeatherAPI, falhando imediatamente
+// enquanto o circuit breaker estiver aberto
+func (c *WeatherClient) get(ctx context.Context, endpoint string, params url.Values, location string, target validResponse) error {
+	span := trace.SpanFromContext(ctx)
+	generation, err := c.breaker.Allow()
+	if err != nil {
+		span.SetAttributes(c.breaker.Attributes()...)
+		return err
+	}
+
+	err = c.fetch(ctx, endpoint, params, location, target)
+	c.breaker.Record(generation, isUpstreamFailure(ctx, err))
+	span.SetAttributes(c.breaker.Attributes()...)
+
+	return err
+}
+
+// fetch executa a consulta na WeatherAPI. Se a chave usada for recusada
+// (401/403), ela entra em quarentena e a consulta é repetida com a próxima
+// chave disponível.
+func (c *WeatherClient) fetch(ctx context.Context, endpoint string, params url.Values, location string, target validResponse) error {
+	span := trace.SpanFromContext(ctx)
+	lastErr := keypool.ErrNoKeys
+	for i := 0; i < c.keys.Size(); i++ {
+		key, err := c.keys.Acquire()
+		if err != nil {
+			break
+		}
+		span.SetAttributes(attribute.String("weatherapi.key_id", key.ID()))
+
+		statusCode, err := c.fetchWithKey(ctx, endpoint, params, location, key, target)
+		c.keys.Report(ctx, key, statusCode)
+		if statusCode != http.StatusUnauthorized && statusCode != http.StatusForbidden {
+			return err
+		}
+		lastErr = err
+	}
+	return lastErr
+}
+
+// fetchWithKey consulta a WeatherAPI com a chave informada, decodificando a
+// resposta em target e retornando o status HTTP (zero em caso de erro de rede)
+func (c *WeatherClient) fetchWithKey(ctx context.Context, endpoint string, params url.Values, location string, key keypool.Key, target validResponse) (int, error) {
+	// Constrói a URL da API
+	endpointURL := fmt.Sprintf("%s/%s", c.baseURL, endpoint)
+
+	// Cria os parâmetros da query, com a chave desta tentativa
+	query := url.Values{"key": {key.Value()}}
+	for name, values := range params {
+		query[name] = values
+	}
+
 	// URL completa
-	fullURL := fmt.Sprintf("%s?%s", endpoint, params.Encode())
+	fullURL := fmt.Sprintf("%s?%s", endpointURL, query.Encode())
 
 	// Cria a requisição HTTP
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao criar requisição: %w", err)
+		return 0, fmt.Errorf("erro ao criar requisição: %w", err)
 	}
 
 	// Define headers
@@ -56,7 +202,12 @@ func (c *WeatherClient) GetCurrentWeather(ctx context.Context, location string)
 	// Executa a requisição
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao executar requisição: %w", err)
+		// A URL da requisição contém a chave e não pode aparecer no erro
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = endpointURL
+		}
+		return 0, fmt.Errorf("erro ao executar requisição: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -64,40 +215,50 @@ func (c *WeatherClient) GetCurrentWeather(ctx context.Context, location string)
 	switch resp.StatusCode {
 	case http.StatusOK:
 		// Sucesso - decodifica resposta normal
-		var weatherResp model.WeatherAPIResponse
-		if err := json.NewDecoder(resp.Body).Decode(&weatherResp); err != nil {
-			return nil, fmt.Errorf("erro ao decodificar resposta: %w", err)
+		if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+			return resp.StatusCode, fmt.Errorf("erro ao decodificar resposta: %w", err)
 		}
 
 		// Valida se a resposta contém dados essenciais
-		if !weatherResp.IsValid() {
-			return nil, fmt.Errorf("resposta inválida da WeatherAPI para localização %s", location)
+		if !target.IsValid() {
+			return resp.StatusCode, fmt.Errorf("resposta inválida da WeatherAPI para localização %s", location)
 		}
 
-		return &weatherResp, nil
+		return resp.StatusCode, nil
 
 	case http.StatusBadRequest:
-		// Erro 400 - localização não encontrada ou inválida
+		// Erro 400 - localização não encontrada ou parâmetro recusado (ex.: data do histórico)
 		var errorResp model.WeatherAPIError
 		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
-			return nil, &LocationNotFoundError{Location: location}
+			return resp.StatusCode, &LocationNotFoundError{Location: location}
+		}
+		if code := errorResp.GetCode(); code != 0 && code != weatherAPILocationNotFound {
+			return resp.StatusCode, &StatusError{API: "WeatherAPI", StatusCode: resp.StatusCode, Message: errorResp.GetMessage()}
 		}
-		return nil, &LocationNotFoundError{
+		return resp.StatusCode, &LocationNotFoundError{
 			Location: location,
 			Message:  errorResp.GetMessage(),
 		}
 
 	case http.StatusUnauthorized:
 		// Erro 401 - API key inválida
-		return nil, fmt.Errorf("API key inválida para WeatherAPI")
+		return resp.StatusCode, &StatusError{API: "WeatherAPI", StatusCode: resp.StatusCode, Message: "API key inválida para WeatherAPI"}
 
 	case http.StatusForbidden:
 		// Erro 403 - quota excedida ou acesso negado
-		return nil, fmt.Errorf("quota excedida ou acesso negado na WeatherAPI")
+		var errorResp model.WeatherAPIError
+		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err == nil && errorResp.GetCode() == weatherAPIQuotaExceeded {
+			return resp.StatusCode, fmt.Errorf("%w: %s", ErrQuotaExhausted, errorResp.GetMessage())
+		}
+		return resp.StatusCode, &StatusError{API: "WeatherAPI", StatusCode: resp.StatusCode, Message: "quota excedida ou acesso negado na WeatherAPI"}
 
 	default:
 		// Outros erros
-		return nil, fmt.Errorf("erro na WeatherAPI: status %d", resp.StatusCode)
+		return resp.StatusCode, &StatusError{
+			API:        "WeatherAPI",
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("erro na WeatherAPI: status %d", resp.StatusCode),
+		}
 	}
 }
 
@@ -0,0 +1,194 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lcidral/goExpertOtel/pkg/telemetry"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/quota"
+)
+
+// WeatherProvider fonte de dados meteorológicos por localização ("Cidade, UF")
+type WeatherProvider interface {
+	GetCurrentWeather(ctx context.Context, location string) (*model.WeatherAPIResponse, error)
+	Name() string
+}
+
+// ForecastProvider fonte de previsão do tempo por localização ("Cidade, UF")
+type ForecastProvider interface {
+	GetForecast(ctx context.Context, location string, days int) (*model.WeatherAPIForecastResponse, error)
+}
+
+// HistoryProvider fonte do histórico meteorológico de uma data por
+// localização ("Cidade, UF")
+type HistoryProvider interface {
+	GetHistory(ctx context.Context, location string, date time.Time) (*model.WeatherAPIHistoryResponse, error)
+	Name() string
+}
+
+// AirQualityProvider fonte da qualidade do ar atual por localização ("Cidade, UF")
+type AirQualityProvider interface {
+	GetAirQuality(ctx context.Context, location string) (*model.AirQuality, error)
+	Name() string
+}
+
+var throttledCounter, _ = telemetry.Meter().Int64Counter("quota.throttled",
+	metric.WithDescription("Weather lookups not sent to WeatherAPI because its budget was reached, by action"),
+)
+
+// BudgetWeatherProvider consulta o provedor principal enquanto houver
+// orçamento de chamadas. Ao atingir o limite configurado, ou quando a API
+// informa a cota esgotada, as consultas vão para o provedor alternativo ou,
+// sem ele, falham com ErrQuotaExhausted para que o serviço responda
+// somente com dados em cache.
+type BudgetWeatherProvider struct {
+	primary  WeatherProvider
+	fallback WeatherProvider // nil: somente cache
+	tracker  *quota.Tracker
+}
+
+// NewBudgetWeatherProvider cria o provedor controlado pela cota; fallback pode ser nil
+func NewBudgetWeatherProvider(primary, fallback WeatherProvider, tracker *quota.Tracker) *BudgetWeatherProvider {
+	return &BudgetWeatherProvider{
+		primary:  primary,
+		fallback: fallback,
+		tracker:  tracker,
+	}
+}
+
+// GetCurrentWeather busca o clima no provedor principal ou, sem orçamento, no alternativo
+func (p *BudgetWeatherProvider) GetCurrentWeather(ctx context.Context, location string) (*model.WeatherAPIResponse, error) {
+	if !p.tracker.Throttled() {
+		weather, err := p.primary.GetCurrentWeather(ctx, location)
+		if !errors.Is(err, ErrQuotaExhausted) {
+			return weather, err
+		}
+		log.Printf("Cota da %s esgotada: %v", p.primary.Name(), err)
+		p.tracker.MarkExhausted()
+	}
+
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Bool("quota.throttled", true))
+	if p.fallback == nil {
+		span.SetAttributes(attribute.String("quota.action", "cache_only"))
+		throttledCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("quota.action", "cache_only")))
+		return nil, ErrQuotaExhausted
+	}
+
+	span.SetAttributes(
+		attribute.String("quota.action", "fallback"),
+		attribute.String("weather.provider", p.fallback.Name()),
+	)
+	throttledCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("quota.action", "fallback")))
+	return p.fallback.GetCurrentWeather(ctx, location)
+}
+
+// GetForecast busca a previsão no provedor principal enquanto houver
+// orçamento; sem ele, a previsão vem somente do cache, pois o provedor
+// alternativo não oferece previsão
+func (p *BudgetWeatherProvider) GetForecast(ctx context.Context, location string, days int) (*model.WeatherAPIForecastResponse, error) {
+	forecaster, ok := p.primary.(ForecastProvider)
+	if !ok {
+		return nil, fmt.Errorf("provedor %s não oferece previsão", p.primary.Name())
+	}
+	if !p.tracker.Throttled() {
+		forecast, err := forecaster.GetForecast(ctx, location, days)
+		if !errors.Is(err, ErrQuotaExhausted) {
+			return forecast, err
+		}
+		log.Printf("Cota da %s esgotada: %v", p.primary.Name(), err)
+		p.tracker.MarkExhausted()
+	}
+
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(
+		attribute.Bool("quota.throttled", true),
+		attribute.String("quota.action", "cache_only"),
+	)
+	throttledCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("quota.action", "cache_only")))
+	return nil, ErrQuotaExhausted
+}
+
+// GetHistory busca o histórico no provedor principal enquanto houver
+// orçamento; sem ele, no provedor alternativo quando este oferece histórico,
+// ou somente no cache
+func (p *BudgetWeatherProvider) GetHistory(ctx context.Context, location string, date time.Time) (*model.WeatherAPIHistoryResponse, error) {
+	historian, ok := p.primary.(HistoryProvider)
+	if !ok {
+		return nil, fmt.Errorf("provedor %s não oferece histórico", p.primary.Name())
+	}
+	if !p.tracker.Throttled() {
+		history, err := historian.GetHistory(ctx, location, date)
+		if !errors.Is(err, ErrQuotaExhausted) {
+			return history, err
+		}
+		log.Printf("Cota da %s esgotada: %v", p.primary.Name(), err)
+		p.tracker.MarkExhausted()
+	}
+
+	action := "cache_only"
+	fallback, hasFallback := p.fallback.(HistoryProvider)
+	if hasFallback {
+		action = "fallback"
+	}
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(
+		attribute.Bool("quota.throttled", true),
+		attribute.String("quota.action", action),
+	)
+	throttledCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("quota.action", action)))
+	if !hasFallback {
+		return nil, ErrQuotaExhausted
+	}
+	return fallback.GetHistory(ctx, location, date)
+}
+
+// GetAirQuality busca a qualidade do ar no provedor principal enquanto
+// houver orçamento; sem ele, no provedor alternativo quando este oferece
+// qualidade do ar, ou somente no cache
+func (p *BudgetWeatherProvider) GetAirQuality(ctx context.Context, location string) (*model.AirQuality, error) {
+	provider, ok := p.primary.(AirQualityProvider)
+	if !ok {
+		return nil, fmt.Errorf("provedor %s não oferece qualidade do ar", p.primary.Name())
+	}
+	if !p.tracker.Throttled() {
+		airQuality, err := provider.GetAirQuality(ctx, location)
+		if !errors.Is(err, ErrQuotaExhausted) {
+			return airQuality, err
+		}
+		log.Printf("Cota da %s esgotada: %v", p.primary.Name(), err)
+		p.tracker.MarkExhausted()
+	}
+
+	action := "cache_only"
+	fallback, hasFallback := p.fallback.(AirQualityProvider)
+	if hasFallback {
+		action = "fallback"
+	}
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(
+		attribute.Bool("quota.throttled", true),
+		attribute.String("quota.action", action),
+	)
+	throttledCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("quota.action", action)))
+	if !hasFallback {
+		return nil, ErrQuotaExhausted
+	}
+	return fallback.GetAirQuality(ctx, location)
+}
+
+// Name retorna o nome do provedor principal e do alternativo
+func (p *BudgetWeatherProvider) Name() string {
+	if p.fallback == nil {
+		return p.primary.Name()
+	}
+	return p.primary.Name() + "," + p.fallback.Name()
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/quota"
+)
+
+// fakeWeatherProvider provedor com resposta fixa que conta as chamadas
+type fakeWeatherProvider struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (p *fakeWeatherProvider) GetCurrentWeather(ctx context.Context, location string) (*model.WeatherAPIResponse, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &model.WeatherAPIResponse{Provider: p.name}, nil
+}
+
+func (p *fakeWeatherProvider) Name() string {
+	return p.name
+}
+
+func TestBudgetWeatherProvider_GetCurrentWeather(t *testing.T) {
+	tests := []struct {
+		name             string
+		throttled        bool
+		primaryErr       error
+		withFallback     bool
+		wantProvider     string
+		wantErr          error
+		wantPrimaryCalls int
+		wantThrottled    bool
+	}{
+		{
+			name:             "Dentro do orçamento usa o principal",
+			wantProvider:     "weatherapi",
+			wantPrimaryCalls: 1,
+		},
+		{
+			name:          "Orçamento atingido usa o alternativo",
+			throttled:     true,
+			withFallback:  true,
+			wantProvider:  "openmeteo",
+			wantThrottled: true,
+		},
+		{
+			name:          "Orçamento atingido sem alternativo",
+			throttled:     true,
+			wantErr:       ErrQuotaExhausted,
+			wantThrottled: true,
+		},
+		{
+			name:             "Cota esgotada na API usa o alternativo",
+			primaryErr:       ErrQuotaExhausted,
+			withFallback:     true,
+			wantProvider:     "openmeteo",
+			wantPrimaryCalls: 1,
+			wantThrottled:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker, err := quota.NewTracker("weatherapi", quota.Options{DailyLimit: 1, Threshold: 1})
+			if err != nil {
+				t.Fatalf("NewTracker() erro inesperado = %v", err)
+			}
+			if tt.throttled {
+				tracker.Record()
+			}
+
+			primary := &fakeWeatherProvider{name: "weatherapi", err: tt.primaryErr}
+			fallback := &fakeWeatherProvider{name: "openmeteo"}
+			var provider *BudgetWeatherProvider
+			if tt.withFallback {
+				provider = NewBudgetWeatherProvider(primary, fallback, tracker)
+			} else {
+				provider = NewBudgetWeatherProvider(primary, nil, tracker)
+			}
+
+			weather, err := provider.GetCurrentWeather(context.Background(), "São Paulo, SP")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("GetCurrentWeather() erro = %v, esperava %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("GetCurrentWeather() erro inesperado = %v", err)
+			} else if weather.Provider != tt.wantProvider {
+				t.Errorf("Provider = %q, esperava %q", weather.Provider, tt.wantProvider)
+			}
+
+			if primary.calls != tt.wantPrimaryCalls {
+				t.Errorf("chamadas ao principal = %d, esperava %d", primary.calls, tt.wantPrimaryCalls)
+			}
+			if got := tracker.Throttled(); got != tt.wantThrottled {
+				t.Errorf("Throttled() = %v, esperava %v", got, tt.wantThrottled)
+			}
+		})
+	}
+}
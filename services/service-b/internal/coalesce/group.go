@@ -0,0 +1,119 @@
+package coalesce
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lcidral/goExpertOtel/pkg/telemetry"
+)
+
+// Group agrupa chamadas concorrentes pela mesma chave, de forma que apenas
+// uma chamada à API externa fique em andamento por chave. A primeira chamada
+// (líder) executa a função; as demais aguardam o resultado e registram um
+// span com link para o span do líder.
+type Group struct {
+	name  string
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	done    chan struct{}
+	val     interface{}
+	err     error
+	leader  trace.SpanContext
+	waiters int
+}
+
+// NewGroup cria um grupo de coalescência; name identifica o tipo de chamada nos spans
+func NewGroup(name string) *Group {
+	return &Group{
+		name:  name,
+		calls: make(map[string]*call),
+	}
+}
+
+// Do executa fn uma única vez por chave entre chamadas concorrentes. shared
+// indica que o resultado veio da chamada de outro líder.
+//
+// fn executa com um contexto desvinculado do cancelamento do líder (mas com o
+// mesmo deadline), para que a desconexão de um cliente não falhe os demais
+// que aguardam o mesmo resultado.
+func (g *Group) Do(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (val interface{}, shared bool, err error) {
+	g.mu.Lock()
+	if c, ok := g.calls[key]; ok {
+		c.waiters++
+		g.mu.Unlock()
+		return g.wait(ctx, key, c)
+	}
+
+	c := &call{
+		done:   make(chan struct{}),
+		leader: trace.SpanContextFromContext(ctx),
+	}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	go func() {
+		callCtx := context.WithoutCancel(ctx)
+		if deadline, ok := ctx.Deadline(); ok {
+			var cancel context.CancelFunc
+			callCtx, cancel = context.WithDeadline(callCtx, deadline)
+			defer cancel()
+		}
+
+		c.val, c.err = fn(callCtx)
+
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+
+	select {
+	case <-c.done:
+		g.mu.Lock()
+		waiters := c.waiters
+		g.mu.Unlock()
+		trace.SpanFromContext(ctx).SetAttributes(
+			attribute.Bool("coalesce.leader", true),
+			attribute.Int("coalesce.waiters", waiters),
+		)
+		return c.val, false, c.err
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+}
+
+// wait aguarda o resultado do líder registrando um span ligado ao span dele
+func (g *Group) wait(ctx context.Context, key string, c *call) (interface{}, bool, error) {
+	var links []trace.Link
+	if c.leader.IsValid() {
+		links = append(links, trace.Link{SpanContext: c.leader})
+	}
+
+	_, span := telemetry.StartLinkedSpan(ctx, "coalesce.wait", links,
+		attribute.String("coalesce.group", g.name),
+		attribute.String("coalesce.key", key),
+	)
+	defer span.End()
+
+	select {
+	case <-c.done:
+		if c.err != nil {
+			span.RecordError(c.err)
+			span.SetStatus(codes.Error, "Coalesced call failed")
+		} else {
+			span.SetStatus(codes.Ok, "Coalesced call completed")
+		}
+		return c.val, true, c.err
+	case <-ctx.Done():
+		span.RecordError(ctx.Err())
+		span.SetStatus(codes.Error, "Gave up waiting for coalesced call")
+		return nil, true, ctx.Err()
+	}
+}
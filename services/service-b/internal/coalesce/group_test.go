@@ -0,0 +1,115 @@
+package coalesce
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroup_CoalescesConcurrentCalls(t *testing.T) {
+	g := NewGroup("test")
+
+	var calls int32
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "São Paulo", nil
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	var sharedCount int32
+	results := make([]interface{}, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			val, shared, err := g.Do(context.Background(), "01310100", fn)
+			if err != nil {
+				t.Errorf("Do() erro inesperado = %v", err)
+			}
+			if shared {
+				atomic.AddInt32(&sharedCount, 1)
+			}
+			results[i] = val
+		}(i)
+	}
+
+	// Aguarda todas as chamadas se registrarem antes de liberar o líder
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); {
+		g.mu.Lock()
+		c := g.calls["01310100"]
+		ready := c != nil && c.waiters == n-1
+		g.mu.Unlock()
+		if ready {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("fn executada %d vezes, esperava 1", calls)
+	}
+	if sharedCount != n-1 {
+		t.Errorf("chamadas compartilhadas = %d, esperava %d", sharedCount, n-1)
+	}
+	for i, val := range results {
+		if val != "São Paulo" {
+			t.Errorf("resultado[%d] = %v, esperava São Paulo", i, val)
+		}
+	}
+}
+
+func TestGroup_DifferentKeysRunIndependently(t *testing.T) {
+	g := NewGroup("test")
+
+	var calls int32
+	fn := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, nil
+	}
+
+	g.Do(context.Background(), "a", fn)
+	g.Do(context.Background(), "b", fn)
+	g.Do(context.Background(), "a", fn)
+
+	if calls != 3 {
+		t.Errorf("fn executada %d vezes, esperava 3 (chamadas sequenciais não são coalescidas)", calls)
+	}
+}
+
+func TestGroup_LeaderCancellationDoesNotFailCall(t *testing.T) {
+	g := NewGroup("test")
+
+	release := make(chan struct{})
+	fnErr := make(chan error, 1)
+	fn := func(ctx context.Context) (interface{}, error) {
+		<-release
+		fnErr <- ctx.Err()
+		return "ok", nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, _, err := g.Do(ctx, "key", fn)
+		done <- err
+	}()
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Do() erro = %v, esperava context.Canceled para o líder cancelado", err)
+	}
+
+	close(release)
+	if err := <-fnErr; err != nil {
+		t.Errorf("contexto da função cancelado junto com o líder: %v", err)
+	}
+}
@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lcidral/goExpertOtel/pkg/models"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/cache"
+)
+
+// maxWarmCEPs limits how many CEPs a single warm request may trigger
+const maxWarmCEPs = 100
+
+// Warmer loads the data needed to answer a CEP into the cache
+type Warmer interface {
+	Warm(ctx context.Context, cep string) error
+}
+
+// AdminHandler exposes cache inspection and maintenance endpoints, protected by a static token
+type AdminHandler struct {
+	cache  cache.Cache
+	warmer Warmer
+	token  string
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(cache cache.Cache, warmer Warmer, token string) *AdminHandler {
+	return &AdminHandler{
+		cache:  cache,
+		warmer: warmer,
+		token:  token,
+	}
+}
+
+// Authenticate rejects requests without the admin token, sent as
+// "Authorization: Bearer <token>" or in the X-Admin-Token header
+func (h *AdminHandler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("X-Admin-Token")
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			token = strings.TrimPrefix(auth, "Bearer ")
+		}
+
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			h.respondJSON(w, http.StatusUnauthorized, models.ErrorResponse{Message: "não autorizado"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// keyPrefixes maps the type filter of the keys listing to the cache key prefix
+var keyPrefixes = map[string]string{
+	cache.TypeLocation:   "location:",
+	cache.TypeWeather:    "weather:",
+	cache.TypeForecast:   "forecast:",
+	cache.TypeHistory:    "history:",
+	cache.TypeAirQuality: "airquality:",
+	cache.TypeNegative:   "notfound:",
+}
+
+// ListKeys lists cache keys with their remaining TTL
+// (GET /admin/cache/keys?type=location&prefix=...&limit=100)
+func (h *AdminHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	if itemType := r.URL.Query().Get("type"); itemType != "" {
+		typePrefix, ok := keyPrefixes[itemType]
+		if !ok {
+			h.respondJSON(w, http.StatusBadRequest, models.ErrorResponse{Message: "type deve ser location, weather, forecast, history, airquality ou negative"})
+			return
+		}
+		prefix = typePrefix + prefix
+	}
+
+	limit := 1000
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			h.respondJSON(w, http.StatusBadRequest, models.ErrorResponse{Message: "limit deve ser um inteiro positivo"})
+			return
+		}
+		limit = parsed
+	}
+
+	keys := h.cache.Keys(prefix, limit)
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"prefix": prefix,
+		"count":  len(keys),
+		"keys":   keys,
+	})
+}
+
+// GetEntry returns a single cache entry with its value (GET /admin/cache/entry?key=...)
+func (h *AdminHandler) GetEntry(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		h.respondJSON(w, http.StatusBadRequest, models.ErrorResponse{Message: "key é obrigatório"})
+		return
+	}
+
+	info, found := h.cache.Inspect(key)
+	if !found {
+		h.respondJSON(w, http.StatusNotFound, models.ErrorResponse{Message: "chave não encontrada"})
+		return
+	}
+	h.respondJSON(w, http.StatusOK, info)
+}
+
+// DeleteEntries deletes a single key (?key=...) or every key with a prefix
+// (?prefix=...) (DELETE /admin/cache/entries)
+func (h *AdminHandler) DeleteEntries(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	prefix := r.URL.Query().Get("prefix")
+
+	var deleted int
+	switch {
+	case key != "" && prefix == "":
+		if h.cache.Delete(key) {
+			deleted = 1
+		}
+	case prefix != "" && key == "":
+		deleted = h.cache.DeletePrefix(prefix)
+	default:
+		// An empty prefix would flush everything; that is what /flush is for
+		h.respondJSON(w, http.StatusBadRequest, models.ErrorResponse{Message: "informe key ou prefix"})
+		return
+	}
+
+	log.Printf("Admin: %d chaves removidas do cache (key=%q, prefix=%q)", deleted, key, prefix)
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{"deleted": deleted})
+}
+
+// Flush clears the whole cache (POST /admin/cache/flush)
+func (h *AdminHandler) Flush(w http.ResponseWriter, r *http.Request) {
+	h.cache.Clear()
+	log.Println("Admin: cache limpo")
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{"flushed": true})
+}
+
+// warmRequest is the body of the warm endpoint
+type warmRequest struct {
+	CEPs []string `json:"ceps"`
+}
+
+// warmResult is the outcome of warming one CEP
+type warmResult struct {
+	CEP    string `json:"cep"`
+	Status string `json:"status"` // warmed, not_found, invalid or error
+	Error  string `json:"error,omitempty"`
+}
+
+// Warm loads location and weather for a list of CEPs into the
+// cache (POST /admin/cache/warm with {"ceps": ["01310100", ...]})
+func (h *AdminHandler) Warm(w http.ResponseWriter, r *http.Request) {
+	var req warmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.CEPs) == 0 {
+		h.respondJSON(w, http.StatusBadRequest, models.ErrorResponse{Message: `corpo deve ser {"ceps": [...]}`})
+		return
+	}
+	if len(req.CEPs) > maxWarmCEPs {
+		h.respondJSON(w, http.StatusBadRequest, models.ErrorResponse{Message: "no máximo " + strconv.Itoa(maxWarmCEPs) + " CEPs por requisição"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+
+	results := make([]warmResult, 0, len(req.CEPs))
+	warmed := 0
+	for _, cep := range req.CEPs {
+		result := warmResult{CEP: cep, Status: "warmed"}
+		if err := h.warmer.Warm(ctx, cep); err != nil {
+			var invalid *InvalidCEPError
+			switch {
+			case errors.As(err, &invalid):
+				result.Status = "invalid"
+			case isNotFound(err):
+				result.Status = "not_found"
+			default:
+				result.Status = "error"
+				result.Error = err.Error()
+			}
+		} else {
+			warmed++
+		}
+		results = append(results, result)
+	}
+
+	log.Printf("Admin: %d de %d CEPs aquecidos no cache", warmed, len(req.CEPs))
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"warmed":  warmed,
+		"results": results,
+	})
+}
+
+// respondJSON sends a JSON response
+func (h *AdminHandler) respondJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Erro ao codificar resposta: %v", err)
+	}
+}
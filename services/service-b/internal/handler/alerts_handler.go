@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lcidral/goExpertOtel/pkg/models"
+	"github.com/lcidral/goExpertOtel/pkg/utils"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/alerts"
+)
+
+// AlertsHandler exposes the temperature alert subscriptions and the failed
+// deliveries. Every caller authenticates with an API key and only sees the
+// subscriptions created with it.
+type AlertsHandler struct {
+	store     *alerts.Store
+	validator *utils.CEPValidator
+	apiKeys   []string
+	// allowPrivate accepts webhooks on internal addresses, e.g. in development
+	allowPrivate bool
+}
+
+// ownerKey is the request context key of the authenticated subscription owner
+type ownerKey struct{}
+
+// NewAlertsHandler creates a new alerts handler accepting the given API keys
+func NewAlertsHandler(store *alerts.Store, apiKeys []string) *AlertsHandler {
+	return &AlertsHandler{
+		store:     store,
+		validator: utils.NewCEPValidator(),
+		apiKeys:   apiKeys,
+	}
+}
+
+// SetAllowPrivateWebhooks accepts webhooks on loopback and private addresses
+func (h *AlertsHandler) SetAllowPrivateWebhooks(allow bool) {
+	h.allowPrivate = allow
+}
+
+// Authenticate rejects requests without one of the API keys, sent as
+// "Authorization: Bearer <key>" or in the X-API-Key header, and identifies
+// the caller as the owner of the subscriptions
+func (h *AlertsHandler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("X-API-Key")
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			token = strings.TrimPrefix(auth, "Bearer ")
+		}
+
+		// Compare against every key so the time taken doesn't reveal which one matched
+		matched := false
+		for _, key := range h.apiKeys {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(key)) == 1 {
+				matched = true
+			}
+		}
+		if token == "" || !matched {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="alerts"`)
+			h.respondJSON(w, http.StatusUnauthorized, models.ErrorResponse{Message: "não autorizado"})
+			return
+		}
+		ctx := context.WithValue(r.Context(), ownerKey{}, alerts.OwnerID(token))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// owner returns the subscription owner authenticated by Authenticate
+func owner(r *http.Request) string {
+	owner, _ := r.Context().Value(ownerKey{}).(string)
+	return owner
+}
+
+// Create subscribes a webhook to a temperature threshold of a CEP (POST /alerts).
+// The response carries the secret used to sign the deliveries; it is not shown again.
+func (h *AlertsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.AlertSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondJSON(w, http.StatusBadRequest, models.ErrorResponse{Message: "corpo inválido"})
+		return
+	}
+
+	sub, message := h.validate(req)
+	if message != "" {
+		h.respondJSON(w, http.StatusBadRequest, models.ErrorResponse{Message: message})
+		return
+	}
+
+	sub.Owner = owner(r)
+	created, err := h.store.Create(sub)
+	if errors.Is(err, alerts.ErrLimitReached) {
+		h.respondJSON(w, http.StatusConflict, models.ErrorResponse{Message: "limite de assinaturas atingido"})
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao criar assinatura de alerta: %v", err)
+		h.respondJSON(w, http.StatusInternalServerError, models.ErrorResponse{Message: "Erro interno do servidor"})
+		return
+	}
+
+	trace.SpanFromContext(r.Context()).SetAttributes(
+		attribute.String("alert.subscription_id", created.ID),
+		attribute.String("cep.value", created.CEP),
+	)
+	log.Printf("Alerta %s criado: CEP %s %s %.1f°%s", created.ID, created.CEP, created.Direction, created.Threshold, created.Unit)
+
+	response := subscriptionResponse(created)
+	response.Secret = created.Secret
+	h.respondJSON(w, http.StatusCreated, response)
+}
+
+// validate checks a subscription request, returning the message of the first problem found
+func (h *AlertsHandler) validate(req models.AlertSubscriptionRequest) (alerts.Subscription, string) {
+	cep, err := h.validator.ValidateAndNormalize(req.CEP)
+	if err != nil {
+		return alerts.Subscription{}, models.ErrInvalidZipcode
+	}
+
+	unit := strings.ToUpper(strings.TrimSpace(req.Unit))
+	switch unit {
+	case "C", "F", "K":
+	default:
+		return alerts.Subscription{}, "unit deve ser C, F ou K"
+	}
+
+	if req.Threshold == nil {
+		return alerts.Subscription{}, "threshold é obrigatório"
+	}
+
+	direction := strings.ToLower(strings.TrimSpace(req.Direction))
+	switch direction {
+	case alerts.DirectionAbove, alerts.DirectionBelow:
+	default:
+		return alerts.Subscription{}, "direction deve ser above ou below"
+	}
+
+	webhook, err := url.Parse(req.WebhookURL)
+	if err != nil || (webhook.Scheme != "http" && webhook.Scheme != "https") || webhook.Host == "" {
+		return alerts.Subscription{}, "webhook_url deve ser uma URL http ou https"
+	}
+	if !h.allowPrivate && alerts.CheckHost(webhook.Hostname()) != nil {
+		return alerts.Subscription{}, "webhook_url não pode apontar para um endereço interno"
+	}
+
+	return alerts.Subscription{
+		CEP:        cep,
+		Unit:       unit,
+		Threshold:  *req.Threshold,
+		Direction:  direction,
+		WebhookURL: webhook.String(),
+	}, ""
+}
+
+// List lists the caller's subscriptions, optionally of a single CEP (GET /alerts?cep=...)
+func (h *AlertsHandler) List(w http.ResponseWriter, r *http.Request) {
+	cep := r.URL.Query().Get("cep")
+	if cep != "" {
+		normalizedCEP, err := h.validator.ValidateAndNormalize(cep)
+		if err != nil {
+			h.respondJSON(w, http.StatusUnprocessableEntity, models.ErrorResponse{Message: models.ErrInvalidZipcode})
+			return
+		}
+		cep = normalizedCEP
+	}
+
+	subs := h.store.List(owner(r), cep)
+	response := make([]models.AlertSubscription, 0, len(subs))
+	for _, sub := range subs {
+		response = append(response, subscriptionResponse(sub))
+	}
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"count":         len(response),
+		"subscriptions": response,
+	})
+}
+
+// Delete removes one of the caller's subscriptions (DELETE /alerts/{id})
+func (h *AlertsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	err := h.store.Delete(owner(r), id)
+	if errors.Is(err, alerts.ErrNotFound) {
+		h.respondJSON(w, http.StatusNotFound, models.ErrorResponse{Message: models.ErrAlertNotFound})
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao remover assinatura de alerta %s: %v", id, err)
+		h.respondJSON(w, http.StatusInternalServerError, models.ErrorResponse{Message: "Erro interno do servidor"})
+		return
+	}
+
+	log.Printf("Alerta %s removido", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeadLetters lists the caller's deliveries that failed every attempt (GET /alerts/dead-letters)
+func (h *AlertsHandler) DeadLetters(w http.ResponseWriter, r *http.Request) {
+	letters := h.store.DeadLetters(owner(r))
+	response := make([]models.AlertDeadLetter, 0, len(letters))
+	for _, letter := range letters {
+		response = append(response, models.AlertDeadLetter{
+			Event:      eventResponse(letter.Event),
+			WebhookURL: alerts.RedactURL(letter.WebhookURL),
+			Attempts:   letter.Attempts,
+			LastError:  letter.LastError,
+			FailedAt:   letter.FailedAt.Format(time.RFC3339),
+		})
+	}
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"count":        len(response),
+		"dead_letters": response,
+	})
+}
+
+// subscriptionResponse converts a subscription, without its secret, to the API model
+func subscriptionResponse(sub alerts.Subscription) models.AlertSubscription {
+	response := models.AlertSubscription{
+		ID:         sub.ID,
+		CEP:        sub.CEP,
+		Unit:       sub.Unit,
+		Threshold:  sub.Threshold,
+		Direction:  sub.Direction,
+		WebhookURL: sub.WebhookURL,
+		CreatedAt:  sub.CreatedAt.Format(time.RFC3339),
+		Triggered:  sub.Triggered,
+		LastValue:  sub.LastValue,
+	}
+	if !sub.LastChecked.IsZero() {
+		response.LastChecked = sub.LastChecked.Format(time.RFC3339)
+	}
+	return response
+}
+
+// eventResponse converts a delivered event to the API model
+func eventResponse(event alerts.Event) models.AlertEvent {
+	return models.AlertEvent{
+		ID:             event.ID,
+		SubscriptionID: event.SubscriptionID,
+		CEP:            event.CEP,
+		City:           event.City,
+		Unit:           event.Unit,
+		Threshold:      event.Threshold,
+		Direction:      event.Direction,
+		Temperature:    event.Temperature,
+		TriggeredAt:    event.TriggeredAt.Format(time.RFC3339),
+	}
+}
+
+// respondJSON sends a JSON response
+func (h *AlertsHandler) respondJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Erro ao codificar resposta: %v", err)
+	}
+}
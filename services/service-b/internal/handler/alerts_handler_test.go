@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/lcidral/goExpertOtel/pkg/models"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/alerts"
+)
+
+// newAlertsRouter monta as rotas de /alerts como em main, atrás da autenticação
+func newAlertsRouter(t *testing.T, apiKeys ...string) http.Handler {
+	store, err := alerts.Open("", 0)
+	if err != nil {
+		t.Fatalf("alerts.Open() erro inesperado = %v", err)
+	}
+	h := NewAlertsHandler(store, apiKeys)
+
+	r := chi.NewRouter()
+	r.Route("/alerts", func(r chi.Router) {
+		r.Use(h.Authenticate)
+		r.Post("/", h.Create)
+		r.Get("/", h.List)
+		r.Get("/dead-letters", h.DeadLetters)
+		r.Delete("/{id}", h.Delete)
+	})
+	return r
+}
+
+// alertsRequest envia uma requisição às rotas de /alerts com a chave apiKey
+func alertsRequest(router http.Handler, method, target, apiKey string, body io.Reader) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, body)
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAlertsHandler(t *testing.T) {
+	router := newAlertsRouter(t, "chave-a", "chave-b")
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantError  string
+	}{
+		{"Assinatura válida", `{"cep":"01001-000","unit":"c","threshold":30,"direction":"Above","webhook_url":"https://example.com/hook"}`, http.StatusCreated, ""},
+		{"CEP inválido", `{"cep":"123","unit":"C","threshold":30,"direction":"above","webhook_url":"https://example.com/hook"}`, http.StatusBadRequest, models.ErrInvalidZipcode},
+		{"Unidade inválida", `{"cep":"01001000","unit":"X","threshold":30,"direction":"above","webhook_url":"https://example.com/hook"}`, http.StatusBadRequest, "unit deve ser C, F ou K"},
+		{"Sem limite", `{"cep":"01001000","unit":"C","direction":"above","webhook_url":"https://example.com/hook"}`, http.StatusBadRequest, "threshold é obrigatório"},
+		{"Direção inválida", `{"cep":"01001000","unit":"C","threshold":30,"direction":"up","webhook_url":"https://example.com/hook"}`, http.StatusBadRequest, "direction deve ser above ou below"},
+		{"Webhook inválido", `{"cep":"01001000","unit":"C","threshold":30,"direction":"below","webhook_url":"ftp://example.com"}`, http.StatusBadRequest, "webhook_url deve ser uma URL http ou https"},
+		{"Webhook interno", `{"cep":"01001000","unit":"C","threshold":30,"direction":"below","webhook_url":"http://169.254.169.254/latest"}`, http.StatusBadRequest, "webhook_url não pode apontar para um endereço interno"},
+		{"Webhook em localhost", `{"cep":"01001000","unit":"C","threshold":30,"direction":"below","webhook_url":"http://localhost:8081/admin/cache/flush"}`, http.StatusBadRequest, "webhook_url não pode apontar para um endereço interno"},
+	}
+
+	var created models.AlertSubscription
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := alertsRequest(router, http.MethodPost, "/alerts", "chave-a", strings.NewReader(tt.body))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Create() status = %d, esperava %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantError != "" {
+				var errorResp models.ErrorResponse
+				json.NewDecoder(rec.Body).Decode(&errorResp)
+				if errorResp.Message != tt.wantError {
+					t.Errorf("mensagem = %q, esperava %q", errorResp.Message, tt.wantError)
+				}
+				return
+			}
+
+			if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+				t.Fatalf("erro ao decodificar resposta: %v", err)
+			}
+			if created.CEP != "01001000" || created.Unit != "C" || created.Direction != alerts.DirectionAbove || created.Secret == "" {
+				t.Errorf("assinatura = %+v, esperava CEP normalizado, unidade C, direção above e segredo", created)
+			}
+		})
+	}
+
+	// A listagem não expõe o segredo
+	rec := alertsRequest(router, http.MethodGet, "/alerts?cep=01001000", "chave-a", nil)
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), created.Secret) || !strings.Contains(rec.Body.String(), created.ID) {
+		t.Errorf("List() = %d %s, esperava a assinatura %s sem o segredo", rec.Code, rec.Body.String(), created.ID)
+	}
+
+	// Outra chave não vê nem remove a assinatura
+	rec = alertsRequest(router, http.MethodGet, "/alerts", "chave-b", nil)
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), created.ID) {
+		t.Errorf("List() com outra chave = %d %s, esperava sem a assinatura %s", rec.Code, rec.Body.String(), created.ID)
+	}
+	if rec := alertsRequest(router, http.MethodDelete, "/alerts/"+created.ID, "chave-b", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("Delete() com outra chave status = %d, esperava 404", rec.Code)
+	}
+
+	if rec := alertsRequest(router, http.MethodDelete, "/alerts/"+created.ID, "chave-a", nil); rec.Code != http.StatusNoContent {
+		t.Errorf("Delete() status = %d, esperava 204", rec.Code)
+	}
+	if rec := alertsRequest(router, http.MethodDelete, "/alerts/"+created.ID, "chave-a", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("Delete() repetido status = %d, esperava 404", rec.Code)
+	}
+}
+
+func TestAlertsHandler_Authenticate(t *testing.T) {
+	router := newAlertsRouter(t, "chave-a")
+
+	tests := []struct {
+		name   string
+		apiKey string
+		target string
+	}{
+		{"Sem chave", "", "/alerts"},
+		{"Chave desconhecida", "chave-x", "/alerts"},
+		{"Entregas com falha sem chave", "", "/alerts/dead-letters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := alertsRequest(router, http.MethodGet, tt.target, tt.apiKey, nil)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, esperava 401", rec.Code)
+			}
+		})
+	}
+}
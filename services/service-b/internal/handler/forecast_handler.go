@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/lcidral/goExpertOtel/pkg/models"
+	"github.com/lcidral/goExpertOtel/pkg/telemetry"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/cache"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/client"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+)
+
+// HandleForecast returns the daily min/max and hourly forecast for a CEP.
+// The optional days query parameter (1 to the configured maximum, which is
+// also the default) limits how many days are returned.
+func (h *TemperatureHandler) HandleForecast(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if h.forecaster == nil {
+		h.respondWithError(w, http.StatusNotImplemented, "Previsão do tempo não disponível")
+		return
+	}
+
+	days := h.forecastMaxDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > h.forecastMaxDays {
+			h.respondWithError(w, http.StatusBadRequest, models.ErrInvalidDays)
+			return
+		}
+		days = n
+	}
+
+	ctx, validationSpan, normalizedCEP, ok := h.validateCEP(w, r, chi.URLParam(r, "cep"))
+	if !ok {
+		return
+	}
+	defer validationSpan.End()
+	validationSpan.SetAttributes(attribute.Int("forecast.days", days))
+
+	// Check cache first: the CEP's city and the city's forecast, both fresh
+	ctx, cacheSpan := telemetry.StartSpan(ctx, "cache.lookup",
+		attribute.String("cache.key", "location:"+normalizedCEP),
+		attribute.String("cache.type", "forecast"),
+	)
+	if location, forecast, found := h.cachedForecast(normalizedCEP); found {
+		log.Printf("Cache hit para previsão do CEP %s", normalizedCEP)
+		cacheSpan.SetAttributes(
+			attribute.Bool("cache.hit", true),
+			attribute.String("city.name", location.GetCityName()),
+		)
+		cacheSpan.SetStatus(codes.Ok, "Cache hit")
+		cacheSpan.End()
+		w.Header().Set("X-Cache-Status", "HIT")
+		h.respondWithSuccess(w, h.buildForecast(ctx, location, forecast, days))
+		return
+	}
+	cacheSpan.SetAttributes(attribute.Bool("cache.hit", false))
+	cacheSpan.SetStatus(codes.Ok, "Cache miss")
+	cacheSpan.End()
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	location, staleLocation, err := h.getLocationWithCache(ctxWithTimeout, normalizedCEP)
+	if err != nil {
+		log.Printf("Erro ao buscar localização para CEP %s: %v", normalizedCEP, err)
+		h.respondLookupError(w, err)
+		return
+	}
+	forecast, staleForecast, err := h.getForecastWithCache(ctxWithTimeout, location.GetFullLocation())
+	if err != nil {
+		log.Printf("Erro ao buscar previsão para %s: %v", location.GetFullLocation(), err)
+		h.respondLookupError(w, err)
+		return
+	}
+
+	if stale := mostStale(staleLocation, staleForecast); stale != nil {
+		setStaleHeaders(w, stale)
+	} else {
+		w.Header().Set("X-Cache-Status", "MISS")
+	}
+
+	response := h.buildForecast(ctx, location, forecast, days)
+	h.respondWithSuccess(w, response)
+	log.Printf("Previsão do CEP %s processada com sucesso: %s, %d dias", normalizedCEP, response.City, len(response.Days))
+}
+
+// cachedForecast returns the cached city of a CEP and the city's forecast, when both are fresh
+func (h *TemperatureHandler) cachedForecast(cep string) (*model.ViaCEPResponse, *model.WeatherAPIForecastResponse, bool) {
+	location, found := h.cache.GetLocation(cep)
+	if !found {
+		return nil, nil, false
+	}
+	forecast, found := h.cache.GetForecast(location.GetFullLocation())
+	if !found {
+		return nil, nil, false
+	}
+	return location, forecast, true
+}
+
+// getForecastWithCache gets the forecast with caching
+func (h *TemperatureHandler) getForecastWithCache(ctx context.Context, location string) (*model.WeatherAPIForecastResponse, *staleInfo, error) {
+	ctx, forecastSpan := telemetry.StartSpan(ctx, "weather.forecast.call",
+		attribute.String("location", location),
+		attribute.String("api.name", "weather"),
+		attribute.Int("forecast.days", h.forecastMaxDays),
+	)
+	defer forecastSpan.End()
+
+	// Locations recently reported as nonexistent are answered without calling the API
+	if h.cache.IsWeatherNotFound(location) {
+		log.Printf("Cache negativo para a localização %s", location)
+		forecastSpan.SetAttributes(
+			attribute.Bool("cache.hit", true),
+			attribute.Bool("cache.negative_hit", true),
+		)
+		forecastSpan.SetStatus(codes.Ok, "Location not found (negative cache)")
+		return nil, nil, &client.LocationNotFoundError{Location: location}
+	}
+
+	// Check cache first
+	ctx, cacheSpan := telemetry.StartSpan(ctx, "cache.lookup",
+		attribute.String("cache.key", "forecast:"+location),
+		attribute.String("cache.type", "forecast"),
+	)
+	cachedForecast, freshness, age, found := h.cache.GetForecastEntry(location)
+	if found && freshness != cache.Expired {
+		log.Printf("Cache hit (%s) para previsão de %s", freshness, location)
+		cacheSpan.SetAttributes(
+			attribute.Bool("cache.hit", true),
+			attribute.String("cache.freshness", freshness.String()),
+		)
+		cacheSpan.SetStatus(codes.Ok, "Cache hit")
+		cacheSpan.End()
+
+		forecastSpan.SetAttributes(attribute.Bool("cache.hit", true))
+		forecastSpan.SetStatus(codes.Ok, "Forecast retrieved from cache")
+
+		if freshness == cache.Stale {
+			// Serve the stale entry and refresh it in background
+			h.revalidate(ctx, "forecast:"+location, func(ctx context.Context) error {
+				_, err := h.fetchForecast(ctx, location)
+				return err
+			})
+			stale := &staleInfo{Reason: "revalidating", Age: age}
+			forecastSpan.SetAttributes(staleAttributes(stale)...)
+			return cachedForecast, stale, nil
+		}
+		return cachedForecast, nil, nil
+	}
+	cacheSpan.SetAttributes(attribute.Bool("cache.hit", false))
+	cacheSpan.SetStatus(codes.Ok, "Cache miss")
+	cacheSpan.End()
+
+	// Not in cache, fetch from API
+	forecast, err := h.fetchForecast(ctx, location)
+	if err != nil {
+		// Serve an expired entry, within the max staleness, if the API failed
+		if _, isLocationNotFound := err.(*client.LocationNotFoundError); found && !isLocationNotFound {
+			log.Printf("Erro ao buscar previsão de %s, servindo dado em cache de %v: %v", location, age.Round(time.Second), err)
+			stale := &staleInfo{Reason: "upstream_error", Age: age}
+			forecastSpan.RecordError(err)
+			forecastSpan.SetAttributes(staleAttributes(stale)...)
+			forecastSpan.SetStatus(codes.Ok, "Stale forecast served after API error")
+			return cachedForecast, stale, nil
+		}
+
+		forecastSpan.RecordError(err)
+		forecastSpan.SetStatus(codes.Error, "Weather forecast call failed")
+		return nil, nil, err
+	}
+
+	forecastSpan.SetAttributes(
+		attribute.Bool("cache.hit", false),
+		attribute.Int("forecast.days_returned", len(forecast.Forecast.Forecastday)),
+	)
+	forecastSpan.SetStatus(codes.Ok, "Forecast retrieved from API")
+	log.Printf("Cache miss para previsão de %s, dados armazenados", location)
+
+	return forecast, nil, nil
+}
+
+// fetchForecast fetches the forecast for the maximum number of days and
+// caches it, sharing a single in-flight API call per location
+func (h *TemperatureHandler) fetchForecast(ctx context.Context, location string) (*model.WeatherAPIForecastResponse, error) {
+	result, _, err := h.forecastCalls.Do(ctx, location, func(ctx context.Context) (interface{}, error) {
+		forecast, err := h.forecaster.GetForecast(ctx, location, h.forecastMaxDays)
+		if err != nil {
+			// Only "not found" answers are cached; transient errors are retried on the next request
+			if _, isLocationNotFound := err.(*client.LocationNotFoundError); isLocationNotFound {
+				h.cache.SetWeatherNotFound(location)
+			}
+			return nil, err
+		}
+
+		ttl := h.ttls.Forecast
+		_, cacheStoreSpan := telemetry.StartSpan(ctx, "cache.store",
+			attribute.String("cache.key", "forecast:"+location),
+			attribute.String("cache.type", "forecast"),
+		)
+		cacheStoreSpan.SetAttributes(ttlAttributes(ttl, cache.TTLSourceFixed)...)
+		h.cache.SetForecast(location, forecast, ttl)
+		cacheStoreSpan.SetStatus(codes.Ok, "Forecast cached")
+		cacheStoreSpan.End()
+
+		return forecast, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*model.WeatherAPIForecastResponse), nil
+}
+
+// buildForecast converts the first days of the provider's forecast to C/F/K,
+// with the hours in the location's timezone
+func (h *TemperatureHandler) buildForecast(ctx context.Context, location *model.ViaCEPResponse, forecast *model.WeatherAPIForecastResponse, days int) *models.ForecastResponse {
+	_, span := telemetry.StartSpan(ctx, "forecast.conversion",
+		attribute.String("city.name", location.GetCityName()),
+		attribute.Int("forecast.days", days),
+	)
+	defer span.End()
+
+	tz, err := time.LoadLocation(forecast.Location.TzID)
+	if err != nil {
+		tz = time.UTC
+	}
+
+	forecastDays := forecast.Forecast.Forecastday
+	if len(forecastDays) > days {
+		forecastDays = forecastDays[:days]
+	}
+
+	response := &models.ForecastResponse{
+		CEP:      location.CEP,
+		City:     location.GetCityName(),
+		UF:       location.UF,
+		Timezone: forecast.Location.TzID,
+		Days:     make([]models.DailyForecast, 0, len(forecastDays)),
+	}
+	hours := 0
+	for _, day := range forecastDays {
+		daily := models.DailyForecast{
+			Date:                day.Date,
+			Min:                 h.tempConverter.ConvertUnits(day.Day.MintempC),
+			Max:                 h.tempConverter.ConvertUnits(day.Day.MaxtempC),
+			ChanceOfRainPercent: day.Day.DailyChanceOfRain,
+			Condition:           forecastCondition(day.Day.Condition),
+			Hours:               make([]models.HourlyForecast, 0, len(day.Hour)),
+		}
+		for _, hour := range day.Hour {
+			daily.Hours = append(daily.Hours, models.HourlyForecast{
+				Time:                time.Unix(hour.TimeEpoch, 0).In(tz).Format(time.RFC3339),
+				Temperature:         h.tempConverter.ConvertUnits(hour.TempC),
+				ChanceOfRainPercent: hour.ChanceOfRain,
+				IsDay:               hour.IsDay == 1,
+				Condition:           forecastCondition(hour.Condition),
+			})
+		}
+		hours += len(daily.Hours)
+		response.Days = append(response.Days, daily)
+	}
+
+	span.SetAttributes(
+		attribute.Int("forecast.days_returned", len(response.Days)),
+		attribute.Int("forecast.hours_returned", hours),
+	)
+	span.SetStatus(codes.Ok, "Forecast conversion successful")
+	return response
+}
+
+// forecastCondition maps the provider's condition, omitted when empty
+func forecastCondition(condition model.ForecastCondition) *models.Condition {
+	if condition.Text == "" {
+		return nil
+	}
+	return &models.Condition{Text: condition.Text, Icon: absoluteIconURL(condition.Icon)}
+}
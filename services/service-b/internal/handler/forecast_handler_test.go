@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/lcidral/goExpertOtel/pkg/models"
+)
+
+const forecastAPIBody = `{
+	"location": {"name": "Cidade 0", "country": "Brazil", "tz_id": "America/Sao_Paulo"},
+	"forecast": {"forecastday": [
+		{
+			"date": "2024-03-15",
+			"day": {"maxtemp_c": 30.2, "mintemp_c": 19.8, "daily_chance_of_rain": 80,
+				"condition": {"text": "Chuva moderada", "icon": "//cdn.weatherapi.com/weather/64x64/day/302.png"}},
+			"hour": [
+				{"time_epoch": 1710471600, "temp_c": 20.5, "is_day": 0, "chance_of_rain": 10},
+				{"time_epoch": 1710475200, "temp_c": 20.1, "is_day": 0, "chance_of_rain": 15}
+			]
+		},
+		{
+			"date": "2024-03-16",
+			"day": {"maxtemp_c": 28, "mintemp_c": 18},
+			"hour": []
+		}
+	]}
+}`
+
+func TestHandleForecast(t *testing.T) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if got := r.URL.Query().Get("days"); got != "2" {
+			t.Errorf("days enviado à API = %s, esperava o máximo configurado 2", got)
+		}
+		w.Write([]byte(forecastAPIBody))
+	}))
+	defer server.Close()
+
+	h, weatherClient := newTestHandler(t, server.URL)
+	h.SetForecastProvider(weatherClient, 2)
+
+	tests := []struct {
+		name            string
+		cep             string
+		days            string
+		wantStatus      int
+		wantCacheStatus string
+		wantDays        int
+	}{
+		{"CEP inválido", "123", "", http.StatusUnprocessableEntity, "", 0},
+		{"Dias acima do máximo", "01001000", "3", http.StatusBadRequest, "", 0},
+		{"Dias inválidos", "01001000", "amanhã", http.StatusBadRequest, "", 0},
+		{"Consulta à API", "01001000", "1", http.StatusOK, "MISS", 1},
+		{"Cache com todos os dias", "01001000", "", http.StatusOK, "HIT", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/forecast/"+tt.cep+"?days="+tt.days, nil)
+			routeCtx := chi.NewRouteContext()
+			routeCtx.URLParams.Add("cep", tt.cep)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+			rec := httptest.NewRecorder()
+			h.HandleForecast(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("HandleForecast() status = %d, esperava %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			if got := rec.Header().Get("X-Cache-Status"); got != tt.wantCacheStatus {
+				t.Errorf("X-Cache-Status = %s, esperava %s", got, tt.wantCacheStatus)
+			}
+
+			var got models.ForecastResponse
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("erro ao decodificar resposta: %v", err)
+			}
+			if len(got.Days) != tt.wantDays {
+				t.Fatalf("len(Days) = %d, esperava %d", len(got.Days), tt.wantDays)
+			}
+			today := got.Days[0]
+			if want := (models.TemperatureUnits{C: 19.8, F: 67.6, K: 292.8}); today.Min != want {
+				t.Errorf("Min = %+v, esperava %+v", today.Min, want)
+			}
+			if want := (models.TemperatureUnits{C: 30.2, F: 86.4, K: 303.2}); today.Max != want {
+				t.Errorf("Max = %+v, esperava %+v", today.Max, want)
+			}
+			if len(today.Hours) != 2 || today.Hours[0].Time != "2024-03-15T00:00:00-03:00" {
+				t.Errorf("Hours = %+v, esperava 2 horas a partir de 2024-03-15T00:00:00-03:00", today.Hours)
+			}
+			if today.Condition == nil || today.Condition.Icon != "https://cdn.weatherapi.com/weather/64x64/day/302.png" {
+				t.Errorf("Condition = %+v, esperava ícone com https", today.Condition)
+			}
+		})
+	}
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("chamadas à API = %d, esperava 1", got)
+	}
+}
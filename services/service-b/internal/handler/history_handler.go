@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/lcidral/goExpertOtel/pkg/models"
+	"github.com/lcidral/goExpertOtel/pkg/telemetry"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/cache"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/client"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+)
+
+// historyDateLayout is the format of the date query parameter
+const historyDateLayout = "2006-01-02"
+
+// HandleHistory returns the temperatures recorded at a CEP's city on the
+// date query parameter (YYYY-MM-DD). Past days are immutable, so they are
+// cached for the long history TTL.
+func (h *TemperatureHandler) HandleHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if h.historian == nil {
+		h.respondWithError(w, http.StatusNotImplemented, "Histórico não disponível")
+		return
+	}
+
+	// Brazil is behind UTC, so a date after today in UTC is in the future everywhere
+	date, err := time.Parse(historyDateLayout, r.URL.Query().Get("date"))
+	if err != nil || date.After(time.Now().UTC()) {
+		h.respondWithError(w, http.StatusBadRequest, models.ErrInvalidDate)
+		return
+	}
+	day := date.Format(historyDateLayout)
+
+	ctx, validationSpan, normalizedCEP, ok := h.validateCEP(w, r, chi.URLParam(r, "cep"))
+	if !ok {
+		return
+	}
+	defer validationSpan.End()
+	validationSpan.SetAttributes(attribute.String("history.date", day))
+
+	// Check cache first: the CEP's city and the city's history for the date
+	ctx, cacheSpan := telemetry.StartSpan(ctx, "cache.lookup",
+		attribute.String("cache.key", "location:"+normalizedCEP),
+		attribute.String("cache.type", "history"),
+	)
+	if location, found := h.cache.GetLocation(normalizedCEP); found {
+		if history, found := h.cache.GetHistory(location.GetFullLocation(), day); found {
+			log.Printf("Cache hit para histórico do CEP %s em %s", normalizedCEP, day)
+			cacheSpan.SetAttributes(
+				attribute.Bool("cache.hit", true),
+				attribute.String("city.name", location.GetCityName()),
+			)
+			cacheSpan.SetStatus(codes.Ok, "Cache hit")
+			cacheSpan.End()
+			w.Header().Set("X-Cache-Status", "HIT")
+			h.respondWithSuccess(w, h.buildHistory(ctx, location, history, day))
+			return
+		}
+	}
+	cacheSpan.SetAttributes(attribute.Bool("cache.hit", false))
+	cacheSpan.SetStatus(codes.Ok, "Cache miss")
+	cacheSpan.End()
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	location, staleLocation, err := h.getLocationWithCache(ctxWithTimeout, normalizedCEP)
+	if err != nil {
+		log.Printf("Erro ao buscar localização para CEP %s: %v", normalizedCEP, err)
+		h.respondLookupError(w, err)
+		return
+	}
+	history, err := h.getHistory(ctxWithTimeout, location.GetFullLocation(), date)
+	if err != nil {
+		log.Printf("Erro ao buscar histórico de %s em %s: %v", location.GetFullLocation(), day, err)
+		h.respondLookupError(w, err)
+		return
+	}
+
+	if staleLocation != nil {
+		setStaleHeaders(w, staleLocation)
+	} else {
+		w.Header().Set("X-Cache-Status", "MISS")
+	}
+
+	response := h.buildHistory(ctx, location, history, day)
+	h.respondWithSuccess(w, response)
+	log.Printf("Histórico do CEP %s em %s processado com sucesso: %s, %.1f°C a %.1f°C",
+		normalizedCEP, day, response.City, response.Min.C, response.Max.C)
+}
+
+// getHistory fetches the history of a date and caches it, sharing a single
+// in-flight provider call per location and date
+func (h *TemperatureHandler) getHistory(ctx context.Context, location string, date time.Time) (*model.WeatherAPIHistoryResponse, error) {
+	day := date.Format(historyDateLayout)
+	ctx, historySpan := telemetry.StartSpan(ctx, "weather.history.call",
+		attribute.String("location", location),
+		attribute.String("history.date", day),
+		attribute.String("history.provider", h.historian.Name()),
+	)
+	defer historySpan.End()
+
+	// Locations recently reported as nonexistent are answered without calling the provider
+	if h.cache.IsWeatherNotFound(location) {
+		log.Printf("Cache negativo para a localização %s", location)
+		historySpan.SetAttributes(attribute.Bool("cache.negative_hit", true))
+		historySpan.SetStatus(codes.Ok, "Location not found (negative cache)")
+		return nil, &client.LocationNotFoundError{Location: location}
+	}
+
+	result, _, err := h.historyCalls.Do(ctx, location+"|"+day, func(ctx context.Context) (interface{}, error) {
+		history, err := h.historian.GetHistory(ctx, location, date)
+		if err != nil {
+			// Only "not found" answers are cached; transient errors are retried on the next request
+			if _, isLocationNotFound := err.(*client.LocationNotFoundError); isLocationNotFound {
+				h.cache.SetWeatherNotFound(location)
+			}
+			return nil, err
+		}
+
+		// A day that isn't over yet in the city's timezone may still change
+		ttl := h.ttls.History
+		if !historyComplete(history, date, time.Now()) {
+			ttl = h.ttls.Weather
+		}
+		_, cacheStoreSpan := telemetry.StartSpan(ctx, "cache.store",
+			attribute.String("cache.key", "history:"+location+":"+day),
+			attribute.String("cache.type", "history"),
+		)
+		cacheStoreSpan.SetAttributes(ttlAttributes(ttl, cache.TTLSourceFixed)...)
+		h.cache.SetHistory(location, day, history, ttl)
+		cacheStoreSpan.SetStatus(codes.Ok, "History cached")
+		cacheStoreSpan.End()
+
+		return history, nil
+	})
+	if err != nil {
+		historySpan.RecordError(err)
+		historySpan.SetStatus(codes.Error, "Weather history call failed")
+		return nil, err
+	}
+
+	history := result.(*model.WeatherAPIHistoryResponse)
+	historySpan.SetAttributes(attribute.String("history.provider", history.Provider))
+	historySpan.SetStatus(codes.Ok, "History retrieved from provider")
+	return history, nil
+}
+
+// historyComplete reports whether date has already ended in the history's timezone
+func historyComplete(history *model.WeatherAPIHistoryResponse, date, now time.Time) bool {
+	tz, err := time.LoadLocation(history.Location.TzID)
+	if err != nil {
+		tz = time.UTC
+	}
+	end := time.Date(date.Year(), date.Month(), date.Day()+1, 0, 0, 0, 0, tz)
+	return !now.Before(end)
+}
+
+// buildHistory converts the provider's history of a date to C/F/K, with the
+// hours in the location's timezone
+func (h *TemperatureHandler) buildHistory(ctx context.Context, location *model.ViaCEPResponse, history *model.WeatherAPIHistoryResponse, day string) *models.HistoryResponse {
+	_, span := telemetry.StartSpan(ctx, "history.conversion",
+		attribute.String("city.name", location.GetCityName()),
+		attribute.String("history.date", day),
+	)
+	defer span.End()
+
+	tz, err := time.LoadLocation(history.Location.TzID)
+	if err != nil {
+		tz = time.UTC
+	}
+
+	response := &models.HistoryResponse{
+		CEP:      location.CEP,
+		City:     location.GetCityName(),
+		UF:       location.UF,
+		Date:     day,
+		Timezone: history.Location.TzID,
+		Provider: history.Provider,
+		Hours:    []models.HourlyTemperature{},
+	}
+	if days := history.Forecast.Forecastday; len(days) > 0 {
+		recorded := days[0]
+		response.Min = h.tempConverter.ConvertUnits(recorded.Day.MintempC)
+		response.Max = h.tempConverter.ConvertUnits(recorded.Day.MaxtempC)
+		if recorded.Day.AvgtempC != 0 {
+			avg := h.tempConverter.ConvertUnits(recorded.Day.AvgtempC)
+			response.Avg = &avg
+		}
+		for _, hour := range recorded.Hour {
+			response.Hours = append(response.Hours, models.HourlyTemperature{
+				Time:        time.Unix(hour.TimeEpoch, 0).In(tz).Format(time.RFC3339),
+				Temperature: h.tempConverter.ConvertUnits(hour.TempC),
+			})
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Float64("temp_min_c", response.Min.C),
+		attribute.Float64("temp_max_c", response.Max.C),
+		attribute.Int("history.hours_returned", len(response.Hours)),
+	)
+	span.SetStatus(codes.Ok, "History conversion successful")
+	return response
+}
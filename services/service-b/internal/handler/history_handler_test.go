@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/lcidral/goExpertOtel/pkg/models"
+)
+
+const historyAPIBody = `{
+	"location": {"name": "Cidade 0", "country": "Brazil", "tz_id": "America/Sao_Paulo"},
+	"forecast": {"forecastday": [{
+		"date": "2024-03-15",
+		"day": {"maxtemp_c": 31, "mintemp_c": 21.4, "avgtemp_c": 25.7},
+		"hour": [{"time_epoch": 1710471600, "temp_c": 22.3}]
+	}]}
+}`
+
+func TestHandleHistory(t *testing.T) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Query().Get("dt") == "2024-03-16" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": {"code": 1008, "message": "dt is outside the allowed range"}}`))
+			return
+		}
+		w.Write([]byte(historyAPIBody))
+	}))
+	defer server.Close()
+
+	h, weatherClient := newTestHandler(t, server.URL)
+	h.SetHistoryProvider(weatherClient)
+
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format(historyDateLayout)
+	tests := []struct {
+		name            string
+		date            string
+		wantStatus      int
+		wantCacheStatus string
+	}{
+		{"Sem data", "", http.StatusBadRequest, ""},
+		{"Data futura", tomorrow, http.StatusBadRequest, ""},
+		{"Data fora do plano", "2024-03-16", http.StatusNotFound, ""},
+		{"Consulta à API", "2024-03-15", http.StatusOK, "MISS"},
+		{"Histórico imutável em cache", "2024-03-15", http.StatusOK, "HIT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/history/01001000?date="+tt.date, nil)
+			routeCtx := chi.NewRouteContext()
+			routeCtx.URLParams.Add("cep", "01001000")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+			rec := httptest.NewRecorder()
+			h.HandleHistory(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("HandleHistory() status = %d, esperava %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			if got := rec.Header().Get("X-Cache-Status"); got != tt.wantCacheStatus {
+				t.Errorf("X-Cache-Status = %s, esperava %s", got, tt.wantCacheStatus)
+			}
+
+			var got models.HistoryResponse
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("erro ao decodificar resposta: %v", err)
+			}
+			if got.Date != "2024-03-15" || got.Provider != "weatherapi" {
+				t.Errorf("Date = %s, Provider = %s, esperava 2024-03-15 e weatherapi", got.Date, got.Provider)
+			}
+			if want := (models.TemperatureUnits{C: 21.4, F: 70.5, K: 294.4}); got.Min != want {
+				t.Errorf("Min = %+v, esperava %+v", got.Min, want)
+			}
+			if got.Avg == nil || got.Avg.C != 25.7 {
+				t.Errorf("Avg = %+v, esperava 25.7°C", got.Avg)
+			}
+			if len(got.Hours) != 1 || got.Hours[0].Time != "2024-03-15T00:00:00-03:00" {
+				t.Errorf("Hours = %+v, esperava 1 hora em 2024-03-15T00:00:00-03:00", got.Hours)
+			}
+		})
+	}
+
+	// A data recusada não é cacheada nem marca a localização como inexistente
+	if got := calls.Load(); got != 2 {
+		t.Errorf("chamadas à API = %d, esperava 2", got)
+	}
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lcidral/goExpertOtel/pkg/models"
+	"github.com/lcidral/goExpertOtel/pkg/telemetry"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/readings"
+)
+
+// maxReadingsBuckets limits how many buckets a summary may be split into
+const maxReadingsBuckets = 1000
+
+// recordReading stores the temperature served for a CEP; failures are only logged
+func (h *TemperatureHandler) recordReading(cep string, location *model.ViaCEPResponse, weather *model.WeatherAPIResponse) {
+	if h.readings == nil {
+		return
+	}
+	reading := readings.Reading{
+		City:     location.GetFullLocation(),
+		CEP:      cep,
+		TempC:    weather.GetTemperatureCelsius(),
+		Provider: weather.Provider,
+	}
+	if weather.Current.LastUpdatedEpoch > 0 {
+		reading.ObservedAt = time.Unix(weather.Current.LastUpdatedEpoch, 0)
+	}
+	if _, err := h.readings.Record(reading); err != nil {
+		log.Printf("Erro ao registrar leitura de %s: %v", reading.City, err)
+	}
+}
+
+// HandleReadingsSummary returns min/max/avg of the temperatures served for a
+// city (city=Cidade, UF) or for a CEP's city (cep=...) over the last window
+// (default 24h), optionally split into step-sized buckets. Readings are kept
+// per city, so a CEP is only a way to name its city: the summary covers every
+// CEP of that city. Repeated serves of the same provider measurement are
+// recorded once, so the summary counts observations, not requests.
+func (h *TemperatureHandler) HandleReadingsSummary(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if h.readings == nil {
+		h.respondWithError(w, http.StatusNotImplemented, "Registro de leituras não habilitado")
+		return
+	}
+
+	query := r.URL.Query()
+	window, step, ok := parseReadingsWindow(query.Get("window"), query.Get("step"))
+	if !ok {
+		h.respondWithError(w, http.StatusBadRequest, "window e step devem ser durações positivas, com no máximo 1000 intervalos")
+		return
+	}
+
+	ctx := r.Context()
+	city := query.Get("city")
+	switch cep := query.Get("cep"); {
+	case cep != "":
+		var validationSpan trace.Span
+		var normalizedCEP string
+		ctx, validationSpan, normalizedCEP, ok = h.validateCEP(w, r, cep)
+		if !ok {
+			return
+		}
+		defer validationSpan.End()
+
+		ctxWithTimeout, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		location, _, err := h.getLocationWithCache(ctxWithTimeout, normalizedCEP)
+		if err != nil {
+			h.respondLookupError(w, err)
+			return
+		}
+		city = location.GetFullLocation()
+	case city == "":
+		h.respondWithError(w, http.StatusBadRequest, "city ou cep é obrigatório")
+		return
+	}
+
+	_, span := telemetry.StartSpan(ctx, "readings.summary",
+		attribute.String("city.name", city),
+		attribute.String("readings.window", window.String()),
+		attribute.String("readings.step", step.String()),
+	)
+	defer span.End()
+
+	to := time.Now().UTC()
+	from := to.Add(-window)
+	total, buckets, err := h.readings.Summarize(city, from, to, step)
+	if errors.Is(err, readings.ErrNoReadings) {
+		span.SetAttributes(attribute.Int("readings.observations", 0))
+		span.SetStatus(codes.Ok, "No readings")
+		h.respondWithError(w, http.StatusNotFound, models.ErrNoReadings)
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Readings summary failed")
+		h.respondWithError(w, http.StatusInternalServerError, "Erro interno do servidor")
+		return
+	}
+
+	response := &models.ReadingsSummaryResponse{
+		City:         city,
+		From:         from.Format(time.RFC3339),
+		To:           to.Format(time.RFC3339),
+		Observations: total.Observations,
+		Min:          h.tempConverter.ConvertUnits(total.Min),
+		Max:          h.tempConverter.ConvertUnits(total.Max),
+		Avg:          h.tempConverter.ConvertUnits(total.Avg),
+		Providers:    total.Providers,
+	}
+	for _, bucket := range buckets {
+		response.Buckets = append(response.Buckets, models.ReadingsBucket{
+			From:         bucket.From.Format(time.RFC3339),
+			To:           bucket.To.Format(time.RFC3339),
+			Observations: bucket.Observations,
+			Min:          h.tempConverter.ConvertUnits(bucket.Min),
+			Max:          h.tempConverter.ConvertUnits(bucket.Max),
+			Avg:          h.tempConverter.ConvertUnits(bucket.Avg),
+		})
+	}
+
+	span.SetAttributes(
+		attribute.Int("readings.observations", total.Observations),
+		attribute.Int("readings.buckets", len(response.Buckets)),
+	)
+	span.SetStatus(codes.Ok, "Readings summarized")
+	h.respondWithSuccess(w, response)
+}
+
+// parseReadingsWindow parses the window (default 24h) and optional step of a summary
+func parseReadingsWindow(windowParam, stepParam string) (time.Duration, time.Duration, bool) {
+	window := 24 * time.Hour
+	if windowParam != "" {
+		parsed, err := time.ParseDuration(windowParam)
+		if err != nil || parsed <= 0 {
+			return 0, 0, false
+		}
+		window = parsed
+	}
+	var step time.Duration
+	if stepParam != "" {
+		parsed, err := time.ParseDuration(stepParam)
+		if err != nil || parsed <= 0 || window/parsed > maxReadingsBuckets {
+			return 0, 0, false
+		}
+		step = parsed
+	}
+	return window, step, true
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lcidral/goExpertOtel/pkg/models"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/readings"
+)
+
+func TestHandleReadingsSummary(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(weatherAPIBody))
+	}))
+	defer server.Close()
+
+	store, err := readings.Open(filepath.Join(t.TempDir(), "readings.jsonl"), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("readings.Open() erro inesperado = %v", err)
+	}
+	defer store.Close()
+
+	h, _ := newTestHandler(t, server.URL)
+	h.SetReadingStore(store)
+
+	// Uma consulta à API e uma resposta do cache
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		h.HandleTemperature(rec, httptest.NewRequest(http.MethodPost, "/temperature", strings.NewReader(`{"cep":"01001000"}`)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("HandleTemperature() status = %d, esperava 200", rec.Code)
+		}
+	}
+
+	tests := []struct {
+		name             string
+		query            url.Values
+		wantStatus       int
+		wantObservations int
+	}{
+		{"Por CEP", url.Values{"cep": {"01001000"}}, http.StatusOK, 2},
+		{"Por cidade, em intervalos", url.Values{"city": {"Cidade 0, SP"}, "window": {"1h"}, "step": {"30m"}}, http.StatusOK, 2},
+		{"Cidade sem leituras", url.Values{"city": {"Campinas, SP"}}, http.StatusNotFound, 0},
+		{"Sem cidade nem CEP", url.Values{}, http.StatusBadRequest, 0},
+		{"Intervalos demais", url.Values{"city": {"Cidade 0, SP"}, "window": {"24h"}, "step": {"1s"}}, http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.HandleReadingsSummary(rec, httptest.NewRequest(http.MethodGet, "/readings/summary?"+tt.query.Encode(), nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("HandleReadingsSummary() status = %d, esperava %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var got models.ReadingsSummaryResponse
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("erro ao decodificar resposta: %v", err)
+			}
+			if got.City != "Cidade 0, SP" || got.Observations != tt.wantObservations {
+				t.Errorf("City = %s, Observations = %d, esperava Cidade 0, SP e %d", got.City, got.Observations, tt.wantObservations)
+			}
+			if want := (models.TemperatureUnits{C: 25, F: 77.1, K: 298}); got.Avg != want {
+				t.Errorf("Avg = %+v, esperava %+v", got.Avg, want)
+			}
+			if got.Providers["weatherapi"] != tt.wantObservations {
+				t.Errorf("Providers = %v, esperava %d leituras da weatherapi", got.Providers, tt.wantObservations)
+			}
+		})
+	}
+}
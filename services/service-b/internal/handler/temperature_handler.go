@@ -3,73 +3,275 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/lcidral/goExpertOtel/pkg/breaker"
 	"github.com/lcidral/goExpertOtel/pkg/models"
 	"github.com/lcidral/goExpertOtel/pkg/telemetry"
 	"github.com/lcidral/goExpertOtel/pkg/utils"
 	"github.com/lcidral/goExpertOtel/services/service-b/internal/cache"
 	"github.com/lcidral/goExpertOtel/services/service-b/internal/client"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/coalesce"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/keypool"
 	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/readings"
 	"github.com/lcidral/goExpertOtel/services/service-b/internal/service"
 )
 
 // TemperatureHandler handles temperature-related HTTP requests
 type TemperatureHandler struct {
-	openCEPClient    *client.OpenCEPClient
-	weatherClient    *client.WeatherClient
+	locationProvider client.LocationProvider
+	weatherClient    client.WeatherProvider
 	tempConverter    *service.TemperatureConverter
-	cache            *cache.MemoryCache
+	cache            cache.Cache
 	validator        *utils.CEPValidator
+	breakers         []*breaker.Breaker
+	revalidating     sync.Map // cache keys being refreshed in background
+	locationCalls    *coalesce.Group
+	weatherCalls     *coalesce.Group
+	temperatureCalls *coalesce.Group
+	forecastCalls    *coalesce.Group
+	forecaster       client.ForecastProvider
+	forecastMaxDays  int
+	historyCalls     *coalesce.Group
+	historian        client.HistoryProvider
+	airQualityCalls  *coalesce.Group
+	airQuality       client.AirQualityProvider
+	readings         ReadingStore
+	traffic          TrafficRecorder
+	ttls             cache.TTLPolicy
+	quota            QuotaReporter
+	apiKeys          KeyPoolReporter
+}
+
+// QuotaReporter reports the budget of calls to the weather API
+type QuotaReporter interface {
+	Throttled() bool
+	Stats() map[string]interface{}
+}
+
+// KeyPoolReporter reports the weather API keys, identified only by their IDs
+type KeyPoolReporter interface {
+	Available() int
+	Stats() map[string]interface{}
+}
+
+// ReadingStore records the temperatures served and summarizes them per city
+type ReadingStore interface {
+	Record(reading readings.Reading) (bool, error)
+	Summarize(city string, from, to time.Time, step time.Duration) (readings.Summary, []readings.Summary, error)
+	Stats() map[string]interface{}
+}
+
+// TrafficRecorder receives every valid CEP requested, e.g. to learn the most popular ones
+type TrafficRecorder interface {
+	Record(cep string)
 }
 
 // NewTemperatureHandler creates a new temperature handler
 func NewTemperatureHandler(
-	openCEPClient *client.OpenCEPClient,
-	weatherClient *client.WeatherClient,
-	cache *cache.MemoryCache,
+	locationProvider client.LocationProvider,
+	weatherClient client.WeatherProvider,
+	appCache cache.Cache,
+	breakers []*breaker.Breaker,
 ) *TemperatureHandler {
 	return &TemperatureHandler{
-		openCEPClient: openCEPClient,
-		weatherClient: weatherClient,
-		tempConverter: service.NewTemperatureConverter(),
-		cache:         cache,
-		validator:     utils.NewCEPValidator(),
+		locationProvider: locationProvider,
+		weatherClient:    weatherClient,
+		tempConverter:    service.NewTemperatureConverter(),
+		cache:            appCache,
+		validator:        utils.NewCEPValidator(),
+		breakers:         breakers,
+		locationCalls:    coalesce.NewGroup("location"),
+		weatherCalls:     coalesce.NewGroup("weather"),
+		temperatureCalls: coalesce.NewGroup("temperature"),
+		forecastCalls:    coalesce.NewGroup("forecast"),
+		forecastMaxDays:  3,
+		historyCalls:     coalesce.NewGroup("history"),
+		airQualityCalls:  coalesce.NewGroup("airquality"),
+		ttls:             cache.DefaultTTLPolicy(),
 	}
 }
 
+// SetQuotaReporter registers the weather API budget, reported by the health check
+func (h *TemperatureHandler) SetQuotaReporter(quota QuotaReporter) {
+	h.quota = quota
+}
+
+// SetKeyPoolReporter registers the weather API key pool, reported by the health check
+func (h *TemperatureHandler) SetKeyPoolReporter(apiKeys KeyPoolReporter) {
+	h.apiKeys = apiKeys
+}
+
+// SetForecastProvider enables the forecast endpoint; forecasts are fetched
+// and cached for maxDays, the most a request may ask for
+func (h *TemperatureHandler) SetForecastProvider(forecaster client.ForecastProvider, maxDays int) {
+	h.forecaster = forecaster
+	h.forecastMaxDays = maxDays
+}
+
+// SetHistoryProvider enables the historical weather endpoint
+func (h *TemperatureHandler) SetHistoryProvider(historian client.HistoryProvider) {
+	h.historian = historian
+}
+
+// SetAirQualityProvider adds the current air quality to the conditions document
+func (h *TemperatureHandler) SetAirQualityProvider(airQuality client.AirQualityProvider) {
+	h.airQuality = airQuality
+}
+
+// SetReadingStore enables recording every temperature served and the readings endpoints
+func (h *TemperatureHandler) SetReadingStore(store ReadingStore) {
+	h.readings = store
+}
+
+// SetTTLPolicy sets how long locations and weather are cached
+func (h *TemperatureHandler) SetTTLPolicy(ttls cache.TTLPolicy) {
+	h.ttls = ttls
+}
+
 // HandleTemperature processes temperature requests
 func (h *TemperatureHandler) HandleTemperature(w http.ResponseWriter, r *http.Request) {
 	// Set response headers
 	w.Header().Set("Content-Type", "application/json")
 
+	// Requests without conversion options keep the original temp_C/temp_F/temp_K response
+	var options *service.ConversionOptions
+	if values := utils.TemperatureOptions(r); len(values) > 0 {
+		parsed, err := service.ParseConversionOptions(values)
+		if err != nil {
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		options = &parsed
+	}
+
+	ctx, validationSpan, normalizedCEP, ok := h.decodeCEP(w, r)
+	if !ok {
+		return
+	}
+	defer validationSpan.End()
+	if options != nil {
+		validationSpan.SetAttributes(conversionAttributes(options)...)
+	}
+
+	// Check cache first: the CEP's city and the city's weather, both fresh
+	ctx, cacheSpan := telemetry.StartSpan(ctx, "cache.lookup",
+		attribute.String("cache.key", "location:"+normalizedCEP),
+		attribute.String("cache.type", "temperature"),
+	)
+	if location, weather, found := h.cachedWeather(normalizedCEP); found {
+		cachedTemp := h.tempConverter.ConvertToAllUnits(weather.GetTemperatureCelsius(), location.GetCityName())
+		log.Printf("Cache hit para temperatura do CEP %s", normalizedCEP)
+		cacheSpan.SetAttributes(
+			attribute.Bool("cache.hit", true),
+			attribute.String("city.name", cachedTemp.City),
+		)
+		cacheSpan.SetStatus(codes.Ok, "Cache hit")
+		cacheSpan.End()
+		w.Header().Set("X-Cache-Status", "HIT")
+		h.respondWithSuccess(w, h.temperatureBody(cachedTemp, weather, options))
+		h.recordReading(normalizedCEP, location, weather)
+		return
+	}
+	cacheSpan.SetAttributes(attribute.Bool("cache.hit", false))
+	cacheSpan.SetStatus(codes.Ok, "Cache miss")
+	cacheSpan.End()
+
+	// Create context with timeout
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// Concurrent requests for the same uncached CEP share a single lookup
+	result, shared, err := h.temperatureCalls.Do(ctxWithTimeout, normalizedCEP, func(ctx context.Context) (interface{}, error) {
+		return h.buildTemperature(ctx, normalizedCEP)
+	})
+	if err != nil {
+		h.respondLookupError(w, err)
+		return
+	}
+
+	// A shared result was built by a concurrent request for the same CEP
+	temperature := result.(*temperatureResult)
+	switch {
+	case temperature.Stale != nil:
+		setStaleHeaders(w, temperature.Stale)
+	case shared:
+		w.Header().Set("X-Cache-Status", "COALESCED")
+	default:
+		w.Header().Set("X-Cache-Status", "MISS")
+	}
+
+	// Respond with success
+	h.respondWithSuccess(w, h.temperatureBody(temperature.Response, temperature.Weather, options))
+	h.recordReading(normalizedCEP, temperature.Location, temperature.Weather)
+	log.Printf("CEP %s processado com sucesso: %s, %.1f°C",
+		normalizedCEP, temperature.Response.City, temperature.Response.TempC)
+}
+
+// temperatureBody returns the response in the original contract or, when the
+// request has conversion options, in the requested scales and precision
+func (h *TemperatureHandler) temperatureBody(response *models.TemperatureResponse, weather *model.WeatherAPIResponse, options *service.ConversionOptions) interface{} {
+	if options == nil {
+		return response
+	}
+	return h.tempConverter.ConvertScales(weather.GetTemperatureCelsius(), response.City, *options)
+}
+
+// conversionAttributes describes the conversion options of a request
+func conversionAttributes(options *service.ConversionOptions) []attribute.KeyValue {
+	scales := make([]string, len(options.Scales))
+	for i, scale := range options.Scales {
+		scales[i] = string(scale)
+	}
+	return []attribute.KeyValue{
+		attribute.StringSlice("temperature.scales", scales),
+		attribute.Int("temperature.precision", options.Precision),
+		attribute.Bool("temperature.strict", options.Strict),
+	}
+}
+
+// decodeCEP parses and validates the CEP in the request body, answering the
+// request itself when the CEP is invalid. The returned validation span is the
+// parent of the rest of the request and must be ended by the caller.
+func (h *TemperatureHandler) decodeCEP(w http.ResponseWriter, r *http.Request) (context.Context, trace.Span, string, bool) {
 	// Parse request body
 	var req models.CEPRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("Erro ao decodificar requisição: %v", err)
 		h.respondWithError(w, http.StatusBadRequest, models.ErrInvalidZipcode)
-		return
+		return nil, nil, "", false
 	}
+	return h.validateCEP(w, r, req.CEP)
+}
 
+// validateCEP validates and normalizes a CEP, answering the request itself
+// when it is invalid. The returned validation span must be ended by the caller.
+func (h *TemperatureHandler) validateCEP(w http.ResponseWriter, r *http.Request, cep string) (context.Context, trace.Span, string, bool) {
 	// Start CEP validation span
 	ctx, validationSpan := telemetry.StartSpan(r.Context(), "cep.validation",
-		attribute.String("cep.input", req.CEP),
+		attribute.String("cep.input", cep),
 	)
-	defer validationSpan.End()
 
 	// Validate and normalize CEP
-	normalizedCEP, err := h.validator.ValidateAndNormalize(req.CEP)
+	normalizedCEP, err := h.validator.ValidateAndNormalize(cep)
 	if err != nil {
-		log.Printf("CEP inválido: %s, erro: %v", req.CEP, err)
+		log.Printf("CEP inválido: %s, erro: %v", cep, err)
 		validationSpan.RecordError(err)
 		validationSpan.SetStatus(codes.Error, "CEP validation failed")
+		validationSpan.End()
 		h.respondWithError(w, http.StatusUnprocessableEntity, models.ErrInvalidZipcode)
-		return
+		return nil, nil, "", false
 	}
 
 	validationSpan.SetAttributes(
@@ -78,62 +280,226 @@ func (h *TemperatureHandler) HandleTemperature(w http.ResponseWriter, r *http.Re
 	)
 	validationSpan.SetStatus(codes.Ok, "CEP validation successful")
 
-	// Check cache first for complete temperature response
-	ctx, cacheSpan := telemetry.StartSpan(ctx, "cache.lookup",
-		attribute.String("cache.key", "temp:"+normalizedCEP),
-		attribute.String("cache.type", "temperature"),
-	)
-	if cachedTemp, found := h.cache.GetTemperature(normalizedCEP); found {
-		log.Printf("Cache hit para temperatura do CEP %s", normalizedCEP)
-		cacheSpan.SetAttributes(
-			attribute.Bool("cache.hit", true),
-			attribute.String("city.name", cachedTemp.City),
-		)
-		cacheSpan.SetStatus(codes.Ok, "Cache hit")
-		cacheSpan.End()
-		h.respondWithSuccess(w, cachedTemp)
+	if h.traffic != nil {
+		h.traffic.Record(normalizedCEP)
+	}
+	return ctx, validationSpan, normalizedCEP, true
+}
+
+// respondLookupError maps a failed location or weather lookup to the HTTP response
+func (h *TemperatureHandler) respondLookupError(w http.ResponseWriter, err error) {
+	// Check if it's a CEP or location not found error
+	_, isCEPNotFound := err.(*client.CEPNotFoundError)
+	_, isLocationNotFound := err.(*client.LocationNotFoundError)
+	if isCEPNotFound || isLocationNotFound {
+		h.respondWithError(w, http.StatusNotFound, models.ErrZipcodeNotFound)
 		return
 	}
-	cacheSpan.SetAttributes(attribute.Bool("cache.hit", false))
-	cacheSpan.SetStatus(codes.Ok, "Cache miss")
-	cacheSpan.End()
 
-	// Create context with timeout
+	// The provider has no data for the requested date (e.g. outside its plan)
+	if errors.Is(err, client.ErrHistoryUnavailable) {
+		h.respondWithError(w, http.StatusNotFound, models.ErrNoHistory)
+		return
+	}
+
+	// Fail fast while an external dependency's circuit breaker is open,
+	// or while the weather API budget is exhausted or every API key is
+	// quarantined and nothing is cached
+	if errors.Is(err, breaker.ErrOpen) || errors.Is(err, client.ErrQuotaExhausted) || errors.Is(err, keypool.ErrNoKeys) {
+		h.respondUnavailable(w, err)
+		return
+	}
+
+	h.respondWithError(w, http.StatusInternalServerError, "Erro interno do servidor")
+}
+
+// InvalidCEPError is returned by Warm for CEPs that fail validation
+type InvalidCEPError struct {
+	CEP string
+	Err error
+}
+
+func (e *InvalidCEPError) Error() string {
+	return "CEP inválido " + e.CEP + ": " + e.Err.Error()
+}
+
+func (e *InvalidCEPError) Unwrap() error {
+	return e.Err
+}
+
+// isNotFound reports whether err means the CEP or its location doesn't exist
+func isNotFound(err error) bool {
+	var cepNotFound *client.CEPNotFoundError
+	var locationNotFound *client.LocationNotFoundError
+	return errors.As(err, &cepNotFound) || errors.As(err, &locationNotFound)
+}
+
+// Warm loads the location and the city's weather for a CEP into the cache,
+// sharing in-flight lookups with regular requests
+func (h *TemperatureHandler) Warm(ctx context.Context, cep string) error {
+	normalizedCEP, err := h.validator.ValidateAndNormalize(cep)
+	if err != nil {
+		return &InvalidCEPError{CEP: cep, Err: err}
+	}
+	if _, found := h.cachedTemperature(normalizedCEP); found {
+		return nil
+	}
+
+	_, _, err = h.temperatureCalls.Do(ctx, normalizedCEP, func(ctx context.Context) (interface{}, error) {
+		return h.buildTemperature(ctx, normalizedCEP)
+	})
+	return err
+}
+
+// CurrentTemperature returns the city and current temperature of a CEP,
+// served from the cache when possible, sharing in-flight lookups with
+// regular requests (used by the alerts scheduler)
+func (h *TemperatureHandler) CurrentTemperature(ctx context.Context, cep string) (string, models.TemperatureUnits, error) {
+	normalizedCEP, err := h.validator.ValidateAndNormalize(cep)
+	if err != nil {
+		return "", models.TemperatureUnits{}, &InvalidCEPError{CEP: cep, Err: err}
+	}
+	if location, weather, found := h.cachedWeather(normalizedCEP); found {
+		return location.GetCityName(), h.tempConverter.ConvertUnits(weather.GetTemperatureCelsius()), nil
+	}
+
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
+	result, _, err := h.temperatureCalls.Do(ctxWithTimeout, normalizedCEP, func(ctx context.Context) (interface{}, error) {
+		return h.buildTemperature(ctx, normalizedCEP)
+	})
+	if err != nil {
+		return "", models.TemperatureUnits{}, err
+	}
+	temperature := result.(*temperatureResult)
+	return temperature.Response.City, h.tempConverter.ConvertUnits(temperature.Weather.GetTemperatureCelsius()), nil
+}
 
-	// Get location from CEP
-	location, err := h.getLocationWithCache(ctxWithTimeout, normalizedCEP)
+// SetTrafficRecorder registers a recorder for the CEPs requested
+func (h *TemperatureHandler) SetTrafficRecorder(recorder TrafficRecorder) {
+	h.traffic = recorder
+}
+
+// Refresh fetches location and weather for a CEP again when the cached
+// entries are missing or expire within window, so they never expire for
+// popular CEPs. beforeFetch runs before each external call (e.g. rate
+// limiting). Nonexistent CEPs and locations are not errors; they land in
+// the negative cache as on regular requests.
+func (h *TemperatureHandler) Refresh(ctx context.Context, cep string, window time.Duration, beforeFetch func(ctx context.Context) error) (int, error) {
+	normalizedCEP, err := h.validator.ValidateAndNormalize(cep)
 	if err != nil {
-		log.Printf("Erro ao buscar localização para CEP %s: %v", normalizedCEP, err)
-		
-		// Check if it's a CEP not found error
-		if _, isCEPNotFound := err.(*client.CEPNotFoundError); isCEPNotFound {
-			h.respondWithError(w, http.StatusNotFound, models.ErrZipcodeNotFound)
-			return
+		return 0, &InvalidCEPError{CEP: cep, Err: err}
+	}
+	if h.cache.IsLocationNotFound(normalizedCEP) {
+		return 0, nil
+	}
+
+	refreshed := 0
+	location, ok := h.freshCacheValue("location:"+normalizedCEP, window).(*model.ViaCEPResponse)
+	if !ok {
+		if err := beforeFetch(ctx); err != nil {
+			return refreshed, err
 		}
-		
-		h.respondWithError(w, http.StatusInternalServerError, "Erro interno do servidor")
-		return
+		if location, err = h.fetchLocation(ctx, normalizedCEP); err != nil {
+			if isNotFound(err) {
+				return refreshed, nil
+			}
+			return refreshed, err
+		}
+		refreshed++
+	}
+
+	fullLocation := location.GetFullLocation()
+	if h.cache.IsWeatherNotFound(fullLocation) {
+		return refreshed, nil
+	}
+	if _, ok := h.freshCacheValue("weather:"+fullLocation, window).(*model.WeatherAPIResponse); !ok {
+		if err := beforeFetch(ctx); err != nil {
+			return refreshed, err
+		}
+		if _, err := h.fetchWeather(ctx, fullLocation); err != nil {
+			if isNotFound(err) {
+				return refreshed, nil
+			}
+			return refreshed, err
+		}
+		refreshed++
+	}
+
+	return refreshed, nil
+}
+
+// freshCacheValue returns the cached value for key if it stays fresh for at least window
+func (h *TemperatureHandler) freshCacheValue(key string, window time.Duration) interface{} {
+	info, found := h.cache.Inspect(key)
+	if !found || info.FreshUntil == nil || time.Until(*info.FreshUntil) < window {
+		return nil
+	}
+	return info.Value
+}
+
+// cachedTemperature derives the temperature for a CEP from the cached city of
+// the CEP and the city's weather, when both are fresh. Temperatures are not
+// cached per CEP: every CEP of a city shares the same weather entry.
+func (h *TemperatureHandler) cachedTemperature(cep string) (*models.TemperatureResponse, bool) {
+	location, weather, found := h.cachedWeather(cep)
+	if !found {
+		return nil, false
+	}
+	return h.tempConverter.ConvertToAllUnits(weather.GetTemperatureCelsius(), location.GetCityName()), true
+}
+
+// cachedWeather returns the cached city of a CEP and the city's weather, when both are fresh
+func (h *TemperatureHandler) cachedWeather(cep string) (*model.ViaCEPResponse, *model.WeatherAPIResponse, bool) {
+	location, found := h.cache.GetLocation(cep)
+	if !found {
+		return nil, nil, false
+	}
+	weather, found := h.cache.GetWeather(location.GetFullLocation())
+	if !found {
+		return nil, nil, false
+	}
+	return location, weather, true
+}
+
+// temperatureResult is the outcome of a full temperature lookup
+type temperatureResult struct {
+	Response *models.TemperatureResponse
+	Stale    *staleInfo
+	Location *model.ViaCEPResponse
+	Weather  *model.WeatherAPIResponse
+}
+
+// resolveWeather resolves the location of a CEP and the weather of its city,
+// returning the oldest stale value used, if any
+func (h *TemperatureHandler) resolveWeather(ctx context.Context, cep string) (*model.ViaCEPResponse, *model.WeatherAPIResponse, *staleInfo, error) {
+	// Get location from CEP
+	location, staleLocation, err := h.getLocationWithCache(ctx, cep)
+	if err != nil {
+		log.Printf("Erro ao buscar localização para CEP %s: %v", cep, err)
+		return nil, nil, nil, err
 	}
 
 	// Get weather data
-	weather, err := h.getWeatherWithCache(ctxWithTimeout, location.GetFullLocation())
+	weather, staleWeather, err := h.getWeatherWithCache(ctx, location.GetFullLocation())
 	if err != nil {
 		log.Printf("Erro ao buscar clima para %s: %v", location.GetFullLocation(), err)
-		
-		// Check if it's a location not found error
-		if _, isLocationNotFound := err.(*client.LocationNotFoundError); isLocationNotFound {
-			h.respondWithError(w, http.StatusNotFound, models.ErrZipcodeNotFound)
-			return
-		}
-		
-		h.respondWithError(w, http.StatusInternalServerError, "Erro interno do servidor")
-		return
+		return nil, nil, nil, err
+	}
+
+	// Responses built from stale data are flagged
+	return location, weather, mostStale(staleLocation, staleWeather), nil
+}
+
+// buildTemperature resolves location and weather for a CEP and converts the temperature
+func (h *TemperatureHandler) buildTemperature(ctx context.Context, cep string) (*temperatureResult, error) {
+	location, weather, stale, err := h.resolveWeather(ctx, cep)
+	if err != nil {
+		return nil, err
 	}
 
 	// Start temperature conversion span
-	_, conversionSpan := telemetry.StartSpan(ctxWithTimeout, "temperature.conversion",
+	_, conversionSpan := telemetry.StartSpan(ctx, "temperature.conversion",
 		attribute.Float64("temp_c", weather.GetTemperatureCelsius()),
 		attribute.String("city.name", location.GetCityName()),
 	)
@@ -151,80 +517,141 @@ func (h *TemperatureHandler) HandleTemperature(w http.ResponseWriter, r *http.Re
 	)
 	conversionSpan.SetStatus(codes.Ok, "Temperature conversion successful")
 
-	// Cache the complete response
-	h.cache.SetTemperature(normalizedCEP, tempResponse, 10*time.Minute)
+	if stale != nil {
+		conversionSpan.SetAttributes(staleAttributes(stale)...)
+	}
 
-	// Respond with success
-	h.respondWithSuccess(w, tempResponse)
-	log.Printf("CEP %s processado com sucesso: %s, %.1f°C", 
-		normalizedCEP, tempResponse.City, tempResponse.TempC)
+	return &temperatureResult{Response: tempResponse, Stale: stale, Location: location, Weather: weather}, nil
+}
+
+// staleInfo describes a cached value served after its TTL
+type staleInfo struct {
+	Reason string // "revalidating" or "upstream_error"
+	Age    time.Duration
 }
 
 // getLocationWithCache gets location with caching
-func (h *TemperatureHandler) getLocationWithCache(ctx context.Context, cep string) (*model.ViaCEPResponse, error) {
-	// Start OpenCEP API call span
-	ctx, opencepSpan := telemetry.StartSpan(ctx, "opencep.api.call",
+func (h *TemperatureHandler) getLocationWithCache(ctx context.Context, cep string) (*model.ViaCEPResponse, *staleInfo, error) {
+	// Start location lookup span; the configured provider goes in an attribute
+	ctx, locationSpan := telemetry.StartSpan(ctx, "location.lookup",
 		attribute.String("cep.value", cep),
-		attribute.String("api.name", "opencep"),
+		attribute.String("location.provider", h.locationProvider.Name()),
 	)
-	defer opencepSpan.End()
+	defer locationSpan.End()
+
+	// CEPs recently reported as nonexistent are answered without calling the provider
+	if h.cache.IsLocationNotFound(cep) {
+		log.Printf("Cache negativo para o CEP %s", cep)
+		locationSpan.SetAttributes(
+			attribute.Bool("cache.hit", true),
+			attribute.Bool("cache.negative_hit", true),
+		)
+		locationSpan.SetStatus(codes.Ok, "CEP not found (negative cache)")
+		return nil, nil, &client.CEPNotFoundError{CEP: cep}
+	}
 
 	// Check cache first
 	ctx, cacheSpan := telemetry.StartSpan(ctx, "cache.lookup",
 		attribute.String("cache.key", "location:"+cep),
 		attribute.String("cache.type", "location"),
 	)
-	if cachedLocation, found := h.cache.GetLocation(cep); found {
-		log.Printf("Cache hit para localização do CEP %s", cep)
+	cachedLocation, freshness, age, found := h.cache.GetLocationEntry(cep)
+	if found && freshness != cache.Expired {
+		log.Printf("Cache hit (%s) para localização do CEP %s", freshness, cep)
 		cacheSpan.SetAttributes(
 			attribute.Bool("cache.hit", true),
+			attribute.String("cache.freshness", freshness.String()),
 			attribute.String("city.name", cachedLocation.GetCityName()),
 		)
 		cacheSpan.SetStatus(codes.Ok, "Cache hit")
 		cacheSpan.End()
-		
-		opencepSpan.SetAttributes(
+
+		locationSpan.SetAttributes(
 			attribute.Bool("cache.hit", true),
 			attribute.String("city.name", cachedLocation.GetCityName()),
 		)
-		opencepSpan.SetStatus(codes.Ok, "Location retrieved from cache")
-		return cachedLocation, nil
+		locationSpan.SetStatus(codes.Ok, "Location retrieved from cache")
+
+		if freshness == cache.Stale {
+			// Serve the stale entry and refresh it in background
+			h.revalidate(ctx, "location:"+cep, func(ctx context.Context) error {
+				_, err := h.fetchLocation(ctx, cep)
+				return err
+			})
+			stale := &staleInfo{Reason: "revalidating", Age: age}
+			locationSpan.SetAttributes(staleAttributes(stale)...)
+			return cachedLocation, stale, nil
+		}
+		return cachedLocation, nil, nil
 	}
 	cacheSpan.SetAttributes(attribute.Bool("cache.hit", false))
 	cacheSpan.SetStatus(codes.Ok, "Cache miss")
 	cacheSpan.End()
 
-	// Not in cache, fetch from API
-	location, err := h.openCEPClient.GetLocationByCEP(ctx, cep)
+	// Not in cache, fetch from the configured location provider
+	location, err := h.fetchLocation(ctx, cep)
 	if err != nil {
-		opencepSpan.RecordError(err)
-		opencepSpan.SetStatus(codes.Error, "OpenCEP API call failed")
-		return nil, err
-	}
+		// Serve an expired entry, within the max staleness, if the provider failed
+		if _, isCEPNotFound := err.(*client.CEPNotFoundError); found && !isCEPNotFound {
+			log.Printf("Erro ao buscar localização do CEP %s, servindo dado em cache de %v: %v", cep, age.Round(time.Second), err)
+			stale := &staleInfo{Reason: "upstream_error", Age: age}
+			locationSpan.RecordError(err)
+			locationSpan.SetAttributes(staleAttributes(stale)...)
+			locationSpan.SetAttributes(attribute.String("city.name", cachedLocation.GetCityName()))
+			locationSpan.SetStatus(codes.Ok, "Stale location served after provider error")
+			return cachedLocation, stale, nil
+		}
 
-	// Cache the result (cache for 24 hours for location data)
-	_, cacheStoreSpan := telemetry.StartSpan(ctx, "cache.store",
-		attribute.String("cache.key", "location:"+cep),
-		attribute.String("cache.type", "location"),
-		attribute.String("cache.ttl", "24h"),
-	)
-	h.cache.SetLocation(cep, location, 24*time.Hour)
-	cacheStoreSpan.SetStatus(codes.Ok, "Location cached")
-	cacheStoreSpan.End()
+		locationSpan.RecordError(err)
+		locationSpan.SetStatus(codes.Error, "Location provider call failed")
+		return nil, nil, err
+	}
 
-	opencepSpan.SetAttributes(
+	locationSpan.SetAttributes(
 		attribute.Bool("cache.hit", false),
 		attribute.String("city.name", location.GetCityName()),
 		attribute.String("state", location.UF),
 	)
-	opencepSpan.SetStatus(codes.Ok, "Location retrieved from OpenCEP API")
+	locationSpan.SetStatus(codes.Ok, "Location retrieved from provider")
 	log.Printf("Cache miss para localização do CEP %s, dados armazenados", cep)
 
-	return location, nil
+	return location, nil, nil
+}
+
+// fetchLocation fetches the location from the provider and caches it,
+// sharing a single in-flight provider call per CEP
+func (h *TemperatureHandler) fetchLocation(ctx context.Context, cep string) (*model.ViaCEPResponse, error) {
+	result, _, err := h.locationCalls.Do(ctx, cep, func(ctx context.Context) (interface{}, error) {
+		location, err := h.locationProvider.GetLocationByCEP(ctx, cep)
+		if err != nil {
+			// Only "not found" answers are cached; transient errors are retried on the next request
+			if _, isCEPNotFound := err.(*client.CEPNotFoundError); isCEPNotFound {
+				h.cache.SetLocationNotFound(cep)
+			}
+			return nil, err
+		}
+
+		// Cache only the CEP to city mapping
+		ttl := h.ttls.Location
+		_, cacheStoreSpan := telemetry.StartSpan(ctx, "cache.store",
+			attribute.String("cache.key", "location:"+cep),
+			attribute.String("cache.type", "location"),
+		)
+		cacheStoreSpan.SetAttributes(ttlAttributes(ttl, cache.TTLSourceFixed)...)
+		h.cache.SetLocation(cep, location.CityRef(), ttl)
+		cacheStoreSpan.SetStatus(codes.Ok, "Location cached")
+		cacheStoreSpan.End()
+
+		return location, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*model.ViaCEPResponse), nil
 }
 
 // getWeatherWithCache gets weather with caching
-func (h *TemperatureHandler) getWeatherWithCache(ctx context.Context, location string) (*model.WeatherAPIResponse, error) {
+func (h *TemperatureHandler) getWeatherWithCache(ctx context.Context, location string) (*model.WeatherAPIResponse, *staleInfo, error) {
 	// Start Weather API call span
 	ctx, weatherSpan := telemetry.StartSpan(ctx, "weather.api.call",
 		attribute.String("location", location),
@@ -232,49 +659,74 @@ func (h *TemperatureHandler) getWeatherWithCache(ctx context.Context, location s
 	)
 	defer weatherSpan.End()
 
+	// Locations recently reported as nonexistent are answered without calling the API
+	if h.cache.IsWeatherNotFound(location) {
+		log.Printf("Cache negativo para a localização %s", location)
+		weatherSpan.SetAttributes(
+			attribute.Bool("cache.hit", true),
+			attribute.Bool("cache.negative_hit", true),
+		)
+		weatherSpan.SetStatus(codes.Ok, "Location not found (negative cache)")
+		return nil, nil, &client.LocationNotFoundError{Location: location}
+	}
+
 	// Check cache first
 	ctx, cacheSpan := telemetry.StartSpan(ctx, "cache.lookup",
 		attribute.String("cache.key", "weather:"+location),
 		attribute.String("cache.type", "weather"),
 	)
-	if cachedWeather, found := h.cache.GetWeather(location); found {
-		log.Printf("Cache hit para clima de %s", location)
+	cachedWeather, freshness, age, found := h.cache.GetWeatherEntry(location)
+	if found && freshness != cache.Expired {
+		log.Printf("Cache hit (%s) para clima de %s", freshness, location)
 		cacheSpan.SetAttributes(
 			attribute.Bool("cache.hit", true),
+			attribute.String("cache.freshness", freshness.String()),
 			attribute.Float64("temp_c", cachedWeather.GetTemperatureCelsius()),
 		)
 		cacheSpan.SetStatus(codes.Ok, "Cache hit")
 		cacheSpan.End()
-		
+
 		weatherSpan.SetAttributes(
 			attribute.Bool("cache.hit", true),
 			attribute.Float64("temp_c", cachedWeather.GetTemperatureCelsius()),
 		)
 		weatherSpan.SetStatus(codes.Ok, "Weather retrieved from cache")
-		return cachedWeather, nil
+
+		if freshness == cache.Stale {
+			// Serve the stale entry and refresh it in background
+			h.revalidate(ctx, "weather:"+location, func(ctx context.Context) error {
+				_, err := h.fetchWeather(ctx, location)
+				return err
+			})
+			stale := &staleInfo{Reason: "revalidating", Age: age}
+			weatherSpan.SetAttributes(staleAttributes(stale)...)
+			return cachedWeather, stale, nil
+		}
+		return cachedWeather, nil, nil
 	}
 	cacheSpan.SetAttributes(attribute.Bool("cache.hit", false))
 	cacheSpan.SetStatus(codes.Ok, "Cache miss")
 	cacheSpan.End()
 
 	// Not in cache, fetch from API
-	weather, err := h.weatherClient.GetCurrentWeather(ctx, location)
+	weather, err := h.fetchWeather(ctx, location)
 	if err != nil {
+		// Serve an expired entry, within the max staleness, if the API failed
+		if _, isLocationNotFound := err.(*client.LocationNotFoundError); found && !isLocationNotFound {
+			log.Printf("Erro ao buscar clima de %s, servindo dado em cache de %v: %v", location, age.Round(time.Second), err)
+			stale := &staleInfo{Reason: "upstream_error", Age: age}
+			weatherSpan.RecordError(err)
+			weatherSpan.SetAttributes(staleAttributes(stale)...)
+			weatherSpan.SetAttributes(attribute.Float64("temp_c", cachedWeather.GetTemperatureCelsius()))
+			weatherSpan.SetStatus(codes.Ok, "Stale weather served after API error")
+			return cachedWeather, stale, nil
+		}
+
 		weatherSpan.RecordError(err)
 		weatherSpan.SetStatus(codes.Error, "Weather API call failed")
-		return nil, err
+		return nil, nil, err
 	}
 
-	// Cache the result (cache for 10 minutes for weather data)
-	_, cacheStoreSpan := telemetry.StartSpan(ctx, "cache.store",
-		attribute.String("cache.key", "weather:"+location),
-		attribute.String("cache.type", "weather"),
-		attribute.String("cache.ttl", "10m"),
-	)
-	h.cache.SetWeather(location, weather, 10*time.Minute)
-	cacheStoreSpan.SetStatus(codes.Ok, "Weather cached")
-	cacheStoreSpan.End()
-
 	weatherSpan.SetAttributes(
 		attribute.Bool("cache.hit", false),
 		attribute.Float64("temp_c", weather.GetTemperatureCelsius()),
@@ -283,7 +735,115 @@ func (h *TemperatureHandler) getWeatherWithCache(ctx context.Context, location s
 	weatherSpan.SetStatus(codes.Ok, "Weather retrieved from API")
 	log.Printf("Cache miss para clima de %s, dados armazenados", location)
 
-	return weather, nil
+	return weather, nil, nil
+}
+
+// fetchWeather fetches the weather from WeatherAPI and caches it,
+// sharing a single in-flight API call per location
+func (h *TemperatureHandler) fetchWeather(ctx context.Context, location string) (*model.WeatherAPIResponse, error) {
+	result, _, err := h.weatherCalls.Do(ctx, location, func(ctx context.Context) (interface{}, error) {
+		weather, err := h.weatherClient.GetCurrentWeather(ctx, location)
+		if err != nil {
+			// Only "not found" answers are cached; transient errors are retried on the next request
+			if _, isLocationNotFound := err.(*client.LocationNotFoundError); isLocationNotFound {
+				h.cache.SetWeatherNotFound(location)
+			}
+			return nil, err
+		}
+
+		// Cache the result, possibly until WeatherAPI's next expected update
+		ttl, source := h.ttls.WeatherTTL(weather, time.Now())
+		_, cacheStoreSpan := telemetry.StartSpan(ctx, "cache.store",
+			attribute.String("cache.key", "weather:"+location),
+			attribute.String("cache.type", "weather"),
+		)
+		cacheStoreSpan.SetAttributes(ttlAttributes(ttl, source)...)
+		h.cache.SetWeather(location, weather, ttl)
+		cacheStoreSpan.SetStatus(codes.Ok, "Weather cached")
+		cacheStoreSpan.End()
+
+		return weather, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*model.WeatherAPIResponse), nil
+}
+
+// revalidate refreshes a stale cache entry in background, at most one refresh per key at a time
+func (h *TemperatureHandler) revalidate(ctx context.Context, key string, refresh func(ctx context.Context) error) {
+	if _, running := h.revalidating.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+
+	// The refresh outlives the request, so it gets its own trace linked to the request span
+	link := trace.LinkFromContext(ctx)
+	go func() {
+		defer h.revalidating.Delete(key)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		ctx, span := telemetry.StartLinkedSpan(ctx, "cache.revalidate", []trace.Link{link},
+			attribute.String("cache.key", key),
+		)
+		defer span.End()
+
+		if err := refresh(ctx); err != nil {
+			log.Printf("Erro ao revalidar cache %s: %v", key, err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Cache revalidation failed")
+			return
+		}
+		span.SetStatus(codes.Ok, "Cache revalidated")
+	}()
+}
+
+// mostStale returns the oldest stale value used to build a response, if any
+func mostStale(values ...*staleInfo) *staleInfo {
+	var result *staleInfo
+	for _, stale := range values {
+		switch {
+		case stale == nil:
+			continue
+		case result == nil:
+		case stale.Reason == "upstream_error" && result.Reason != "upstream_error":
+		case stale.Reason == result.Reason && stale.Age > result.Age:
+		default:
+			continue
+		}
+		result = stale
+	}
+	return result
+}
+
+// setStaleHeaders flags a response served from stale data (RFC 7234 warnings)
+func setStaleHeaders(w http.ResponseWriter, stale *staleInfo) {
+	w.Header().Set("X-Cache-Status", "STALE")
+	w.Header().Set("Age", strconv.Itoa(int(stale.Age.Seconds())))
+	if stale.Reason == "upstream_error" {
+		w.Header().Set("Warning", `111 - "Revalidation Failed"`)
+	} else {
+		w.Header().Set("Warning", `110 - "Response is Stale"`)
+	}
+}
+
+// ttlAttributes describes the TTL chosen for a cached value for span attributes
+func ttlAttributes(ttl time.Duration, source string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("cache.ttl", ttl.String()),
+		attribute.Float64("cache.ttl_seconds", ttl.Seconds()),
+		attribute.String("cache.ttl_source", source),
+	}
+}
+
+// staleAttributes describes a stale value for span attributes
+func staleAttributes(stale *staleInfo) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Bool("cache.stale", true),
+		attribute.String("cache.stale_reason", stale.Reason),
+		attribute.Float64("cache.age_seconds", stale.Age.Seconds()),
+	}
 }
 
 // HealthCheck provides a health check endpoint
@@ -291,35 +851,87 @@ func (h *TemperatureHandler) HealthCheck(w http.ResponseWriter, r *http.Request)
 	w.Header().Set("Content-Type", "application/json")
 	
 	cacheStats := h.cache.Stats()
-	
+
+	// An open breaker degrades the service but doesn't make it unhealthy
+	status := "healthy"
+	breakerStats := make(map[string]interface{}, len(h.breakers))
+	for _, cb := range h.breakers {
+		if cb.State() != breaker.StateClosed {
+			status = "degraded"
+		}
+		breakerStats[cb.Name()] = cb.Stats()
+	}
+
 	response := map[string]interface{}{
-		"status":      "healthy",
-		"service":     "service-b",
-		"timestamp":   time.Now().UTC().Format(time.RFC3339),
-		"cache_stats": cacheStats,
+		"status":           status,
+		"service":          "service-b",
+		"timestamp":        time.Now().UTC().Format(time.RFC3339),
+		"cache_stats":      cacheStats,
+		"circuit_breakers": breakerStats,
+	}
+
+	// A throttled weather budget also degrades the service: answers come from cache or the fallback
+	if h.quota != nil {
+		if h.quota.Throttled() {
+			response["status"] = "degraded"
+		}
+		response["weather_quota"] = h.quota.Stats()
+	}
+
+	// With every key quarantined, only cached answers can be served
+	if h.apiKeys != nil {
+		if h.apiKeys.Available() == 0 {
+			response["status"] = "degraded"
+		}
+		response["weather_api_keys"] = h.apiKeys.Stats()
+	}
+
+	if h.readings != nil {
+		response["readings"] = h.readings.Stats()
 	}
 	
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
 
-// CacheStats provides cache statistics endpoint
+// CacheStats provides cache statistics endpoint. With ?count=true, caches
+// backed by Redis also count their keys, which scans the whole namespace and
+// is therefore opt-in (and never done by the health check).
 func (h *TemperatureHandler) CacheStats(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	
+
 	stats := h.cache.Stats()
+	if counter, ok := h.cache.(cache.KeyCounter); ok && r.URL.Query().Get("count") == "true" {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+		if counts, err := counter.CountKeys(ctx); err != nil {
+			stats["key_counts_error"] = err.Error()
+		} else {
+			stats["key_counts"] = counts
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(stats)
 }
 
 // respondWithSuccess sends a success response
-func (h *TemperatureHandler) respondWithSuccess(w http.ResponseWriter, data *models.TemperatureResponse) {
+func (h *TemperatureHandler) respondWithSuccess(w http.ResponseWriter, data interface{}) {
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
 		log.Printf("Erro ao codificar resposta: %v", err)
 	}
 }
 
+// respondUnavailable sends a 503 response for calls rejected by an open circuit breaker
+func (h *TemperatureHandler) respondUnavailable(w http.ResponseWriter, err error) {
+	var openErr *breaker.OpenError
+	if errors.As(err, &openErr) && openErr.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(openErr.RetryAfter.Seconds()))))
+	}
+	h.respondWithError(w, http.StatusServiceUnavailable, "Serviço temporariamente indisponível")
+}
+
 // respondWithError sends an error response
 func (h *TemperatureHandler) respondWithError(w http.ResponseWriter, statusCode int, message string) {
 	w.WriteHeader(statusCode)
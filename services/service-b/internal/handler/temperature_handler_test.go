@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lcidral/goExpertOtel/pkg/breaker"
+	"github.com/lcidral/goExpertOtel/pkg/models"
+	"github.com/lcidral/goExpertOtel/pkg/retry"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/cache"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/client"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/keypool"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+)
+
+// countingLocationProvider resolve cada CEP para uma de cities cidades,
+// contando as chamadas
+type countingLocationProvider struct {
+	cities int
+	calls  atomic.Int64
+}
+
+func (p *countingLocationProvider) GetLocationByCEP(_ context.Context, cep string) (*model.ViaCEPResponse, error) {
+	p.calls.Add(1)
+	var n int
+	fmt.Sscanf(cep, "%d", &n)
+	return &model.ViaCEPResponse{
+		CEP:        cep,
+		Logradouro: "Rua " + cep,
+		Bairro:     "Centro",
+		Localidade: fmt.Sprintf("Cidade %d", n%p.cities),
+		UF:         "SP",
+	}, nil
+}
+
+func (p *countingLocationProvider) Name() string {
+	return "counting"
+}
+
+// newTestHandler monta o handler usado nos testes: uma única cidade, a
+// WeatherAPI simulada em weatherServerURL, sem retentativas e com cache em
+// memória. Retorna também o cliente, para registrar os demais provedores.
+func newTestHandler(tb testing.TB, weatherServerURL string) (*TemperatureHandler, *client.WeatherClient) {
+	tb.Helper()
+	keys := keypool.New("weatherapi", []string{"test"}, keypool.RoundRobin, time.Minute)
+	weatherClient := client.NewWeatherClient(weatherServerURL, keys, 5*time.Second,
+		retry.Policy{MaxAttempts: 1}, breaker.New("weatherapi", breaker.DefaultSettings()), nil)
+	h := NewTemperatureHandler(&countingLocationProvider{cities: 1}, weatherClient,
+		cache.NewMemoryCache(time.Hour, time.Hour, cache.Options{}), nil)
+	return h, weatherClient
+}
+
+// newCountingWeatherServer simula a WeatherAPI, contando as chamadas
+func newCountingWeatherServer(b *testing.B, calls *atomic.Int64) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		weather := model.WeatherAPIResponse{}
+		weather.Location.Name = r.URL.Query().Get("q")
+		weather.Current.TempC = 25
+		json.NewEncoder(w).Encode(weather)
+	}))
+	b.Cleanup(server.Close)
+	return server
+}
+
+func TestHandleTemperature_ConversionOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(weatherAPIBody))
+	}))
+	defer server.Close()
+
+	h, _ := newTestHandler(t, server.URL)
+
+	tests := []struct {
+		name       string
+		target     string
+		header     string
+		wantStatus int
+		wantBody   string
+	}{
+		{"Contrato original", "/temperature", "", http.StatusOK, `{"city":"Cidade 0","temp_C":25,"temp_F":77.1,"temp_K":298}`},
+		{"Escalas e precisão pela consulta", "/temperature?scales=K,Re,De&precision=2&mode=strict", "", http.StatusOK, `{"city":"Cidade 0","temp_K":298.19,"temp_Re":20.03,"temp_De":112.44}`},
+		{"Escalas pelo cabeçalho", "/temperature", "R", http.StatusOK, `{"city":"Cidade 0","temp_R":536.7}`},
+		{"Escala inválida", "/temperature?scales=C,X", "", http.StatusBadRequest, `{"message":"` + models.ErrInvalidScale + `"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(`{"cep":"01001000"}`))
+			if tt.header != "" {
+				req.Header.Set(models.TemperatureScalesHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.HandleTemperature(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("HandleTemperature() status = %d, esperava %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.wantBody {
+				t.Errorf("HandleTemperature() = %s, esperava %s", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHandleTemperature_CoalescedRequests(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		w.Write([]byte(weatherAPIBody))
+	}))
+	defer server.Close()
+
+	h, _ := newTestHandler(t, server.URL)
+
+	serve := func(status chan<- string) {
+		rec := httptest.NewRecorder()
+		h.HandleTemperature(rec, httptest.NewRequest(http.MethodPost, "/temperature", strings.NewReader(`{"cep":"01001000"}`)))
+		status <- rec.Header().Get("X-Cache-Status")
+	}
+
+	// A segunda requisição chega com a consulta da primeira em andamento
+	leader, follower := make(chan string, 1), make(chan string, 1)
+	go serve(leader)
+	<-started
+	go serve(follower)
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if got := <-leader; got != "MISS" {
+		t.Errorf("X-Cache-Status da líder = %s, esperava MISS", got)
+	}
+	if got := <-follower; got != "COALESCED" {
+		t.Errorf("X-Cache-Status da que aguardou = %s, esperava COALESCED", got)
+	}
+}
+
+// BenchmarkHandleTemperature_SharedCityWeather consulta CEPs aleatórios de
+// poucas cidades e compara as chamadas às APIs externas com as de um cache
+// por CEP, em que cada CEP novo consultaria localização e clima
+func BenchmarkHandleTemperature_SharedCityWeather(b *testing.B) {
+	const ceps = 10000
+
+	for _, cities := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("cities=%d", cities), func(b *testing.B) {
+			log.SetOutput(io.Discard)
+			b.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+			var weatherCalls atomic.Int64
+			server := newCountingWeatherServer(b, &weatherCalls)
+			provider := &countingLocationProvider{cities: cities}
+			h, _ := newTestHandler(b, server.URL)
+			h.locationProvider = provider
+			memoryCache := h.cache.(*cache.MemoryCache)
+
+			random := rand.New(rand.NewSource(1))
+			seen := make(map[string]bool)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				cep := fmt.Sprintf("%08d", 1000000+random.Intn(ceps))
+				seen[cep] = true
+
+				req := httptest.NewRequest(http.MethodPost, "/temperature", strings.NewReader(`{"cep":"`+cep+`"}`))
+				rec := httptest.NewRecorder()
+				h.HandleTemperature(rec, req)
+				if rec.Code != http.StatusOK {
+					b.Fatalf("HandleTemperature() status = %d, esperava 200: %s", rec.Code, rec.Body.String())
+				}
+			}
+			b.StopTimer()
+
+			upstream := provider.calls.Load() + weatherCalls.Load()
+			perCEP := int64(2 * len(seen))
+			b.ReportMetric(float64(weatherCalls.Load())/float64(b.N), "weather_calls/op")
+			b.ReportMetric(float64(upstream)/float64(b.N), "upstream_calls/op")
+			b.ReportMetric(float64(perCEP)/float64(b.N), "per_cep_upstream_calls/op")
+			b.ReportMetric(100*(1-float64(upstream)/float64(perCEP)), "%_reduction")
+			b.ReportMetric(float64(memoryCache.GetSize()), "cache_items")
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/lcidral/goExpertOtel/pkg/models"
+	"github.com/lcidral/goExpertOtel/pkg/telemetry"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/cache"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+)
+
+// HandleWeather returns the full current conditions for a CEP as a versioned,
+// provider-neutral document. It shares the location and weather caches with
+// HandleTemperature, whose contract is unchanged.
+func (h *TemperatureHandler) HandleWeather(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx, validationSpan, normalizedCEP, ok := h.decodeCEP(w, r)
+	if !ok {
+		return
+	}
+	defer validationSpan.End()
+
+	// Check cache first: the CEP's city and the city's weather, both fresh
+	ctx, cacheSpan := telemetry.StartSpan(ctx, "cache.lookup",
+		attribute.String("cache.key", "location:"+normalizedCEP),
+		attribute.String("cache.type", "conditions"),
+	)
+	if location, weather, found := h.cachedWeather(normalizedCEP); found {
+		log.Printf("Cache hit para condições do CEP %s", normalizedCEP)
+		cacheSpan.SetAttributes(
+			attribute.Bool("cache.hit", true),
+			attribute.String("city.name", location.GetCityName()),
+		)
+		cacheSpan.SetStatus(codes.Ok, "Cache hit")
+		cacheSpan.End()
+		w.Header().Set("X-Cache-Status", "HIT")
+		conditions := h.buildConditions(ctx, location, weather)
+		h.addAirQuality(ctx, conditions, location.GetFullLocation())
+		h.respondWithSuccess(w, conditions)
+		h.recordReading(normalizedCEP, location, weather)
+		return
+	}
+	cacheSpan.SetAttributes(attribute.Bool("cache.hit", false))
+	cacheSpan.SetStatus(codes.Ok, "Cache miss")
+	cacheSpan.End()
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	location, weather, stale, err := h.resolveWeather(ctxWithTimeout, normalizedCEP)
+	if err != nil {
+		h.respondLookupError(w, err)
+		return
+	}
+
+	if stale != nil {
+		setStaleHeaders(w, stale)
+	} else {
+		w.Header().Set("X-Cache-Status", "MISS")
+	}
+
+	conditions := h.buildConditions(ctx, location, weather)
+	h.addAirQuality(ctxWithTimeout, conditions, location.GetFullLocation())
+	h.respondWithSuccess(w, conditions)
+	h.recordReading(normalizedCEP, location, weather)
+	log.Printf("Condições do CEP %s processadas com sucesso: %s, %.1f°C",
+		normalizedCEP, conditions.Location.City, conditions.Current.Temperature.C)
+}
+
+// buildConditions maps the provider's weather to the conditions document
+func (h *TemperatureHandler) buildConditions(ctx context.Context, location *model.ViaCEPResponse, weather *model.WeatherAPIResponse) *models.WeatherConditionsResponse {
+	_, span := telemetry.StartSpan(ctx, "weather.conditions",
+		attribute.String("city.name", location.GetCityName()),
+		attribute.String("conditions.version", models.WeatherConditionsVersion),
+	)
+	defer span.End()
+
+	current := weather.Current
+	conditions := &models.WeatherConditionsResponse{
+		Version: models.WeatherConditionsVersion,
+		Location: models.ConditionsLocation{
+			CEP:          location.CEP,
+			City:         location.GetCityName(),
+			Neighborhood: location.Bairro,
+			UF:           location.UF,
+			State:        location.Estado,
+			IBGE:         location.IBGE,
+			Country:      weather.Location.Country,
+			Latitude:     weather.Location.Lat,
+			Longitude:    weather.Location.Lon,
+		},
+		Current: models.CurrentConditions{
+			Temperature:     h.tempConverter.ConvertUnits(current.TempC),
+			FeelsLike:       h.tempConverter.ConvertUnits(current.FeelslikeC),
+			HumidityPercent: current.Humidity,
+			CloudPercent:    current.Cloud,
+			Wind: models.Wind{
+				SpeedKph:         current.WindKph,
+				GustKph:          current.GustKph,
+				DirectionDegrees: current.WindDegree,
+				Direction:        current.WindDir,
+			},
+			PressureHPa:     current.PressureMb, // 1 mb = 1 hPa
+			PrecipitationMm: current.PrecipMm,
+			VisibilityKm:    current.VisKm,
+			UVIndex:         current.UV,
+			IsDay:           current.IsDay == 1,
+		},
+	}
+	if current.Condition.Text != "" {
+		conditions.Current.Condition = &models.Condition{
+			Text: current.Condition.Text,
+			Icon: absoluteIconURL(current.Condition.Icon),
+		}
+	}
+
+	conditions.Observation = buildObservation(weather, time.Now())
+
+	span.SetAttributes(
+		attribute.Float64("temp_c", conditions.Current.Temperature.C),
+		attribute.Int("humidity_percent", conditions.Current.HumidityPercent),
+	)
+	if age := conditions.Observation.AgeSeconds; age != nil {
+		span.SetAttributes(attribute.Int64("weather.age_seconds", *age))
+	}
+	span.SetStatus(codes.Ok, "Conditions built")
+	return conditions
+}
+
+// buildObservation reports the location's local time at now and when the
+// provider measured the conditions. Cached weather keeps its original
+// observation time, so the age grows while it is served from cache.
+func buildObservation(weather *model.WeatherAPIResponse, now time.Time) *models.Observation {
+	tzID := weather.Location.TzID
+	tz, err := time.LoadLocation(tzID)
+	if err != nil || tzID == "" {
+		tzID = "UTC"
+		tz = time.UTC
+	}
+
+	observation := &models.Observation{
+		Timezone:  tzID,
+		LocalTime: now.In(tz).Format(time.RFC3339),
+	}
+	if epoch := weather.Current.LastUpdatedEpoch; epoch > 0 {
+		observedAt := time.Unix(epoch, 0)
+		age := int64(now.Sub(observedAt) / time.Second)
+		if age < 0 {
+			age = 0 // clock skew between the provider and this host
+		}
+		observation.ObservedAt = observedAt.In(tz).Format(time.RFC3339)
+		observation.AgeSeconds = &age
+	}
+	return observation
+}
+
+// addAirQuality adds the location's air quality to the conditions, when
+// enabled. It is optional data: failures are logged and the field omitted.
+func (h *TemperatureHandler) addAirQuality(ctx context.Context, conditions *models.WeatherConditionsResponse, location string) {
+	if h.airQuality == nil {
+		return
+	}
+	airQuality, err := h.getAirQuality(ctx, location)
+	if err != nil {
+		log.Printf("Qualidade do ar indisponível para %s: %v", location, err)
+		return
+	}
+	conditions.Current.AirQuality = &models.AirQuality{
+		USEPAIndex:   airQuality.USEPAIndex,
+		Category:     airQualityCategory(airQuality.USEPAIndex),
+		GBDefraIndex: airQuality.GBDefraIndex,
+		Pollutants: models.Pollutants{
+			CO:    airQuality.CO,
+			NO2:   airQuality.NO2,
+			O3:    airQuality.O3,
+			SO2:   airQuality.SO2,
+			PM2_5: airQuality.PM2_5,
+			PM10:  airQuality.PM10,
+		},
+		Provider: airQuality.Provider,
+	}
+}
+
+// getAirQuality returns the cached air quality of a location, or fetches and
+// caches it, sharing a single in-flight provider call per location
+func (h *TemperatureHandler) getAirQuality(ctx context.Context, location string) (*model.AirQuality, error) {
+	if airQuality, found := h.cache.GetAirQuality(location); found {
+		return airQuality, nil
+	}
+
+	ctx, airQualitySpan := telemetry.StartSpan(ctx, "weather.air_quality.call",
+		attribute.String("location", location),
+		attribute.String("air_quality.provider", h.airQuality.Name()),
+	)
+	defer airQualitySpan.End()
+
+	result, _, err := h.airQualityCalls.Do(ctx, location, func(ctx context.Context) (interface{}, error) {
+		airQuality, err := h.airQuality.GetAirQuality(ctx, location)
+		if err != nil {
+			return nil, err
+		}
+
+		_, cacheStoreSpan := telemetry.StartSpan(ctx, "cache.store",
+			attribute.String("cache.key", "airquality:"+location),
+			attribute.String("cache.type", "airquality"),
+		)
+		cacheStoreSpan.SetAttributes(ttlAttributes(h.ttls.AirQuality, cache.TTLSourceFixed)...)
+		h.cache.SetAirQuality(location, airQuality, h.ttls.AirQuality)
+		cacheStoreSpan.SetStatus(codes.Ok, "Air quality cached")
+		cacheStoreSpan.End()
+
+		return airQuality, nil
+	})
+	if err != nil {
+		airQualitySpan.RecordError(err)
+		airQualitySpan.SetStatus(codes.Error, "Air quality call failed")
+		return nil, err
+	}
+
+	airQuality := result.(*model.AirQuality)
+	airQualitySpan.SetAttributes(attribute.Int("air_quality.us_epa_index", airQuality.USEPAIndex))
+	airQualitySpan.SetStatus(codes.Ok, "Air quality retrieved from provider")
+	return airQuality, nil
+}
+
+// airQualityCategories names the US EPA index bands, from index 1 to 6
+var airQualityCategories = []string{
+	models.AirQualityGood,
+	models.AirQualityModerate,
+	models.AirQualityUnhealthyForSensitiveGroups,
+	models.AirQualityUnhealthy,
+	models.AirQualityVeryUnhealthy,
+	models.AirQualityHazardous,
+}
+
+// airQualityCategory maps a US EPA index to its category; out-of-range
+// indexes have no category, so a missing index is never reported as "good"
+func airQualityCategory(index int) string {
+	if index < 1 || index > len(airQualityCategories) {
+		return ""
+	}
+	return airQualityCategories[index-1]
+}
+
+// absoluteIconURL turns WeatherAPI's protocol-relative icon URLs ("//cdn...") into https URLs
+func absoluteIconURL(icon string) string {
+	if strings.HasPrefix(icon, "//") {
+		return "https:" + icon
+	}
+	return icon
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lcidral/goExpertOtel/pkg/models"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/model"
+)
+
+const weatherAPIBody = `{
+	"location": {"name": "Cidade 0", "country": "Brazil", "lat": -23.55, "lon": -46.63},
+	"current": {
+		"temp_c": 25.04, "feelslike_c": 26.3, "humidity": 65, "cloud": 40, "is_day": 1,
+		"wind_kph": 11.2, "gust_kph": 15.1, "wind_degree": 120, "wind_dir": "ESE",
+		"pressure_mb": 1015, "precip_mm": 0.1, "vis_km": 10, "uv": 6,
+		"condition": {"text": "Parcialmente nublado", "icon": "//cdn.weatherapi.com/weather/64x64/day/116.png", "code": 1003}
+	}
+}`
+
+func TestHandleWeather(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(weatherAPIBody))
+	}))
+	defer server.Close()
+
+	h, _ := newTestHandler(t, server.URL)
+
+	tests := []struct {
+		name            string
+		body            string
+		wantStatus      int
+		wantCacheStatus string
+	}{
+		{"CEP inválido", `{"cep":"123"}`, http.StatusUnprocessableEntity, ""},
+		{"Consulta às APIs", `{"cep":"01001000"}`, http.StatusOK, "MISS"},
+		{"Cache com o bairro do CEP", `{"cep":"01001000"}`, http.StatusOK, "HIT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/weather", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			h.HandleWeather(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("HandleWeather() status = %d, esperava %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			if got := rec.Header().Get("X-Cache-Status"); got != tt.wantCacheStatus {
+				t.Errorf("X-Cache-Status = %s, esperava %s", got, tt.wantCacheStatus)
+			}
+
+			var got models.WeatherConditionsResponse
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("erro ao decodificar resposta: %v", err)
+			}
+			if got.Version != models.WeatherConditionsVersion {
+				t.Errorf("Version = %s, esperava %s", got.Version, models.WeatherConditionsVersion)
+			}
+			if got.Location.Neighborhood != "Centro" || got.Location.UF != "SP" {
+				t.Errorf("Location = %+v, esperava bairro Centro e UF SP", got.Location)
+			}
+			if want := (models.TemperatureUnits{C: 25, F: 77.1, K: 298}); got.Current.Temperature != want {
+				t.Errorf("Temperature = %+v, esperava %+v", got.Current.Temperature, want)
+			}
+			if got.Current.HumidityPercent != 65 || got.Current.PressureHPa != 1015 || got.Current.Wind.Direction != "ESE" {
+				t.Errorf("Current = %+v, esperava umidade 65, pressão 1015 e vento ESE", got.Current)
+			}
+			if got.Current.Condition == nil || got.Current.Condition.Icon != "https://cdn.weatherapi.com/weather/64x64/day/116.png" {
+				t.Errorf("Condition = %+v, esperava ícone com https", got.Current.Condition)
+			}
+		})
+	}
+}
+
+func TestHandleWeather_AirQuality(t *testing.T) {
+	var airQualityCalls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("aqi") != "yes" {
+			w.Write([]byte(weatherAPIBody))
+			return
+		}
+		airQualityCalls++
+		w.Write([]byte(`{
+			"location": {"name": "Cidade 0"},
+			"current": {"air_quality": {"co": 227.0, "no2": 12.5, "o3": 61.2, "so2": 3.1, "pm2_5": 38.4, "pm10": 45.9, "us-epa-index": 3, "gb-defra-index": 4}}
+		}`))
+	}))
+	defer server.Close()
+
+	h, weatherClient := newTestHandler(t, server.URL)
+	h.SetAirQualityProvider(weatherClient)
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		h.HandleWeather(rec, httptest.NewRequest(http.MethodPost, "/weather", strings.NewReader(`{"cep":"01001000"}`)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("HandleWeather() status = %d, esperava 200: %s", rec.Code, rec.Body.String())
+		}
+
+		var got models.WeatherConditionsResponse
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("erro ao decodificar resposta: %v", err)
+		}
+		airQuality := got.Current.AirQuality
+		if airQuality == nil {
+			t.Fatalf("AirQuality ausente, esperava índice 3")
+		}
+		if airQuality.USEPAIndex != 3 || airQuality.Category != models.AirQualityUnhealthyForSensitiveGroups ||
+			airQuality.GBDefraIndex != 4 || airQuality.Pollutants.PM2_5 != 38.4 || airQuality.Provider != "weatherapi" {
+			t.Errorf("AirQuality = %+v, esperava índice 3 (%s), DEFRA 4 e PM2.5 38.4 da weatherapi",
+				airQuality, models.AirQualityUnhealthyForSensitiveGroups)
+		}
+	}
+	if airQualityCalls != 1 {
+		t.Errorf("consultas de qualidade do ar = %d, esperava 1 (segunda em cache)", airQualityCalls)
+	}
+}
+
+func TestAirQualityCategory(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, ""},
+		{1, models.AirQualityGood},
+		{2, models.AirQualityModerate},
+		{5, models.AirQualityVeryUnhealthy},
+		{6, models.AirQualityHazardous},
+		{7, ""},
+	}
+
+	for _, tt := range tests {
+		if got := airQualityCategory(tt.index); got != tt.want {
+			t.Errorf("airQualityCategory(%d) = %q, esperava %q", tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestBuildObservation(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	observed := now.Add(-7 * time.Minute).Unix()
+
+	tests := []struct {
+		name           string
+		tzID           string
+		epoch          int64
+		wantTimezone   string
+		wantLocalTime  string
+		wantObservedAt string
+		wantAge        int64
+	}{
+		{"Fuso e horário da medição", "America/Sao_Paulo", observed, "America/Sao_Paulo", "2024-03-15T09:00:00-03:00", "2024-03-15T08:53:00-03:00", 420},
+		{"Sem fuso", "", observed, "UTC", "2024-03-15T12:00:00Z", "2024-03-15T11:53:00Z", 420},
+		{"Sem horário da medição", "America/Manaus", 0, "America/Manaus", "2024-03-15T08:00:00-04:00", "", -1},
+		{"Medição no futuro", "UTC", now.Add(time.Minute).Unix(), "UTC", "2024-03-15T12:00:00Z", "2024-03-15T12:01:00Z", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weather := &model.WeatherAPIResponse{}
+			weather.Location.TzID = tt.tzID
+			weather.Current.LastUpdatedEpoch = tt.epoch
+
+			got := buildObservation(weather, now)
+			if got.Timezone != tt.wantTimezone || got.LocalTime != tt.wantLocalTime || got.ObservedAt != tt.wantObservedAt {
+				t.Errorf("buildObservation() = %+v, esperava fuso %s, horário local %s e medição %s",
+					got, tt.wantTimezone, tt.wantLocalTime, tt.wantObservedAt)
+			}
+			if tt.wantAge < 0 {
+				if got.AgeSeconds != nil {
+					t.Errorf("AgeSeconds = %d, esperava ausente", *got.AgeSeconds)
+				}
+				return
+			}
+			if got.AgeSeconds == nil || *got.AgeSeconds != tt.wantAge {
+				t.Errorf("AgeSeconds = %v, esperava %d", got.AgeSeconds, tt.wantAge)
+			}
+		})
+	}
+}
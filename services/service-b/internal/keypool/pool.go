@@ -0,0 +1,316 @@
+package keypool
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/lcidral/goExpertOtel/pkg/telemetry"
+)
+
+// Strategy critério de escolha da próxima chave
+type Strategy string
+
+const (
+	// RoundRobin alterna entre as chaves disponíveis
+	RoundRobin Strategy = "round-robin"
+	// LeastUsed escolhe a chave disponível com menos requisições
+	LeastUsed Strategy = "least-used"
+)
+
+// ErrNoKeys indica que nenhuma chave está disponível: o pool está vazio ou
+// todas as chaves estão em quarentena
+var ErrNoKeys = errors.New("nenhuma chave de API disponível")
+
+// Key chave escolhida para uma requisição. Somente o ID, derivado do hash da
+// chave, pode aparecer em logs, traces e métricas; String e GoString
+// retornam o ID para que a chave não vaze ao ser formatada.
+type Key struct {
+	id    string
+	value string
+}
+
+// ID identificador da chave, seguro para logs e métricas
+func (k Key) ID() string {
+	return k.id
+}
+
+// Value valor da chave, a ser usado somente na requisição
+func (k Key) Value() string {
+	return k.value
+}
+
+func (k Key) String() string {
+	return k.id
+}
+
+func (k Key) GoString() string {
+	return k.id
+}
+
+// entry estado de uma chave do pool
+type entry struct {
+	Key
+	requests         int64
+	failures         int64
+	quarantines      int64
+	quarantinedUntil time.Time
+	lastStatus       int
+}
+
+var (
+	requestsCounter, _ = telemetry.Meter().Int64Counter("apikey.requests",
+		metric.WithDescription("Requests made with each API key, by outcome"),
+	)
+	quarantinesCounter, _ = telemetry.Meter().Int64Counter("apikey.quarantines",
+		metric.WithDescription("Times an API key was quarantined after being rejected"),
+	)
+)
+
+// Pool conjunto de chaves de uma API externa. Chaves recusadas pela API
+// (401/403) ficam em quarentena por cooldown e depois voltam a ser usadas.
+type Pool struct {
+	name     string
+	strategy Strategy
+	cooldown time.Duration
+	now      func() time.Time
+
+	mu   sync.Mutex
+	keys []*entry
+	next int
+}
+
+// New cria o pool da API name com as chaves informadas; chaves vazias ou
+// repetidas são ignoradas
+func New(name string, values []string, strategy Strategy, cooldown time.Duration) *Pool {
+	p := &Pool{
+		name:     name,
+		strategy: strategy,
+		cooldown: cooldown,
+		now:      time.Now,
+	}
+	seen := make(map[string]bool)
+	for _, value := range values {
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		p.keys = append(p.keys, &entry{Key: Key{id: fingerprint(value), value: value}})
+	}
+
+	// Estado de cada chave: 1 em quarentena, 0 disponível
+	_, err := telemetry.Meter().Int64ObservableGauge("apikey.quarantined",
+		metric.WithDescription("Whether each API key is quarantined (1) or available (0)"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			now := p.now()
+			for _, e := range p.keys {
+				var quarantined int64
+				if now.Before(e.quarantinedUntil) {
+					quarantined = 1
+				}
+				o.Observe(quarantined, metric.WithAttributes(p.attributes(e.Key)...))
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		log.Printf("Aviso: falha ao registrar métricas das chaves da %s: %v", name, err)
+	}
+	return p
+}
+
+// Replace substitui as chaves do pool de forma atômica, por exemplo após a
+// rotação do segredo. Chaves mantidas conservam o uso e a quarentena;
+// requisições em andamento terminam com a chave que já obtiveram.
+func (p *Pool) Replace(values []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	current := make(map[string]*entry, len(p.keys))
+	for _, e := range p.keys {
+		current[e.id] = e
+	}
+
+	var keys []*entry
+	added := 0
+	for _, value := range values {
+		id := fingerprint(value)
+		if value == "" || containsID(keys, id) {
+			continue
+		}
+		e, ok := current[id]
+		if !ok {
+			e = &entry{Key: Key{id: id, value: value}}
+			added++
+		}
+		keys = append(keys, e)
+	}
+
+	removed := len(p.keys) - (len(keys) - added)
+	p.keys = keys
+	p.next = 0
+	log.Printf("Chaves da %s atualizadas: %d chaves (%d novas, %d removidas)", p.name, len(keys), added, removed)
+}
+
+// containsID indica se a chave id já está em keys
+func containsID(keys []*entry, id string) bool {
+	for _, e := range keys {
+		if e.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// fingerprint identifica a chave pelos primeiros caracteres do seu SHA-256
+func fingerprint(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "key-" + hex.EncodeToString(sum[:4])
+}
+
+// attributes identificam a chave nas métricas
+func (p *Pool) attributes(key Key) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("apikey.api", p.name),
+		attribute.String("apikey.id", key.id),
+	}
+}
+
+// Size número de chaves do pool, incluindo as em quarentena
+func (p *Pool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.keys)
+}
+
+// Available número de chaves fora de quarentena
+func (p *Pool) Available() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	available := 0
+	for _, e := range p.keys {
+		if !now.Before(e.quarantinedUntil) {
+			available++
+		}
+	}
+	return available
+}
+
+// Acquire escolhe a chave da próxima requisição segundo a estratégia do pool,
+// ignorando as chaves em quarentena
+func (p *Pool) Acquire() (Key, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	var chosen *entry
+	for i := range p.keys {
+		idx := (p.next + i) % len(p.keys)
+		e := p.keys[idx]
+		if now.Before(e.quarantinedUntil) {
+			continue
+		}
+		if p.strategy != LeastUsed {
+			chosen = e
+			p.next = idx + 1
+			break
+		}
+		if chosen == nil || e.requests < chosen.requests {
+			chosen = e
+		}
+	}
+	if chosen == nil {
+		return Key{}, ErrNoKeys
+	}
+
+	if !chosen.quarantinedUntil.IsZero() {
+		chosen.quarantinedUntil = time.Time{}
+		log.Printf("Chave %s da %s reativada após quarentena", chosen.id, p.name)
+	}
+	chosen.requests++
+	return chosen.Key, nil
+}
+
+// Report registra o resultado da requisição feita com key: statusCode é o
+// status HTTP da resposta ou zero em caso de erro de rede. Chaves recusadas
+// pela API (401/403) entram em quarentena.
+func (p *Pool) Report(ctx context.Context, key Key, statusCode int) {
+	outcome := "success"
+	rejected := statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden
+	switch {
+	case rejected:
+		outcome = "rejected"
+	case statusCode == 0 || statusCode >= http.StatusInternalServerError:
+		outcome = "error"
+	}
+	attrs := p.attributes(key)
+	requestsCounter.Add(ctx, 1, metric.WithAttributes(append(attrs, attribute.String("apikey.outcome", outcome))...))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, e := range p.keys {
+		if e.id != key.id {
+			continue
+		}
+		e.lastStatus = statusCode
+		if !rejected {
+			return
+		}
+		e.failures++
+		if !p.now().Before(e.quarantinedUntil) {
+			e.quarantines++
+			e.quarantinedUntil = p.now().Add(p.cooldown)
+			quarantinesCounter.Add(ctx, 1, metric.WithAttributes(attrs...))
+			log.Printf("Chave %s da %s em quarentena por %v (status %d)", e.id, p.name, p.cooldown, statusCode)
+		}
+		return
+	}
+}
+
+// Stats retorna o uso e o estado de cada chave, identificadas somente pelo ID
+func (p *Pool) Stats() map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	available := 0
+	keys := make([]map[string]interface{}, 0, len(p.keys))
+	for _, e := range p.keys {
+		quarantined := now.Before(e.quarantinedUntil)
+		key := map[string]interface{}{
+			"id":          e.id,
+			"requests":    e.requests,
+			"failures":    e.failures,
+			"quarantines": e.quarantines,
+			"quarantined": quarantined,
+		}
+		if e.lastStatus != 0 {
+			key["last_status"] = e.lastStatus
+		}
+		if quarantined {
+			key["quarantined_until"] = e.quarantinedUntil.UTC().Format(time.RFC3339)
+		} else {
+			available++
+		}
+		keys = append(keys, key)
+	}
+	return map[string]interface{}{
+		"api":       p.name,
+		"strategy":  string(p.strategy),
+		"cooldown":  p.cooldown.String(),
+		"size":      len(p.keys),
+		"available": available,
+		"keys":      keys,
+	}
+}
@@ -0,0 +1,155 @@
+package keypool
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestPool cria um pool com relógio controlado pelo teste
+func newTestPool(now *time.Time, values []string, strategy Strategy) *Pool {
+	pool := New("weatherapi", values, strategy, 10*time.Minute)
+	pool.now = func() time.Time { return *now }
+	return pool
+}
+
+// acquireValues retorna os valores das próximas n chaves escolhidas
+func acquireValues(t *testing.T, pool *Pool, n int) string {
+	t.Helper()
+	var values []string
+	for i := 0; i < n; i++ {
+		key, err := pool.Acquire()
+		if err != nil {
+			t.Fatalf("Acquire() erro inesperado = %v", err)
+		}
+		values = append(values, key.Value())
+	}
+	return strings.Join(values, ",")
+}
+
+func TestPool_Acquire(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		strategy Strategy
+		setup    func(*Pool)
+		want     string
+	}{
+		{"Round-robin", []string{"a", "b", "c"}, RoundRobin, nil, "a,b,c,a"},
+		{"Chaves vazias e repetidas ignoradas", []string{"a", "", "a", "b"}, RoundRobin, nil, "a,b,a,b"},
+		{
+			"Least-used equilibra o uso",
+			[]string{"a", "b", "c"}, LeastUsed,
+			func(p *Pool) {
+				p.keys[0].requests = 5
+				p.keys[1].requests = 2
+			},
+			"c,c,b,c",
+		},
+		{
+			"Chave em quarentena ignorada",
+			[]string{"a", "b"}, RoundRobin,
+			func(p *Pool) {
+				p.Report(context.Background(), p.keys[0].Key, http.StatusUnauthorized)
+			},
+			"b,b,b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+			pool := newTestPool(&now, tt.values, tt.strategy)
+			if tt.setup != nil {
+				tt.setup(pool)
+			}
+			n := strings.Count(tt.want, ",") + 1
+			if got := acquireValues(t, pool, n); got != tt.want {
+				t.Errorf("chaves escolhidas = %s, esperava %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPool_QuarantineAndReinstate(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	pool := newTestPool(&now, []string{"a", "b"}, RoundRobin)
+	ctx := context.Background()
+
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+		key, err := pool.Acquire()
+		if err != nil {
+			t.Fatalf("Acquire() erro inesperado = %v", err)
+		}
+		pool.Report(ctx, key, status)
+	}
+	if got := pool.Available(); got != 0 {
+		t.Fatalf("Available() = %d, esperava 0", got)
+	}
+	if _, err := pool.Acquire(); err != ErrNoKeys {
+		t.Fatalf("Acquire() erro = %v, esperava ErrNoKeys", err)
+	}
+
+	// Erros que não indicam chave recusada não colocam a chave em quarentena
+	now = now.Add(10 * time.Minute)
+	key, err := pool.Acquire()
+	if err != nil {
+		t.Fatalf("Acquire() após o cooldown erro inesperado = %v", err)
+	}
+	pool.Report(ctx, key, http.StatusInternalServerError)
+	if got := pool.Available(); got != 2 {
+		t.Errorf("Available() = %d, esperava 2", got)
+	}
+}
+
+func TestPool_NeverExposesKeyValues(t *testing.T) {
+	const secret = "0123456789abcdef0123456789abcdef"
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	pool := newTestPool(&now, []string{secret}, RoundRobin)
+
+	key, err := pool.Acquire()
+	if err != nil {
+		t.Fatalf("Acquire() erro inesperado = %v", err)
+	}
+	pool.Report(context.Background(), key, http.StatusForbidden)
+
+	for name, text := range map[string]string{
+		"ID":    key.ID(),
+		"%v":    fmt.Sprintf("%v", key),
+		"%#v":   fmt.Sprintf("%#v", key),
+		"Stats": fmt.Sprintf("%v", pool.Stats()),
+	} {
+		if strings.Contains(text, secret) {
+			t.Errorf("%s expõe o valor da chave: %s", name, text)
+		}
+	}
+	if !strings.HasPrefix(key.ID(), "key-") {
+		t.Errorf("ID() = %s, esperava o prefixo key-", key.ID())
+	}
+}
+
+func TestPool_Replace(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	pool := newTestPool(&now, []string{"a", "b"}, RoundRobin)
+
+	// Requisição em andamento com a chave a, recusada após a rotação
+	inFlight, err := pool.Acquire()
+	if err != nil {
+		t.Fatalf("Acquire() erro inesperado = %v", err)
+	}
+	pool.Report(context.Background(), pool.keys[1].Key, http.StatusForbidden)
+
+	pool.Replace([]string{"b", "c"})
+	pool.Report(context.Background(), inFlight, http.StatusUnauthorized)
+
+	if got := pool.Size(); got != 2 {
+		t.Errorf("Size() = %d, esperava 2", got)
+	}
+	// b continua em quarentena e a chave removida não afeta o pool
+	if got := acquireValues(t, pool, 2); got != "c,c" {
+		t.Errorf("chaves escolhidas = %s, esperava c,c", got)
+	}
+}
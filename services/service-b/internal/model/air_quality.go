@@ -0,0 +1,32 @@
+package model
+
+// AirQuality representa a qualidade do ar atual, no formato do campo
+// air_quality da WeatherAPI (concentrações em μg/m³)
+type AirQuality struct {
+	CO           float64 `json:"co"`
+	NO2          float64 `json:"no2"`
+	O3           float64 `json:"o3"`
+	SO2          float64 `json:"so2"`
+	PM2_5        float64 `json:"pm2_5"`
+	PM10         float64 `json:"pm10"`
+	USEPAIndex   int     `json:"us-epa-index"`   // 1 (boa) a 6 (perigosa)
+	GBDefraIndex int     `json:"gb-defra-index"` // 1 a 10; somente WeatherAPI
+	// Provider provedor que forneceu os dados; não faz parte da resposta da WeatherAPI
+	Provider string `json:"provider,omitempty"`
+}
+
+// WeatherAPIAirQualityResponse representa a resposta do current.json com aqi=yes
+type WeatherAPIAirQualityResponse struct {
+	Location struct {
+		Name string `json:"name"`
+	} `json:"location"`
+	Current struct {
+		AirQuality *AirQuality `json:"air_quality"`
+	} `json:"current"`
+}
+
+// IsValid verifica se a resposta identifica a localização; a qualidade do
+// ar pode faltar, por exemplo em planos sem esse dado
+func (r *WeatherAPIAirQualityResponse) IsValid() bool {
+	return r.Location.Name != ""
+}
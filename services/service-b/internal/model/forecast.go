@@ -0,0 +1,55 @@
+package model
+
+// ForecastCondition descrição do tempo previsto
+type ForecastCondition struct {
+	Text string `json:"text"`
+	Icon string `json:"icon"`
+	Code int    `json:"code"`
+}
+
+// ForecastHour previsão de uma hora
+type ForecastHour struct {
+	TimeEpoch    int64             `json:"time_epoch"`
+	Time         string            `json:"time"` // 2006-01-02 15:04, no fuso da localização
+	TempC        float64           `json:"temp_c"`
+	TempF        float64           `json:"temp_f"`
+	IsDay        int               `json:"is_day"`
+	Condition    ForecastCondition `json:"condition"`
+	ChanceOfRain int               `json:"chance_of_rain"`
+}
+
+// ForecastDay previsão de um dia, com as horas do dia
+type ForecastDay struct {
+	Date      string `json:"date"` // 2006-01-02
+	DateEpoch int64  `json:"date_epoch"`
+	Day       struct {
+		MaxtempC          float64           `json:"maxtemp_c"`
+		MaxtempF          float64           `json:"maxtemp_f"`
+		MintempC          float64           `json:"mintemp_c"`
+		MintempF          float64           `json:"mintemp_f"`
+		AvgtempC          float64           `json:"avgtemp_c"`
+		DailyChanceOfRain int               `json:"daily_chance_of_rain"`
+		Condition         ForecastCondition `json:"condition"`
+	} `json:"day"`
+	Hour []ForecastHour `json:"hour"`
+}
+
+// WeatherAPIForecastResponse representa a resposta do forecast.json da WeatherAPI
+type WeatherAPIForecastResponse struct {
+	Location struct {
+		Name    string  `json:"name"`
+		Region  string  `json:"region"`
+		Country string  `json:"country"`
+		Lat     float64 `json:"lat"`
+		Lon     float64 `json:"lon"`
+		TzID    string  `json:"tz_id"`
+	} `json:"location"`
+	Forecast struct {
+		Forecastday []ForecastDay `json:"forecastday"`
+	} `json:"forecast"`
+}
+
+// IsValid verifica se a resposta contém a localização e ao menos um dia de previsão
+func (f *WeatherAPIForecastResponse) IsValid() bool {
+	return f.Location.Name != "" && len(f.Forecast.Forecastday) > 0
+}
@@ -0,0 +1,9 @@
+package model
+
+// WeatherAPIHistoryResponse representa o histórico de um dia, no formato do
+// history.json da WeatherAPI (igual ao forecast.json), com o provedor que o
+// forneceu
+type WeatherAPIHistoryResponse struct {
+	WeatherAPIForecastResponse
+	Provider string `json:"provider,omitempty"`
+}
@@ -34,4 +34,17 @@ func (v *ViaCEPResponse) GetFullLocation() string {
 // GetCityName retorna apenas o nome da cidade
 func (v *ViaCEPResponse) GetCityName() string {
 	return v.Localidade
-}
\ No newline at end of file
+}
+
+// CityRef retorna somente os campos que identificam a cidade e o bairro do
+// CEP, usados no cache como mapeamento de CEP para cidade
+func (v *ViaCEPResponse) CityRef() *ViaCEPResponse {
+	return &ViaCEPResponse{
+		CEP:        v.CEP,
+		Bairro:     v.Bairro,
+		Localidade: v.Localidade,
+		UF:         v.UF,
+		Estado:     v.Estado,
+		IBGE:       v.IBGE,
+	}
+}
@@ -41,6 +41,8 @@ type WeatherAPIResponse struct {
 		GustMph    float64 `json:"gust_mph"`
 		GustKph    float64 `json:"gust_kph"`
 	} `json:"current"`
+	// Provider provedor que forneceu os dados; não faz parte da resposta da WeatherAPI
+	Provider string `json:"provider,omitempty"`
 }
 
 // GetTemperatureCelsius retorna a temperatura em Celsius
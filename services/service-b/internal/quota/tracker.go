@@ -0,0 +1,280 @@
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/lcidral/goExpertOtel/pkg/telemetry"
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/atomicfile"
+)
+
+// Options configura os limites de chamadas à API externa
+type Options struct {
+	// DailyLimit e MonthlyLimit chamadas permitidas por dia e por mês (UTC); zero sem limite
+	DailyLimit   int64
+	MonthlyLimit int64
+	// Threshold fração do limite a partir da qual as chamadas são
+	// restringidas, preservando uma reserva (por exemplo 0.9)
+	Threshold float64
+	// StatePath arquivo em que os contadores são persistidos; vazio mantém
+	// os contadores somente em memória
+	StatePath string
+}
+
+// state contadores persistidos
+type state struct {
+	Day        string `json:"day"` // 2006-01-02
+	DayCalls   int64  `json:"day_calls"`
+	Month      string `json:"month"` // 2006-01
+	MonthCalls int64  `json:"month_calls"`
+	// ExhaustedMonth mês em que a API informou a cota esgotada
+	ExhaustedMonth string `json:"exhausted_month,omitempty"`
+}
+
+// Tracker conta as chamadas feitas a uma API externa por dia e por mês e
+// indica quando o orçamento configurado foi atingido
+type Tracker struct {
+	name    string
+	options Options
+	now     func() time.Time
+
+	mu    sync.Mutex
+	state state
+	dirty bool
+}
+
+// NewTracker cria o contador da API name, restaurando os contadores
+// persistidos em StatePath; um arquivo inexistente não é erro
+func NewTracker(name string, options Options) (*Tracker, error) {
+	if options.Threshold <= 0 || options.Threshold > 1 {
+		options.Threshold = 1
+	}
+	t := &Tracker{
+		name:    name,
+		options: options,
+		now:     time.Now,
+	}
+	if err := t.load(); err != nil {
+		return nil, err
+	}
+
+	// Uso e saldo do orçamento, por período
+	observe := func(o metric.Int64Observer, remaining bool) {
+		stats := t.snapshot()
+		for _, period := range []struct {
+			name  string
+			calls int64
+			limit int64
+		}{
+			{"day", stats.DayCalls, options.DailyLimit},
+			{"month", stats.MonthCalls, options.MonthlyLimit},
+		} {
+			attrs := metric.WithAttributes(
+				attribute.String("quota.api", name),
+				attribute.String("quota.period", period.name),
+			)
+			switch {
+			case !remaining:
+				o.Observe(period.calls, attrs)
+			case period.limit > 0:
+				o.Observe(max(period.limit-period.calls, 0), attrs)
+			}
+		}
+	}
+	_, err := telemetry.Meter().Int64ObservableGauge("quota.used",
+		metric.WithDescription("Calls made to the external API in the current day and month"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			observe(o, false)
+			return nil
+		}),
+	)
+	if err == nil {
+		_, err = telemetry.Meter().Int64ObservableGauge("quota.remaining",
+			metric.WithDescription("Calls left in the external API budget for the current day and month"),
+			metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+				observe(o, true)
+				return nil
+			}),
+		)
+	}
+	if err != nil {
+		log.Printf("Aviso: falha ao registrar métricas de cota da %s: %v", name, err)
+	}
+	return t, nil
+}
+
+// rollover zera os contadores ao mudar o dia ou o mês; deve ser chamado com mu bloqueado
+func (t *Tracker) rollover() {
+	now := t.now().UTC()
+	if day := now.Format("2006-01-02"); t.state.Day != day {
+		t.state.Day, t.state.DayCalls = day, 0
+		t.dirty = true
+	}
+	if month := now.Format("2006-01"); t.state.Month != month {
+		t.state.Month, t.state.MonthCalls = month, 0
+		t.dirty = true
+	}
+}
+
+// snapshot retorna os contadores do período atual
+func (t *Tracker) snapshot() state {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover()
+	return t.state
+}
+
+// Record registra uma chamada à API
+func (t *Tracker) Record() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover()
+	t.state.DayCalls++
+	t.state.MonthCalls++
+	t.dirty = true
+}
+
+// MarkExhausted registra que a API recusou a chamada por cota esgotada; as
+// chamadas ficam restringidas até o fim do mês
+func (t *Tracker) MarkExhausted() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover()
+	if t.state.ExhaustedMonth != t.state.Month {
+		t.state.ExhaustedMonth = t.state.Month
+		t.dirty = true
+	}
+}
+
+// Throttled indica se o orçamento atingiu o limite configurado ou se a API
+// informou a cota esgotada neste mês
+func (t *Tracker) Throttled() bool {
+	s := t.snapshot()
+	return s.ExhaustedMonth == s.Month ||
+		t.reached(s.DayCalls, t.options.DailyLimit) ||
+		t.reached(s.MonthCalls, t.options.MonthlyLimit)
+}
+
+// reached indica se calls atingiu a fração Threshold de limit
+func (t *Tracker) reached(calls, limit int64) bool {
+	return limit > 0 && float64(calls) >= float64(limit)*t.options.Threshold
+}
+
+// Stats retorna o uso e o saldo do orçamento
+func (t *Tracker) Stats() map[string]interface{} {
+	s := t.snapshot()
+	stats := map[string]interface{}{
+		"api":         t.name,
+		"day":         s.Day,
+		"day_calls":   s.DayCalls,
+		"month":       s.Month,
+		"month_calls": s.MonthCalls,
+		"threshold":   t.options.Threshold,
+		"exhausted":   s.ExhaustedMonth == s.Month,
+		"throttled":   t.Throttled(),
+	}
+	if t.options.DailyLimit > 0 {
+		stats["daily_limit"] = t.options.DailyLimit
+		stats["remaining_day"] = max(t.options.DailyLimit-s.DayCalls, 0)
+	}
+	if t.options.MonthlyLimit > 0 {
+		stats["monthly_limit"] = t.options.MonthlyLimit
+		stats["remaining_month"] = max(t.options.MonthlyLimit-s.MonthCalls, 0)
+	}
+	return stats
+}
+
+// load restaura os contadores persistidos
+func (t *Tracker) load() error {
+	if t.options.StatePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(t.options.StatePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao ler contadores de cota: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := json.Unmarshal(data, &t.state); err != nil {
+		return fmt.Errorf("contadores de cota inválidos: %w", err)
+	}
+	t.rollover()
+	return nil
+}
+
+// Save persiste os contadores em StatePath, substituindo o arquivo de forma
+// atômica; não faz nada se nada mudou desde a última gravação
+func (t *Tracker) Save() error {
+	if t.options.StatePath == "" {
+		return nil
+	}
+
+	t.mu.Lock()
+	if !t.dirty {
+		t.mu.Unlock()
+		return nil
+	}
+	data, err := json.Marshal(&t.state)
+	t.dirty = false
+	t.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("erro ao serializar contadores de cota: %w", err)
+	}
+
+	if err := atomicfile.WriteFile(t.options.StatePath, data); err != nil {
+		t.mu.Lock()
+		t.dirty = true
+		t.mu.Unlock()
+		return fmt.Errorf("erro ao gravar contadores de cota: %w", err)
+	}
+	return nil
+}
+
+// Run persiste os contadores a cada interval até ctx ser cancelado
+func (t *Tracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := t.Save(); err != nil {
+				log.Printf("Erro ao salvar contadores de cota da %s: %v", t.name, err)
+			}
+		}
+	}
+}
+
+// Transport retorna um http.RoundTripper que registra cada requisição feita
+// por base, incluindo novas tentativas
+func (t *Tracker) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		t.Record()
+		return base.RoundTrip(req)
+	})
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
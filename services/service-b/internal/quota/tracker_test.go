@@ -0,0 +1,95 @@
+package quota
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestTracker cria um contador com relógio controlado pelo teste
+func newTestTracker(t *testing.T, now *time.Time, options Options) *Tracker {
+	t.Helper()
+	tracker, err := NewTracker("weatherapi", options)
+	if err != nil {
+		t.Fatalf("NewTracker() erro inesperado = %v", err)
+	}
+	tracker.now = func() time.Time { return *now }
+	return tracker
+}
+
+func TestTracker_Threshold(t *testing.T) {
+	tests := []struct {
+		name          string
+		options       Options
+		calls         int
+		wantThrottled bool
+	}{
+		{"Sem limites", Options{}, 100, false},
+		{"Abaixo do limite diário", Options{DailyLimit: 10, Threshold: 0.8}, 7, false},
+		{"Limite diário com reserva", Options{DailyLimit: 10, Threshold: 0.8}, 8, true},
+		{"Limite mensal", Options{MonthlyLimit: 5}, 5, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+			tracker := newTestTracker(t, &now, tt.options)
+			for i := 0; i < tt.calls; i++ {
+				tracker.Record()
+			}
+			if got := tracker.Throttled(); got != tt.wantThrottled {
+				t.Errorf("Throttled() = %v, esperava %v", got, tt.wantThrottled)
+			}
+		})
+	}
+}
+
+func TestTracker_Rollover(t *testing.T) {
+	now := time.Date(2024, 3, 31, 23, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(t, &now, Options{DailyLimit: 2, MonthlyLimit: 100})
+
+	tracker.Record()
+	tracker.Record()
+	tracker.MarkExhausted()
+	if !tracker.Throttled() {
+		t.Fatal("Throttled() deveria ser verdadeiro com o limite diário atingido")
+	}
+
+	// Novo dia e novo mês: contadores e cota esgotada são zerados
+	now = now.Add(2 * time.Hour)
+	if tracker.Throttled() {
+		t.Error("Throttled() deveria ser falso após a virada do mês")
+	}
+	stats := tracker.Stats()
+	if stats["day_calls"] != int64(0) || stats["month_calls"] != int64(0) || stats["remaining_day"] != int64(2) {
+		t.Errorf("Stats() = %v, esperava contadores zerados", stats)
+	}
+}
+
+func TestTracker_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.json")
+	// NewTracker restaura os contadores com o relógio real
+	now := time.Now()
+
+	tracker := newTestTracker(t, &now, Options{MonthlyLimit: 1000, StatePath: path})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	client := &http.Client{Transport: tracker.Transport(nil)}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if err := tracker.Save(); err != nil {
+		t.Fatalf("Save() erro inesperado = %v", err)
+	}
+
+	restored := newTestTracker(t, &now, Options{MonthlyLimit: 1000, StatePath: path})
+	if stats := restored.Stats(); stats["month_calls"] != int64(3) || stats["remaining_month"] != int64(997) {
+		t.Errorf("Stats() após restaurar = %v, esperava 3 chamadas no mês", stats)
+	}
+}
@@ -0,0 +1,280 @@
+package readings
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lcidral/goExpertOtel/services/service-b/internal/atomicfile"
+)
+
+// ErrNoReadings indica que não há leituras da cidade na janela consultada
+var ErrNoReadings = errors.New("nenhuma leitura no período")
+
+// Reading temperatura servida para uma cidade
+type Reading struct {
+	Time       time.Time `json:"time"`                  // quando foi servida
+	ObservedAt time.Time `json:"observed_at,omitempty"` // quando o provedor a mediu
+	City       string    `json:"city"`                  // "Cidade, UF"
+	CEP        string    `json:"cep,omitempty"`
+	TempC      float64   `json:"temp_c"`
+	Provider   string    `json:"provider,omitempty"`
+}
+
+// Summary mínima, máxima e média das leituras de uma cidade em um período.
+// Observations conta as leituras gravadas, que já excluem a mesma medição
+// servida várias vezes.
+type Summary struct {
+	From         time.Time
+	To           time.Time
+	Observations int
+	Min          float64
+	Max          float64
+	Avg          float64
+	Providers    map[string]int
+}
+
+// Store armazena as leituras em um arquivo JSON lines somente de inclusão,
+// com um índice em memória por cidade para as consultas. Leituras mais
+// antigas que a retenção são descartadas do índice e removidas do arquivo
+// ao abri-lo. Uma mesma medição do provedor servida várias vezes (por
+// exemplo, a partir do cache) é gravada uma única vez.
+type Store struct {
+	path      string
+	retention time.Duration
+	now       func() time.Time
+
+	mu     sync.Mutex
+	file   *os.File
+	cities map[string][]Reading // ordenadas por Time
+}
+
+// Open abre o arquivo de leituras em path, criando-o se necessário
+func Open(path string, retention time.Duration) (*Store, error) {
+	s := &Store{
+		path:      path,
+		retention: retention,
+		now:       time.Now,
+		cities:    make(map[string][]Reading),
+	}
+	expired, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	if expired > 0 {
+		if err := s.rewrite(); err != nil {
+			return nil, err
+		}
+		log.Printf("Leituras: %d leituras anteriores à retenção de %v removidas de %s", expired, retention, path)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir arquivo de leituras: %w", err)
+	}
+	s.file = file
+	return s, nil
+}
+
+// load lê o arquivo existente, retornando quantas leituras expiraram
+func (s *Store) load() (int, error) {
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("erro ao abrir arquivo de leituras: %w", err)
+	}
+	defer file.Close()
+
+	cutoff := s.now().Add(-s.retention)
+	expired, invalid := 0, 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var reading Reading
+		if err := json.Unmarshal(scanner.Bytes(), &reading); err != nil || reading.City == "" {
+			// Linha incompleta de uma gravação interrompida
+			invalid++
+			continue
+		}
+		if reading.Time.Before(cutoff) {
+			expired++
+			continue
+		}
+		s.cities[reading.City] = append(s.cities[reading.City], reading)
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("erro ao ler arquivo de leituras: %w", err)
+	}
+	if invalid > 0 {
+		log.Printf("Aviso: %d linhas inválidas ignoradas em %s", invalid, s.path)
+	}
+
+	for city, readings := range s.cities {
+		sort.SliceStable(readings, func(i, j int) bool { return readings[i].Time.Before(readings[j].Time) })
+		s.cities[city] = readings
+	}
+	return expired + invalid, nil
+}
+
+// rewrite grava as leituras do índice em um novo arquivo, substituído de forma atômica
+func (s *Store) rewrite() error {
+	err := atomicfile.Write(s.path, func(w io.Writer) error {
+		for _, readings := range s.cities {
+			for _, reading := range readings {
+				if err := writeReading(w, reading); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao reescrever arquivo de leituras: %w", err)
+	}
+	return nil
+}
+
+// writeReading grava a leitura como uma linha JSON
+func writeReading(w io.Writer, reading Reading) error {
+	line, err := json.Marshal(reading)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar leitura: %w", err)
+	}
+	if _, err := w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("erro ao gravar leitura: %w", err)
+	}
+	return nil
+}
+
+// Record grava a leitura, a menos que seja a mesma medição da última leitura
+// da cidade. Retorna se a leitura foi gravada.
+func (s *Store) Record(reading Reading) (bool, error) {
+	if reading.Time.IsZero() {
+		reading.Time = s.now()
+	}
+	reading.Time = reading.Time.UTC()
+	if !reading.ObservedAt.IsZero() {
+		reading.ObservedAt = reading.ObservedAt.UTC()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	readings := s.cities[reading.City]
+	if n := len(readings); n > 0 {
+		last := readings[n-1]
+		if !reading.ObservedAt.IsZero() && last.ObservedAt.Equal(reading.ObservedAt) &&
+			last.TempC == reading.TempC && last.Provider == reading.Provider {
+			return false, nil
+		}
+	}
+
+	if err := writeReading(s.file, reading); err != nil {
+		return false, err
+	}
+	s.cities[reading.City] = append(s.prune(readings), reading)
+	return true, nil
+}
+
+// prune descarta do índice as leituras anteriores à retenção
+func (s *Store) prune(readings []Reading) []Reading {
+	cutoff := s.now().Add(-s.retention)
+	i := sort.Search(len(readings), func(i int) bool { return !readings[i].Time.Before(cutoff) })
+	if i == 0 {
+		return readings
+	}
+	return append([]Reading(nil), readings[i:]...)
+}
+
+// Summarize calcula mínima, máxima e média das leituras da cidade em
+// [from, to). Com step positivo, o período é dividido em intervalos de step
+// e somente os intervalos com leituras são retornados.
+func (s *Store) Summarize(city string, from, to time.Time, step time.Duration) (Summary, []Summary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	readings := s.cities[city]
+	start := sort.Search(len(readings), func(i int) bool { return !readings[i].Time.Before(from) })
+	end := sort.Search(len(readings), func(i int) bool { return !readings[i].Time.Before(to) })
+	if start >= end {
+		return Summary{}, nil, ErrNoReadings
+	}
+	window := readings[start:end]
+
+	total := summarize(window, from, to)
+	if step <= 0 {
+		return total, nil, nil
+	}
+
+	var buckets []Summary
+	for bucketStart := from; bucketStart.Before(to); bucketStart = bucketStart.Add(step) {
+		bucketEnd := bucketStart.Add(step)
+		if bucketEnd.After(to) {
+			bucketEnd = to
+		}
+		first := sort.Search(len(window), func(i int) bool { return !window[i].Time.Before(bucketStart) })
+		last := sort.Search(len(window), func(i int) bool { return !window[i].Time.Before(bucketEnd) })
+		if first < last {
+			buckets = append(buckets, summarize(window[first:last], bucketStart, bucketEnd))
+		}
+	}
+	return total, buckets, nil
+}
+
+// summarize calcula as estatísticas de readings, que não pode ser vazio
+func summarize(readings []Reading, from, to time.Time) Summary {
+	summary := Summary{
+		From:      from,
+		To:        to,
+		Min:       math.Inf(1),
+		Max:       math.Inf(-1),
+		Providers: make(map[string]int),
+	}
+	sum := 0.0
+	for _, reading := range readings {
+		summary.Observations++
+		sum += reading.TempC
+		summary.Min = math.Min(summary.Min, reading.TempC)
+		summary.Max = math.Max(summary.Max, reading.TempC)
+		provider := reading.Provider
+		if provider == "" {
+			provider = "unknown"
+		}
+		summary.Providers[provider]++
+	}
+	summary.Avg = sum / float64(summary.Observations)
+	return summary
+}
+
+// Stats retorna o número de cidades e de leituras no índice
+func (s *Store) Stats() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	total := 0
+	for _, readings := range s.cities {
+		total += len(readings)
+	}
+	return map[string]interface{}{
+		"path":      s.path,
+		"retention": s.retention.String(),
+		"cities":    len(s.cities),
+		"readings":  total,
+	}
+}
+
+// Close fecha o arquivo de leituras
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
@@ -0,0 +1,119 @@
+package readings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openTestStore abre um store em um diretório temporário com relógio controlado pelo teste
+func openTestStore(t *testing.T, path string, now *time.Time) *Store {
+	t.Helper()
+	s := &Store{path: path, retention: 24 * time.Hour, now: func() time.Time { return *now }, cities: make(map[string][]Reading)}
+	if _, err := s.load(); err != nil {
+		t.Fatalf("load() erro inesperado = %v", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("erro ao abrir arquivo: %v", err)
+	}
+	s.file = file
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestStore_RecordAndSummarize(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	s := openTestStore(t, filepath.Join(t.TempDir(), "readings.jsonl"), &now)
+
+	observed := now.Add(-10 * time.Minute)
+	records := []struct {
+		offset   time.Duration
+		observed time.Time
+		tempC    float64
+		provider string
+		want     bool
+	}{
+		{-3 * time.Hour, observed.Add(-3 * time.Hour), 20, "weatherapi", true},
+		{-2 * time.Hour, observed.Add(-2 * time.Hour), 24, "weatherapi", true},
+		{-90 * time.Minute, observed.Add(-2 * time.Hour), 24, "weatherapi", false}, // mesma medição, servida do cache
+		{-30 * time.Minute, observed, 25, "openmeteo", true},
+	}
+	for _, r := range records {
+		got, err := s.Record(Reading{Time: now.Add(r.offset), ObservedAt: r.observed, City: "São Paulo, SP", TempC: r.tempC, Provider: r.provider})
+		if err != nil {
+			t.Fatalf("Record() erro inesperado = %v", err)
+		}
+		if got != r.want {
+			t.Errorf("Record(%v) = %v, esperava %v", r.offset, got, r.want)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		window      time.Duration
+		step        time.Duration
+		wantCount   int
+		wantMin     float64
+		wantMax     float64
+		wantAvg     float64
+		wantBuckets int
+	}{
+		{"Todas as leituras", 6 * time.Hour, 0, 3, 20, 25, 23, 0},
+		{"Última hora", time.Hour, 0, 1, 25, 25, 25, 0},
+		{"Intervalos de uma hora", 6 * time.Hour, time.Hour, 3, 20, 25, 23, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total, buckets, err := s.Summarize("São Paulo, SP", now.Add(-tt.window), now, tt.step)
+			if err != nil {
+				t.Fatalf("Summarize() erro inesperado = %v", err)
+			}
+			if total.Observations != tt.wantCount || total.Min != tt.wantMin || total.Max != tt.wantMax || total.Avg != tt.wantAvg {
+				t.Errorf("Summarize() = %+v, esperava %d leituras, mín %v, máx %v, média %v",
+					total, tt.wantCount, tt.wantMin, tt.wantMax, tt.wantAvg)
+			}
+			if len(buckets) != tt.wantBuckets {
+				t.Errorf("len(buckets) = %d, esperava %d", len(buckets), tt.wantBuckets)
+			}
+		})
+	}
+
+	if _, _, err := s.Summarize("Campinas, SP", now.Add(-time.Hour), now, 0); err != ErrNoReadings {
+		t.Errorf("Summarize() de cidade sem leituras erro = %v, esperava ErrNoReadings", err)
+	}
+}
+
+func TestOpen_RetentionAndInvalidLines(t *testing.T) {
+	now := time.Now().UTC()
+	path := filepath.Join(t.TempDir(), "readings.jsonl")
+	content := strings.Join([]string{
+		`{"time":"` + now.Add(-48*time.Hour).Format(time.RFC3339) + `","city":"São Paulo, SP","temp_c":18}`,
+		`{"time":"` + now.Add(-time.Hour).Format(time.RFC3339) + `","city":"São Paulo, SP","temp_c":22}`,
+		`{"time":"` + now.Format(time.RFC3339),
+	}, "\n")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("erro ao gravar arquivo: %v", err)
+	}
+
+	s, err := Open(path, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Open() erro inesperado = %v", err)
+	}
+	defer s.Close()
+
+	total, _, err := s.Summarize("São Paulo, SP", now.Add(-72*time.Hour), now.Add(time.Minute), 0)
+	if err != nil || total.Observations != 1 || total.Min != 22 {
+		t.Errorf("Summarize() = %+v, %v, esperava somente a leitura de 22°C", total, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("erro ao ler arquivo: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 1 {
+		t.Errorf("arquivo com %d linhas após a limpeza, esperava 1", lines)
+	}
+}
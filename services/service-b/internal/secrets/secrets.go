@@ -0,0 +1,98 @@
+package secrets
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// ReadFile lê um segredo montado como arquivo (Docker/Kubernetes secrets),
+// sem os espaços e a quebra de linha finais
+func ReadFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("erro ao ler segredo %s: %w", path, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ParseList separa uma lista de segredos por vírgula ou quebra de linha,
+// ignorando itens vazios
+func ParseList(value string) []string {
+	var values []string
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r'
+	}) {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// Watcher relê periodicamente um arquivo de segredo e chama onChange quando
+// o conteúdo muda. O conteúdo é comparado pelo hash e nunca é registrado em
+// log; leituras com erro ou arquivo vazio (por exemplo durante a troca do
+// segredo) mantêm o valor atual.
+type Watcher struct {
+	path     string
+	interval time.Duration
+	onChange func(value string)
+	current  [sha256.Size]byte
+}
+
+// NewWatcher cria o observador do arquivo path; o conteúdo atual é a base
+// de comparação e não dispara onChange
+func NewWatcher(path string, interval time.Duration, onChange func(value string)) *Watcher {
+	w := &Watcher{
+		path:     path,
+		interval: interval,
+		onChange: onChange,
+	}
+	if value, err := ReadFile(path); err == nil {
+		w.current = sha256.Sum256([]byte(value))
+	}
+	return w
+}
+
+// Check relê o arquivo e indica se o segredo mudou
+func (w *Watcher) Check() bool {
+	value, err := ReadFile(w.path)
+	if err != nil {
+		log.Printf("Aviso: %v; mantendo o valor atual", err)
+		return false
+	}
+	if value == "" {
+		log.Printf("Aviso: segredo %s vazio; mantendo o valor atual", w.path)
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(value))
+	if sum == w.current {
+		return false
+	}
+	w.current = sum
+	w.onChange(value)
+	return true
+}
+
+// Run verifica o arquivo a cada interval até ctx ser cancelado
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if w.Check() {
+				log.Printf("🔑 Segredo %s atualizado", w.path)
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseList(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{"Uma chave", "abc", []string{"abc"}},
+		{"Separadas por vírgula", "abc, def", []string{"abc", "def"}},
+		{"Uma por linha", "abc\r\ndef\n\n", []string{"abc", "def"}},
+		{"Vazio", " \n", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseList(tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseList(%q) = %v, esperava %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatcher_Check(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weather_api_key")
+	write := func(value string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(value), 0o600); err != nil {
+			t.Fatalf("WriteFile() erro inesperado = %v", err)
+		}
+	}
+	write("old\n")
+
+	var got []string
+	w := NewWatcher(path, time.Minute, func(value string) {
+		got = append(got, value)
+	})
+
+	steps := []struct {
+		name        string
+		value       string
+		remove      bool
+		wantChanged bool
+	}{
+		{"Sem mudança", "old\n", false, false},
+		{"Nova chave", "new\n", false, true},
+		{"Arquivo vazio mantém a chave", "", false, false},
+		{"Arquivo removido mantém a chave", "", true, false},
+		{"Mesma chave após falha de leitura", "new", false, false},
+		{"Outra chave", "newer", false, true},
+	}
+	for _, step := range steps {
+		if step.remove {
+			os.Remove(path)
+		} else {
+			write(step.value)
+		}
+		if changed := w.Check(); changed != step.wantChanged {
+			t.Errorf("%s: Check() = %v, esperava %v", step.name, changed, step.wantChanged)
+		}
+	}
+
+	if want := []string{"new", "newer"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("onChange recebeu %v, esperava %v", got, want)
+	}
+}
@@ -1,14 +1,97 @@
 package service
 
 import (
+	"errors"
 	"math"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/lcidral/goExpertOtel/pkg/models"
 )
 
+// MaxPrecision maior número de casas decimais aceito nas opções de conversão
+const MaxPrecision = 6
+
+// Erros das opções de conversão; as mensagens são as da API
+var (
+	ErrInvalidScale     = errors.New(models.ErrInvalidScale)
+	ErrInvalidPrecision = errors.New(models.ErrInvalidPrecision)
+	ErrInvalidMode      = errors.New(models.ErrInvalidMode)
+)
+
 // TemperatureConverter serviço para conversão de temperaturas
 type TemperatureConverter struct{}
 
+// ConversionOptions escalas, casas decimais e modo pedidos na requisição
+type ConversionOptions struct {
+	Scales    []models.TemperatureScale
+	Precision int
+	// Strict usa K = C + 273.15 em vez de K = C + 273; afeta somente o Kelvin
+	Strict bool
+}
+
+// DefaultConversionOptions opções equivalentes ao contrato original: C, F e K
+// com uma casa decimal e K = C + 273
+func DefaultConversionOptions() ConversionOptions {
+	return ConversionOptions{
+		Scales:    []models.TemperatureScale{models.ScaleCelsius, models.ScaleFahrenheit, models.ScaleKelvin},
+		Precision: 1,
+	}
+}
+
+// ParseConversionOptions valida as opções de conversão (parâmetros scales,
+// precision e mode); opções ausentes mantêm o valor padrão
+func ParseConversionOptions(values url.Values) (ConversionOptions, error) {
+	options := DefaultConversionOptions()
+
+	if raw := values.Get(models.TemperatureScalesParam); raw != "" {
+		options.Scales = nil
+		seen := make(map[models.TemperatureScale]bool)
+		for _, name := range strings.Split(raw, ",") {
+			scale, ok := parseScale(strings.TrimSpace(name))
+			if !ok {
+				return ConversionOptions{}, ErrInvalidScale
+			}
+			if !seen[scale] {
+				seen[scale] = true
+				options.Scales = append(options.Scales, scale)
+			}
+		}
+	}
+
+	if raw := values.Get(models.TemperaturePrecisionParam); raw != "" {
+		precision, err := strconv.Atoi(raw)
+		if err != nil || precision < 0 || precision > MaxPrecision {
+			return ConversionOptions{}, ErrInvalidPrecision
+		}
+		options.Precision = precision
+	}
+
+	switch strings.ToLower(values.Get(models.TemperatureModeParam)) {
+	case "", models.TemperatureModeSpec:
+	case models.TemperatureModeStrict:
+		options.Strict = true
+	default:
+		return ConversionOptions{}, ErrInvalidMode
+	}
+
+	return options, nil
+}
+
+// parseScale reconhece o nome de uma escala, sem diferenciar maiúsculas
+func parseScale(name string) (models.TemperatureScale, bool) {
+	for _, scale := range []models.TemperatureScale{
+		models.ScaleCelsius, models.ScaleFahrenheit, models.ScaleKelvin,
+		models.ScaleRankine, models.ScaleReaumur, models.ScaleDelisle,
+	} {
+		if strings.EqualFold(name, string(scale)) {
+			return scale, true
+		}
+	}
+	return "", false
+}
+
 // NewTemperatureConverter cria uma nova instância do conversor
 func NewTemperatureConverter() *TemperatureConverter {
 	return &TemperatureConverter{}
@@ -24,6 +107,65 @@ func (tc *TemperatureConverter) ConvertToAllUnits(celsius float64, city string)
 	}
 }
 
+// ConvertUnits converte temperatura de Celsius para Celsius, Fahrenheit e
+// Kelvin, com o mesmo arredondamento de ConvertToAllUnits
+func (tc *TemperatureConverter) ConvertUnits(celsius float64) models.TemperatureUnits {
+	return models.TemperatureUnits{
+		C: tc.roundToOneDecimal(celsius),
+		F: tc.roundToOneDecimal(tc.CelsiusToFahrenheit(celsius)),
+		K: tc.roundToOneDecimal(tc.CelsiusToKelvin(celsius)),
+	}
+}
+
+// ConvertScales converte temperatura de Celsius para as escalas das opções,
+// arredondando para as casas decimais pedidas
+func (tc *TemperatureConverter) ConvertScales(celsius float64, city string, options ConversionOptions) *models.ScaledTemperatureResponse {
+	response := &models.ScaledTemperatureResponse{City: city}
+	for _, scale := range options.Scales {
+		value := tc.round(tc.Convert(celsius, scale, options.Strict), options.Precision)
+		switch scale {
+		case models.ScaleCelsius:
+			response.TempC = &value
+		case models.ScaleFahrenheit:
+			response.TempF = &value
+		case models.ScaleKelvin:
+			response.TempK = &value
+		case models.ScaleRankine:
+			response.TempR = &value
+		case models.ScaleReaumur:
+			response.TempRe = &value
+		case models.ScaleDelisle:
+			response.TempDe = &value
+		}
+	}
+	return response
+}
+
+// Convert converte temperatura de Celsius para a escala informada, sem
+// arredondar; strict usa o zero absoluto exato (273.15) no Kelvin. Rankine é
+// uma escala absoluta e sempre parte do zero absoluto exato.
+func (tc *TemperatureConverter) Convert(celsius float64, scale models.TemperatureScale, strict bool) float64 {
+	kelvin := tc.CelsiusToKelvin(celsius)
+	if strict {
+		kelvin = tc.CelsiusToKelvinStrict(celsius)
+	}
+
+	switch scale {
+	case models.ScaleFahrenheit:
+		return tc.CelsiusToFahrenheit(celsius)
+	case models.ScaleKelvin:
+		return kelvin
+	case models.ScaleRankine:
+		return tc.KelvinToRankine(tc.CelsiusToKelvinStrict(celsius))
+	case models.ScaleReaumur:
+		return tc.CelsiusToReaumur(celsius)
+	case models.ScaleDelisle:
+		return tc.CelsiusToDelisle(celsius)
+	default:
+		return celsius
+	}
+}
+
 // CelsiusToFahrenheit converte Celsius para Fahrenheit
 // Fórmula: F = C * 1.8 + 32
 func (tc *TemperatureConverter) CelsiusToFahrenheit(celsius float64) float64 {
@@ -36,6 +178,32 @@ func (tc *TemperatureConverter) CelsiusToKelvin(celsius float64) float64 {
 	return celsius + 273
 }
 
+// CelsiusToKelvinStrict converte Celsius para Kelvin com o zero absoluto exato
+// Fórmula: K = C + 273.15
+func (tc *TemperatureConverter) CelsiusToKelvinStrict(celsius float64) float64 {
+	return celsius + 273.15
+}
+
+// KelvinToRankine converte Kelvin absoluto (C + 273.15) para Rankine,
+// de modo que 0°C equivale a 491.67°R
+// Fórmula: R = K * 1.8
+func (tc *TemperatureConverter) KelvinToRankine(kelvin float64) float64 {
+	return kelvin * 1.8
+}
+
+// CelsiusToReaumur converte Celsius para Réaumur
+// Fórmula: Re = C * 0.8
+func (tc *TemperatureConverter) CelsiusToReaumur(celsius float64) float64 {
+	return celsius * 0.8
+}
+
+// CelsiusToDelisle converte Celsius para Delisle; a escala é invertida
+// (a água ferve a 0°De e congela a 150°De)
+// Fórmula: De = (100 - C) * 1.5
+func (tc *TemperatureConverter) CelsiusToDelisle(celsius float64) float64 {
+	return (100 - celsius) * 1.5
+}
+
 // FahrenheitToCelsius converte Fahrenheit para Celsius
 // Fórmula: C = (F - 32) / 1.8
 func (tc *TemperatureConverter) FahrenheitToCelsius(fahrenheit float64) float64 {
@@ -53,6 +221,13 @@ func (tc *TemperatureConverter) roundToOneDecimal(value float64) float64 {
 	return math.Round(value*10) / 10
 }
 
+// round arredonda para precision casas decimais; com uma casa é idêntico a
+// roundToOneDecimal
+func (tc *TemperatureConverter) round(value float64, precision int) float64 {
+	scale := math.Pow(10, float64(precision))
+	return math.Round(value*scale) / scale
+}
+
 // ValidateTemperature valida se a temperatura está dentro de limites razoáveis
 func (tc *TemperatureConverter) ValidateTemperature(celsius float64) bool {
 	// Limites razoáveis para temperatura terrestre: -100°C a 60°C
@@ -1,7 +1,11 @@
 package service
 
 import (
+	"encoding/json"
+	"net/url"
 	"testing"
+
+	"github.com/lcidral/goExpertOtel/pkg/models"
 )
 
 func TestTemperatureConverter_CelsiusToFahrenheit(t *testing.T) {
@@ -205,6 +209,82 @@ func TestTemperatureConverter_GetTemperatureDescription(t *testing.T) {
 	}
 }
 
+func TestTemperatureConverter_ConvertScales(t *testing.T) {
+	converter := NewTemperatureConverter()
+
+	tests := []struct {
+		name    string
+		celsius float64
+		query   string
+		want    string
+	}{
+		{"Padrão", 25.04, "", `{"city":"São Paulo","temp_C":25,"temp_F":77.1,"temp_K":298}`},
+		{"Novas escalas", 25, "scales=R,Re,De", `{"city":"São Paulo","temp_R":536.7,"temp_Re":20,"temp_De":112.5}`},
+		{"Modo estrito", 25, "scales=k,r&mode=strict&precision=2", `{"city":"São Paulo","temp_K":298.15,"temp_R":536.67}`},
+		{"Sem casas decimais", 25.56, "scales=C,C,F&precision=0", `{"city":"São Paulo","temp_C":26,"temp_F":78}`},
+		{"Delisle abaixo de zero", 120, "scales=De", `{"city":"São Paulo","temp_De":-30}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			options, err := ParseConversionOptions(values)
+			if err != nil {
+				t.Fatalf("ParseConversionOptions(%q) erro inesperado = %v", tt.query, err)
+			}
+			got, _ := json.Marshal(converter.ConvertScales(tt.celsius, "São Paulo", options))
+			if string(got) != tt.want {
+				t.Errorf("ConvertScales(%v, %q) = %s, esperava %s", tt.celsius, tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemperatureConverter_ConvertScalesDefaultMatchesContract(t *testing.T) {
+	converter := NewTemperatureConverter()
+
+	// As opções padrão produzem exatamente o JSON de temp_C/temp_F/temp_K
+	for celsius := -40.0; celsius <= 50; celsius += 0.37 {
+		want, _ := json.Marshal(converter.ConvertToAllUnits(celsius, "Curitiba"))
+		got, _ := json.Marshal(converter.ConvertScales(celsius, "Curitiba", DefaultConversionOptions()))
+		if string(got) != string(want) {
+			t.Fatalf("ConvertScales(%v) = %s, esperava %s", celsius, got, want)
+		}
+	}
+}
+
+func TestParseConversionOptions_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr error
+	}{
+		{"Escala desconhecida", "scales=C,X", ErrInvalidScale},
+		{"Escala vazia na lista", "scales=C,,F", ErrInvalidScale},
+		{"Precisão negativa", "precision=-1", ErrInvalidPrecision},
+		{"Precisão acima do máximo", "precision=7", ErrInvalidPrecision},
+		{"Precisão não numérica", "precision=duas", ErrInvalidPrecision},
+		{"Modo desconhecido", "mode=exact", ErrInvalidMode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			if _, err := ParseConversionOptions(values); err != tt.wantErr {
+				t.Errorf("ParseConversionOptions(%q) erro = %v, esperava %v", tt.query, err, tt.wantErr)
+			}
+		})
+	}
+
+	options, err := ParseConversionOptions(url.Values{"mode": {"Strict"}})
+	if err != nil || !options.Strict || len(options.Scales) != 3 || options.Precision != 1 {
+		t.Errorf("ParseConversionOptions(mode=Strict) = %+v, %v, esperava C/F/K, uma casa e modo estrito", options, err)
+	}
+	if options.Scales[0] != models.ScaleCelsius {
+		t.Errorf("Scales = %v, esperava C primeiro", options.Scales)
+	}
+}
+
 // abs retorna o valor absoluto de um float64
 func abs(x float64) float64 {
 	if x < 0 {
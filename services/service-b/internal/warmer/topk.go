@@ -0,0 +1,144 @@
+package warmer
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+)
+
+// TopK estima os CEPs mais consultados com memória limitada (algoritmo
+// Space-Saving): ao atingir a capacidade, o CEP menos contado é substituído
+// pelo novo, que herda a contagem dele. Decay reduz as contagens para que a
+// lista reflita o tráfego recente.
+//
+// Os CEPs ficam agrupados em faixas de mesma contagem, em ordem crescente
+// (stream-summary), o que mantém Record em O(1) mesmo com a capacidade cheia.
+type TopK struct {
+	capacity int
+
+	mu      sync.Mutex
+	counts  map[string]*counter
+	buckets *list.List // faixas de contagem, da menor para a maior
+}
+
+// counter contagem de um CEP acompanhado
+type counter struct {
+	cep    string
+	bucket *list.Element // faixa com a contagem do CEP
+	entry  *list.Element // posição do CEP dentro da faixa
+}
+
+// countBucket CEPs com a mesma contagem
+type countBucket struct {
+	count int64
+	ceps  *list.List // frente: contado mais recentemente
+}
+
+// NewTopK cria um contador que acompanha até capacity CEPs distintos
+func NewTopK(capacity int) *TopK {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &TopK{
+		capacity: capacity,
+		counts:   make(map[string]*counter, capacity),
+		buckets:  list.New(),
+	}
+}
+
+// Record registra uma consulta ao CEP
+func (t *TopK) Record(cep string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if c, found := t.counts[cep]; found {
+		t.increment(c)
+		return
+	}
+
+	if len(t.counts) < t.capacity {
+		first := t.buckets.Front()
+		if first == nil || first.Value.(*countBucket).count != 1 {
+			first = t.buckets.PushFront(&countBucket{count: 1, ceps: list.New()})
+		}
+		c := &counter{cep: cep, bucket: first}
+		c.entry = first.Value.(*countBucket).ceps.PushFront(c)
+		t.counts[cep] = c
+		return
+	}
+
+	// Substitui o CEP contado há mais tempo da faixa com menor contagem
+	c := t.buckets.Front().Value.(*countBucket).ceps.Back().Value.(*counter)
+	delete(t.counts, c.cep)
+	c.cep = cep
+	t.counts[cep] = c
+	t.increment(c)
+}
+
+// increment move o CEP para a faixa com a contagem seguinte
+func (t *TopK) increment(c *counter) {
+	current := c.bucket
+	bucket := current.Value.(*countBucket)
+	next := current.Next()
+	if next == nil || next.Value.(*countBucket).count != bucket.count+1 {
+		next = t.buckets.InsertAfter(&countBucket{count: bucket.count + 1, ceps: list.New()}, current)
+	}
+
+	bucket.ceps.Remove(c.entry)
+	if bucket.ceps.Len() == 0 {
+		t.buckets.Remove(current)
+	}
+	c.bucket = next
+	c.entry = next.Value.(*countBucket).ceps.PushFront(c)
+}
+
+// Top retorna os k CEPs mais consultados, do mais para o menos consultado
+func (t *TopK) Top(k int) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var ceps []string
+	for b := t.buckets.Back(); b != nil; b = b.Prev() {
+		start := len(ceps)
+		for e := b.Value.(*countBucket).ceps.Front(); e != nil; e = e.Next() {
+			ceps = append(ceps, e.Value.(*counter).cep)
+		}
+		// Empates em ordem de CEP, para um resultado estável
+		sort.Strings(ceps[start:])
+		if k > 0 && len(ceps) >= k {
+			return ceps[:k]
+		}
+	}
+	return ceps
+}
+
+// Decay divide as contagens pela metade, removendo CEPs que chegam a zero
+func (t *TopK) Decay() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// A divisão preserva a ordem das faixas; faixas vizinhas que passam a ter
+	// a mesma contagem são unidas
+	for b := t.buckets.Front(); b != nil; {
+		next := b.Next()
+		bucket := b.Value.(*countBucket)
+		bucket.count /= 2
+
+		switch prev := b.Prev(); {
+		case bucket.count == 0:
+			for e := bucket.ceps.Front(); e != nil; e = e.Next() {
+				delete(t.counts, e.Value.(*counter).cep)
+			}
+			t.buckets.Remove(b)
+		case prev != nil && prev.Value.(*countBucket).count == bucket.count:
+			merged := prev.Value.(*countBucket).ceps
+			for e := bucket.ceps.Front(); e != nil; e = e.Next() {
+				c := e.Value.(*counter)
+				c.bucket = prev
+				c.entry = merged.PushBack(c)
+			}
+			t.buckets.Remove(b)
+		}
+		b = next
+	}
+}
@@ -0,0 +1,289 @@
+package warmer
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lcidral/goExpertOtel/pkg/telemetry"
+)
+
+// Refresher atualiza no cache a localização e o clima de um CEP quando os
+// itens estão ausentes ou expiram dentro de window. beforeFetch é chamado
+// antes de cada chamada a uma API externa e pode bloquear (limite de taxa)
+// ou cancelar a atualização retornando erro.
+type Refresher interface {
+	Refresh(ctx context.Context, cep string, window time.Duration, beforeFetch func(ctx context.Context) error) (refreshed int, err error)
+}
+
+// Source fornece a lista de CEPs a aquecer em cada ciclo
+type Source interface {
+	Name() string
+	CEPs() ([]string, error)
+}
+
+// Options configura o aquecimento
+type Options struct {
+	// Interval tempo entre o início de dois ciclos; itens que expiram antes
+	// do próximo ciclo são atualizados
+	Interval time.Duration
+	// Rate chamadas por segundo permitidas às APIs externas
+	Rate float64
+}
+
+var (
+	cepsCounter, _ = telemetry.Meter().Int64Counter("cache.warmer.ceps",
+		metric.WithDescription("CEPs processed by the cache warmer, by outcome"),
+	)
+	fetchesCounter, _ = telemetry.Meter().Int64Counter("cache.warmer.fetches",
+		metric.WithDescription("External API calls made by the cache warmer"),
+	)
+)
+
+// Warmer atualiza periodicamente no cache os CEPs fornecidos pela Source,
+// antes que expirem, respeitando o limite de chamadas às APIs externas
+type Warmer struct {
+	source    Source
+	refresher Refresher
+	options   Options
+
+	cycles    atomic.Int64
+	total     atomic.Int64
+	processed atomic.Int64
+	fetches   atomic.Int64
+	failed    atomic.Int64
+}
+
+// New cria o warmer
+func New(source Source, refresher Refresher, options Options) *Warmer {
+	if options.Interval <= 0 {
+		options.Interval = 5 * time.Minute
+	}
+	if options.Rate <= 0 {
+		options.Rate = 1
+	}
+	w := &Warmer{
+		source:    source,
+		refresher: refresher,
+		options:   options,
+	}
+
+	// Progresso do ciclo atual
+	_, err := telemetry.Meter().Int64ObservableGauge("cache.warmer.pending",
+		metric.WithDescription("CEPs not yet processed in the current cache warmer cycle"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(w.total.Load() - w.processed.Load())
+			return nil
+		}),
+	)
+	if err != nil {
+		log.Printf("Aviso: falha ao registrar métrica do aquecimento do cache: %v", err)
+	}
+	return w
+}
+
+// Run executa ciclos de aquecimento até ctx ser cancelado
+func (w *Warmer) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		w.RunCycle(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunCycle atualiza uma vez todos os CEPs da Source
+func (w *Warmer) RunCycle(ctx context.Context) {
+	ctx, span := telemetry.StartSpan(ctx, "cache.warm",
+		attribute.String("warmer.source", w.source.Name()),
+	)
+	defer span.End()
+
+	ceps, err := w.source.CEPs()
+	if err != nil {
+		log.Printf("Erro ao obter CEPs para aquecimento do cache: %v", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to load CEPs")
+		return
+	}
+
+	w.cycles.Add(1)
+	w.total.Store(int64(len(ceps)))
+	w.processed.Store(0)
+	span.SetAttributes(attribute.Int("warmer.ceps", len(ceps)))
+
+	limiter := newLimiter(w.options.Rate)
+	defer limiter.stop()
+	beforeFetch := func(ctx context.Context) error {
+		if err := limiter.wait(ctx); err != nil {
+			return err
+		}
+		w.fetches.Add(1)
+		fetchesCounter.Add(ctx, 1)
+		return nil
+	}
+
+	var refreshed, fresh, failed int
+	for _, cep := range ceps {
+		if ctx.Err() != nil {
+			break
+		}
+
+		count, err := w.refresher.Refresh(ctx, cep, w.options.Interval, beforeFetch)
+		outcome := "fresh"
+		switch {
+		case err != nil && ctx.Err() != nil:
+			outcome = "canceled"
+		case err != nil:
+			outcome = "failed"
+			failed++
+			w.failed.Add(1)
+			span.AddEvent("cache.warm.failed", trace.WithAttributes(
+				attribute.String("cep.value", cep),
+				attribute.String("error.message", err.Error()),
+			))
+		case count > 0:
+			outcome = "refreshed"
+			refreshed++
+		default:
+			fresh++
+		}
+		cepsCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("warmer.outcome", outcome)))
+		w.processed.Add(1)
+	}
+
+	span.SetAttributes(
+		attribute.Int("warmer.refreshed", refreshed),
+		attribute.Int("warmer.fresh", fresh),
+		attribute.Int("warmer.failed", failed),
+	)
+	if ctx.Err() != nil {
+		span.SetStatus(codes.Error, "Warm cycle interrupted")
+		return
+	}
+	span.SetStatus(codes.Ok, "Warm cycle completed")
+	log.Printf("🔥 Aquecimento do cache: %d CEPs, %d atualizados, %d já frescos, %d falhas",
+		len(ceps), refreshed, fresh, failed)
+}
+
+// Stats retorna o progresso do aquecimento
+func (w *Warmer) Stats() map[string]interface{} {
+	return map[string]interface{}{
+		"source":    w.source.Name(),
+		"cycles":    w.cycles.Load(),
+		"ceps":      w.total.Load(),
+		"processed": w.processed.Load(),
+		"fetches":   w.fetches.Load(),
+		"failures":  w.failed.Load(),
+	}
+}
+
+// limiter libera uma chamada a cada 1/rate segundos
+type limiter struct {
+	ticker *time.Ticker
+}
+
+func newLimiter(rate float64) *limiter {
+	return &limiter{ticker: time.NewTicker(time.Duration(float64(time.Second) / rate))}
+}
+
+func (l *limiter) wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-l.ticker.C:
+		return nil
+	}
+}
+
+func (l *limiter) stop() {
+	l.ticker.Stop()
+}
+
+// FileSource lê os CEPs de um arquivo com um CEP por linha; linhas vazias e
+// iniciadas por # são ignoradas. O arquivo é relido a cada ciclo.
+type FileSource struct {
+	path string
+}
+
+// NewFileSource cria a fonte a partir do arquivo informado
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: path}
+}
+
+// Name identifica a fonte
+func (s *FileSource) Name() string {
+	return "file"
+}
+
+// CEPs lê o arquivo
+func (s *FileSource) CEPs() ([]string, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir lista de CEPs: %w", err)
+	}
+	defer file.Close()
+
+	return ParseList(file)
+}
+
+// ParseList lê uma lista de CEPs, um por linha, sem duplicados
+func ParseList(r io.Reader) ([]string, error) {
+	var ceps []string
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || seen[line] {
+			continue
+		}
+		seen[line] = true
+		ceps = append(ceps, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler lista de CEPs: %w", err)
+	}
+	return ceps, nil
+}
+
+// TrafficSource fornece os CEPs mais consultados recentemente
+type TrafficSource struct {
+	topK *TopK
+	k    int
+}
+
+// NewTrafficSource cria a fonte com os k CEPs mais consultados
+func NewTrafficSource(topK *TopK, k int) *TrafficSource {
+	return &TrafficSource{topK: topK, k: k}
+}
+
+// Name identifica a fonte
+func (s *TrafficSource) Name() string {
+	return "traffic"
+}
+
+// CEPs retorna os mais consultados e reduz as contagens, para que CEPs que
+// deixaram de ser consultados saiam da lista nos próximos ciclos
+func (s *TrafficSource) CEPs() ([]string, error) {
+	ceps := s.topK.Top(s.k)
+	s.topK.Decay()
+	return ceps, nil
+}
@@ -0,0 +1,152 @@
+package warmer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTopK(t *testing.T) {
+	topK := NewTopK(3)
+
+	for _, cep := range []string{"01310100", "01310100", "01310100", "80010000", "80010000", "20040002"} {
+		topK.Record(cep)
+	}
+
+	if top := topK.Top(2); !reflect.DeepEqual(top, []string{"01310100", "80010000"}) {
+		t.Errorf("Top(2) = %v, esperava [01310100 80010000]", top)
+	}
+
+	// Com a capacidade cheia o CEP novo substitui o menos contado, herdando a contagem
+	topK.Record("30130010")
+	if top := topK.Top(0); !reflect.DeepEqual(top, []string{"01310100", "30130010", "80010000"}) {
+		t.Errorf("Top(0) = %v, esperava 30130010 no lugar de 20040002", top)
+	}
+
+	// Contagens: 3, 2 e 2; após dois decays todas chegam a zero
+	topK.Decay()
+	if top := topK.Top(0); len(top) != 3 {
+		t.Errorf("Top(0) após um decay = %v, esperava os 3 CEPs", top)
+	}
+	topK.Decay()
+	if top := topK.Top(0); len(top) != 0 {
+		t.Errorf("Top(0) após dois decays = %v, esperava lista vazia", top)
+	}
+}
+
+func TestTopK_DecayMergesCounts(t *testing.T) {
+	topK := NewTopK(4)
+
+	// Contagens 6, 5, 4 e 1; após o decay ficam 3, 2, 2 e nenhuma
+	for cep, count := range map[string]int{"01310100": 6, "80010000": 5, "20040002": 4, "30130010": 1} {
+		for i := 0; i < count; i++ {
+			topK.Record(cep)
+		}
+	}
+	topK.Decay()
+
+	// 20040002 empata com 80010000 e passa à frente com mais uma consulta
+	topK.Record("20040002")
+	if top := topK.Top(0); !reflect.DeepEqual(top, []string{"01310100", "20040002", "80010000"}) {
+		t.Errorf("Top(0) = %v, esperava [01310100 20040002 80010000]", top)
+	}
+}
+
+func BenchmarkTopK_Record(b *testing.B) {
+	topK := NewTopK(10000)
+	ceps := make([]string, 50000)
+	for i := range ceps {
+		ceps[i] = fmt.Sprintf("%08d", i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		topK.Record(ceps[i%len(ceps)])
+	}
+}
+
+func TestParseList(t *testing.T) {
+	input := "# CEPs populares\n01310100\n\n  80010000  \n01310100\n"
+
+	ceps, err := ParseList(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseList() erro inesperado = %v", err)
+	}
+	if !reflect.DeepEqual(ceps, []string{"01310100", "80010000"}) {
+		t.Errorf("ParseList() = %v, esperava [01310100 80010000]", ceps)
+	}
+}
+
+type staticSource []string
+
+func (s staticSource) Name() string            { return "static" }
+func (s staticSource) CEPs() ([]string, error) { return s, nil }
+
+// fakeRefresher simula o handler: cada CEP da lista stale exige duas chamadas externas
+type fakeRefresher struct {
+	mu      sync.Mutex
+	stale   map[string]bool
+	fetches int
+}
+
+func (f *fakeRefresher) Refresh(ctx context.Context, cep string, window time.Duration, beforeFetch func(ctx context.Context) error) (int, error) {
+	if cep == "00000000" {
+		return 0, errors.New("falha na API")
+	}
+	if !f.stale[cep] {
+		return 0, nil
+	}
+	for i := 0; i < 2; i++ {
+		if err := beforeFetch(ctx); err != nil {
+			return i, err
+		}
+		f.mu.Lock()
+		f.fetches++
+		f.mu.Unlock()
+	}
+	return 2, nil
+}
+
+func TestWarmer_RunCycle(t *testing.T) {
+	refresher := &fakeRefresher{stale: map[string]bool{"01310100": true, "80010000": true}}
+	w := New(staticSource{"01310100", "20040002", "80010000", "00000000"}, refresher, Options{
+		Interval: time.Minute,
+		Rate:     50,
+	})
+
+	start := time.Now()
+	w.RunCycle(context.Background())
+	elapsed := time.Since(start)
+
+	stats := w.Stats()
+	if stats["processed"] != int64(4) || stats["fetches"] != int64(4) || stats["failures"] != int64(1) {
+		t.Errorf("Stats() = %v, esperava 4 processados, 4 chamadas e 1 falha", stats)
+	}
+
+	// As chamadas externas respeitam o limite de 50 por segundo: 4 chamadas levam ao menos 80ms
+	if elapsed < 75*time.Millisecond {
+		t.Errorf("RunCycle() levou %v, esperava ao menos 80ms com o limite de taxa", elapsed)
+	}
+}
+
+func TestWarmer_RunCycleCanceled(t *testing.T) {
+	refresher := &fakeRefresher{stale: map[string]bool{"01310100": true, "80010000": true}}
+	w := New(staticSource{"01310100", "80010000"}, refresher, Options{Rate: 0.1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	w.RunCycle(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("RunCycle() levou %v após o cancelamento", elapsed)
+	}
+	if refresher.fetches != 0 {
+		t.Errorf("chamadas externas = %d, esperava 0 antes do limite liberar", refresher.fetches)
+	}
+}